@@ -0,0 +1,37 @@
+// Command mailer consumes the mail queue and delivers messages over SMTP.
+package main
+
+import (
+	"context"
+	"golang/internal/core/services"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/config"
+	"golang/internal/infrastructure/database/connections"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+
+func main() {
+	if !config.RabbitConfigured() || !config.SmtpConfigured() {
+		log.Fatal("mailer needs both RABBIT_HOST and SMTP_HOST")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var redisClient *clients.RedisClient
+	if config.RedisConfigured() {
+		redisClient = clients.NewRedisClient("", connections.NewRedisConnection())
+	}
+
+	queue := clients.NewMailQueue(clients.NewRabbitClient(), clients.NewSmtpClient())
+	worker := services.NewMailWorker(queue, redisClient)
+
+	log.Printf("Mailer is consuming %s", clients.MailQueueName)
+	if err := worker.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
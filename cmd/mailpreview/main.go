@@ -0,0 +1,64 @@
+// Command mailpreview renders the transactional emails with sample data.
+//
+//	go run ./cmd/mailpreview -list
+//	go run ./cmd/mailpreview -template digest -locale en > digest.html
+//	go run ./cmd/mailpreview -out preview/   # every template, locale and part
+package main
+
+import (
+	"flag"
+	"fmt"
+	"golang/templates"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+
+func main() {
+	list := flag.Bool("list", false, "list templates and locales")
+	name := flag.String("template", "", "template to render")
+	locale := flag.String("locale", templates.DefaultLocale, "locale to render in")
+	format := flag.String("format", "html", "part to print: html or text")
+	out := flag.String("out", "", "render every template into this directory")
+	flag.Parse()
+
+	switch {
+	case *list:
+		fmt.Println("templates:", templates.Names())
+		fmt.Println("locales:", templates.Locales)
+
+	case *out != "":
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range templates.Names() {
+			for _, locale := range templates.Locales {
+				email, err := templates.Render(name, locale, templates.Sample(name))
+				if err != nil {
+					log.Fatalf("%s/%s: %v", locale, name, err)
+				}
+				base := filepath.Join(*out, locale+"_"+name)
+				os.WriteFile(base+".html", []byte(email.HTML), 0o644)
+				os.WriteFile(base+".txt", []byte("Subject: "+email.Subject+"\n\n"+email.Text), 0o644)
+			}
+		}
+		log.Printf("Rendered %d templates into %s", len(templates.Names()), *out)
+
+	case *name != "":
+		email, err := templates.Render(*name, *locale, templates.Sample(*name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintln(os.Stderr, "Subject:", email.Subject)
+		if *format == "text" {
+			fmt.Print(email.Text)
+		} else {
+			fmt.Print(email.HTML)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"golang/internal/core/services"
 	"golang/internal/handlers/dependencies"
 	"golang/internal/handlers/setup"
 	"golang/internal/handlers/v1"
+	"golang/internal/infrastructure/config"
 	"golang/internal/infrastructure/database/connections"
 	"log"
 	"net/http"
@@ -16,25 +19,55 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// redis := connections.NewRedisConnection()
+	conns := setup.NewConnections(db)
 
-	// rabbit, err := connections.NewRabbitConnection()
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
+	if conns.Rabbit != nil {
+		go services.NewOutboxRelay(db, conns.Rabbit).Run(context.Background())
+		go services.NewWebhookDispatcher(db, conns.Rabbit, config.LoadWebhookConfig()).Run(context.Background())
+	} else {
+		log.Printf("RABBIT_HOST is not set, domain events stay in the outbox and webhooks are not delivered")
+	}
+
+	if conns.Mail != nil {
+		go services.NewDigestService(db, conns.Mail).Run(context.Background())
+	}
+
+	go services.NewTrashPurger(db, config.LoadTrashConfig()).Run(context.Background())
+	go services.NewAttachmentCleaner(db, conns.Blobs, config.LoadStorageConfig()).Run(context.Background())
 
 	server := http.NewServeMux()
 
-	userHandler, _ := setup.InitNewHandler(&handlers.UserHandler{}, db)
-	authHandler, _ := setup.InitNewHandler(&handlers.AuthHandler{}, db)
-	documentHandler, _ := setup.InitNewHandler(&handlers.DocumentHandler{}, db)
+	userHandler, _ := setup.InitNewHandler(&handlers.UserHandler{}, conns)
+	authHandler, _ := setup.InitNewHandler(&handlers.AuthHandler{}, conns)
+	documentHandler, _ := setup.InitNewHandler(&handlers.DocumentHandler{}, conns)
+	apiKeyHandler, _ := setup.InitNewHandler(&handlers.ApiKeyHandler{}, conns)
+	webhookHandler, _ := setup.InitNewHandler(&handlers.WebhookHandler{}, conns)
+	notificationHandler, _ := setup.InitNewHandler(&handlers.NotificationHandler{}, conns)
+	auditHandler, _ := setup.InitNewHandler(&handlers.AuditHandler{}, conns)
+	workspaceHandler, _ := setup.InitNewHandler(&handlers.WorkspaceHandler{}, conns)
+	folderHandler, _ := setup.InitNewHandler(&handlers.FolderHandler{}, conns)
+	templateHandler, _ := setup.InitNewHandler(&handlers.TemplateHandler{}, conns)
+	mailTemplateHandler, _ := setup.InitNewHandler(&handlers.MailTemplateHandler{}, conns)
+	devHandler, _ := setup.InitNewHandler(&handlers.DevHandler{}, conns)
 	
-	authDependency := deps.NewAuthDependency(authHandler.Service)
+	authDependency := deps.NewAuthDependency(authHandler.Service, apiKeyHandler.Service)
 
 	userHandler.SetupRoutes(server, "/api/v1", authDependency)
 	authHandler.SetupRoutes(server, "/api/v1", authDependency)
 	documentHandler.SetupRoutes(server, "/api/v1", authDependency)
+	apiKeyHandler.SetupRoutes(server, "/api/v1", authDependency)
+	webhookHandler.SetupRoutes(server, "/api/v1", authDependency)
+	notificationHandler.SetupRoutes(server, "/api/v1", authDependency)
+	auditHandler.SetupRoutes(server, "/api/v1", authDependency)
+	workspaceHandler.SetupRoutes(server, "/api/v1", authDependency)
+	folderHandler.SetupRoutes(server, "/api/v1", authDependency)
+	templateHandler.SetupRoutes(server, "/api/v1", authDependency)
+	mailTemplateHandler.SetupRoutes(server, "/api/v1", authDependency)
+	devHandler.SetupRoutes(server, "/api/v1", authDependency)
+	documentHandler.SetupSocket(documentHandler.Socket, authDependency)
 	documentHandler.RunWebsocket()
+	documentHandler.RunActivityPush()
+	notificationHandler.RunPush(documentHandler.Socket)
 
 	http.ListenAndServe("localhost:8000", server)
 }
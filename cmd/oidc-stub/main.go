@@ -0,0 +1,203 @@
+// Command oidc-stub is a minimal OpenID Connect provider for local testing of
+// the SSO login flow. Every authorization request is approved immediately for
+// the email passed as login_hint (or -email), so no UI is involved.
+//
+//	go run ./cmd/oidc-stub -addr localhost:9000 -client-id local
+//
+// and configure the API with OIDC_PROVIDERS=local, OIDC_LOCAL_ISSUER=http://localhost:9000.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+
+type authorization struct {
+	ClientId      string
+	RedirectUri   string
+	Nonce         string
+	CodeChallenge string
+	Email         string
+}
+
+
+type provider struct {
+	Issuer   string
+	ClientId string
+	Email    string
+	Verified bool
+	key      *rsa.PrivateKey
+	mutex    sync.Mutex
+	codes    map[string]authorization
+}
+
+
+func (p *provider) discovery(response http.ResponseWriter, request *http.Request) {
+	writeJSON(response, http.StatusOK, map[string]any{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+
+func (p *provider) jwks(response http.ResponseWriter, request *http.Request) {
+	writeJSON(response, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "stub",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+
+func (p *provider) authorize(response http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	if query.Get("client_id") != p.ClientId || query.Get("code_challenge_method") != "S256" {
+		http.Error(response, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	email := query.Get("login_hint")
+	if email == "" {
+		email = p.Email
+	}
+
+	code := randomString()
+	p.mutex.Lock()
+	p.codes[code] = authorization{
+		ClientId:      query.Get("client_id"),
+		RedirectUri:   query.Get("redirect_uri"),
+		Nonce:         query.Get("nonce"),
+		CodeChallenge: query.Get("code_challenge"),
+		Email:         email,
+	}
+	p.mutex.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(response, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(response, request, redirect.String(), http.StatusFound)
+}
+
+
+func (p *provider) token(response http.ResponseWriter, request *http.Request) {
+	if err := request.ParseForm(); err != nil {
+		writeJSON(response, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	p.mutex.Lock()
+	auth, ok := p.codes[request.PostForm.Get("code")]
+	delete(p.codes, request.PostForm.Get("code"))
+	p.mutex.Unlock()
+
+	verifier := sha256.Sum256([]byte(request.PostForm.Get("code_verifier")))
+	if !ok ||
+		request.PostForm.Get("redirect_uri") != auth.RedirectUri ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != auth.CodeChallenge {
+		writeJSON(response, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.Issuer,
+		"aud":            auth.ClientId,
+		"sub":            "stub|" + auth.Email,
+		"email":          auth.Email,
+		"email_verified": p.Verified,
+		"nonce":          auth.Nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	})
+	idToken.Header["kid"] = "stub"
+
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		writeJSON(response, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(response, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+
+func writeJSON(response http.ResponseWriter, status int, data any) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(status)
+	json.NewEncoder(response).Encode(data)
+}
+
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "listen address")
+	clientId := flag.String("client-id", "local", "accepted client_id")
+	email := flag.String("email", "dev@example.com", "email returned when no login_hint is given")
+	verified := flag.Bool("verified", true, "value of the email_verified claim")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := &provider{
+		Issuer:   "http://" + *addr,
+		ClientId: *clientId,
+		Email:    *email,
+		Verified: *verified,
+		key:      key,
+		codes:    make(map[string]authorization),
+	}
+
+	server := http.NewServeMux()
+	server.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	server.HandleFunc("GET /jwks", p.jwks)
+	server.HandleFunc("GET /authorize", p.authorize)
+	server.HandleFunc("POST /token", p.token)
+
+	log.Printf("OIDC stub provider listening on %s", p.Issuer)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
@@ -0,0 +1,118 @@
+// Command s3-stub is a minimal S3-compatible object store for local testing
+// of the s3 attachment backend. It keeps objects as files under -dir, one
+// directory per bucket, and checks Signature Version 4 when -secret-key is
+// set. Only path-style PUT, GET and DELETE of single objects are supported.
+//
+//	go run ./cmd/s3-stub -addr localhost:9100 -access-key local -secret-key secret
+//
+// and configure the API with STORAGE_BACKEND=s3, S3_ENDPOINT=http://localhost:9100,
+// S3_BUCKET=attachments, S3_ACCESS_KEY=local, S3_SECRET_KEY=secret.
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"golang/internal/infrastructure/clients"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+
+type stub struct {
+	Dir       string
+	AccessKey string
+	SecretKey string
+	Region    string
+}
+
+
+// verify signs a copy of the request with the same date and compares the
+// Authorization headers. The payload hash must match the body.
+func (s *stub) verify(request *http.Request, body []byte) bool {
+	sum := sha256.Sum256(body)
+	if request.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		return false
+	}
+	date, err := time.Parse("20060102T150405Z", request.Header.Get("X-Amz-Date"))
+	if err != nil || time.Since(date).Abs() > 15*time.Minute {
+		return false
+	}
+
+	expected := request.Clone(request.Context())
+	expected.Header = http.Header{"X-Amz-Content-Sha256": {request.Header.Get("X-Amz-Content-Sha256")}}
+	clients.SignS3Request(expected, s.AccessKey, s.SecretKey, s.Region, date)
+	return hmac.Equal([]byte(expected.Header.Get("Authorization")), []byte(request.Header.Get("Authorization")))
+}
+
+
+func (s *stub) object(response http.ResponseWriter, request *http.Request) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		http.Error(response, "IncompleteBody", http.StatusBadRequest)
+		return
+	}
+	if s.SecretKey != "" && !s.verify(request, body) {
+		http.Error(response, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	bucket, key := request.PathValue("bucket"), request.PathValue("key")
+	if !filepath.IsLocal(bucket) {
+		http.Error(response, "InvalidBucketName", http.StatusBadRequest)
+		return
+	}
+	store := &clients.LocalBlobStore{Dir: filepath.Join(s.Dir, bucket)}
+	log.Printf("%s %s/%s (%d bytes)", request.Method, bucket, key, len(body))
+
+	switch request.Method {
+	case http.MethodPut:
+		err = store.Put(request.Context(), key, body, request.Header.Get("Content-Type"))
+	case http.MethodDelete:
+		if err = store.Delete(request.Context(), key); err == nil {
+			response.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		var blob io.ReadCloser
+		if blob, err = store.Get(request.Context(), key); err == nil {
+			defer blob.Close()
+			response.Header().Set("Content-Type", "application/octet-stream")
+			io.Copy(response, blob)
+			return
+		}
+	}
+
+	switch {
+	case errors.Is(err, clients.ErrBlobNotFound):
+		http.Error(response, "NoSuchKey", http.StatusNotFound)
+	case errors.Is(err, clients.ErrInvalidBlobKey):
+		http.Error(response, "InvalidArgument", http.StatusBadRequest)
+	case err != nil:
+		http.Error(response, "InternalError", http.StatusInternalServerError)
+		log.Printf("%s %s/%s failed: %v", request.Method, bucket, key, err)
+	}
+}
+
+
+func main() {
+	s := &stub{}
+	addr := flag.String("addr", "localhost:9100", "listen address")
+	flag.StringVar(&s.Dir, "dir", "s3-data", "directory the buckets are kept in")
+	flag.StringVar(&s.AccessKey, "access-key", "local", "expected access key")
+	flag.StringVar(&s.SecretKey, "secret-key", "", "secret key; signatures are not checked when empty")
+	flag.StringVar(&s.Region, "region", "us-east-1", "region signatures are scoped to")
+	flag.Parse()
+
+	http.HandleFunc("PUT /{bucket}/{key...}", s.object)
+	http.HandleFunc("GET /{bucket}/{key...}", s.object)
+	http.HandleFunc("DELETE /{bucket}/{key...}", s.object)
+
+	log.Printf("S3 stub listening on http://%s, objects in %s", *addr, s.Dir)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,69 @@
+// Command webhook-receiver is a local endpoint for trying webhooks out. It
+// prints every delivery and checks its signature against -secret.
+//
+//	go run ./cmd/webhook-receiver -secret whsec_... -fail 0.3
+package main
+
+import (
+	"crypto/hmac"
+	"flag"
+	"golang/internal/infrastructure/clients"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+
+func verify(secret string, header string, body []byte) bool {
+	var timestamp string
+	for _, part := range strings.Split(header, ",") {
+		if value, ok := strings.CutPrefix(part, "t="); ok {
+			timestamp = value
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(unix, 0)).Abs() > 5*time.Minute {
+		return false
+	}
+	expected := clients.SignWebhook(secret, time.Unix(unix, 0), body)
+	return hmac.Equal([]byte(expected), []byte(header))
+}
+
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "listen address")
+	secret := flag.String("secret", "", "webhook secret; signatures are not checked when empty")
+	failRate := flag.Float64("fail", 0, "fraction of deliveries answered with 500, to exercise retries")
+	flag.Parse()
+
+	http.HandleFunc("POST /", func(response http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		signature := request.Header.Get(clients.WebhookSignatureHeader)
+
+		valid := "unchecked"
+		if *secret != "" {
+			valid = strconv.FormatBool(verify(*secret, signature, body))
+		}
+		log.Printf(
+			"%s delivery=%s signature_valid=%s\n%s",
+			request.Header.Get(clients.WebhookEventHeader), request.Header.Get(clients.WebhookDeliveryHeader), valid, body,
+		)
+
+		if valid == "false" {
+			http.Error(response, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		if rand.Float64() < *failRate {
+			http.Error(response, "simulated failure", http.StatusInternalServerError)
+			return
+		}
+		response.Write([]byte("ok"))
+	})
+
+	log.Printf("Webhook receiver listening on http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
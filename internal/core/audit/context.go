@@ -0,0 +1,27 @@
+// Package audit carries the request metadata recorded with audit entries
+// from the HTTP layer down to the repositories that write them.
+package audit
+
+import "context"
+
+
+type Request struct {
+	Ip        string
+	UserAgent string
+}
+
+
+type contextKey struct{}
+
+
+func WithRequest(ctx context.Context, ip string, userAgent string) context.Context {
+	return context.WithValue(ctx, contextKey{}, Request{Ip: ip, UserAgent: userAgent})
+}
+
+
+// FromContext returns the request stored by WithRequest, or a zero Request
+// for background work such as workers and scheduled jobs.
+func FromContext(ctx context.Context) Request {
+	request, _ := ctx.Value(contextKey{}).(Request)
+	return request
+}
@@ -0,0 +1,64 @@
+package authz
+
+import (
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"slices"
+)
+
+
+const (
+	PrincipalSession = "session"
+	PrincipalApiKey  = "api_key"
+)
+
+
+// Principal is whoever a request acts as, independent of the credential that
+// proved it (session JWT, API key, ...). Every credential type is reduced to a
+// user plus a scope list and goes through the same checks.
+type Principal struct {
+	User   *models.BaseUserModel
+	Kind   string
+	Scopes []string
+}
+
+
+// implied lists scopes granted implicitly by holding another scope.
+var implied = map[string][]string{
+	utils.ScopeDocumentsWrite: {utils.ScopeDocumentsRead},
+}
+
+
+// SessionScopes are granted to interactive user sessions.
+var SessionScopes = []string{
+	utils.ScopeDocumentsRead,
+	utils.ScopeDocumentsWrite,
+	utils.ScopeComments,
+	utils.ScopeAccount,
+}
+
+
+func expand(scopes []string) []string {
+	expanded := slices.Clone(scopes)
+	for _, scope := range scopes {
+		expanded = append(expanded, implied[scope]...)
+	}
+	return expanded
+}
+
+
+// Missing returns the first required scope not covered by granted.
+func Missing(granted []string, required ...string) (string, bool) {
+	expanded := expand(granted)
+	for _, scope := range required {
+		if !slices.Contains(expanded, scope) {
+			return scope, true
+		}
+	}
+	return "", false
+}
+
+
+func (p *Principal) Missing(required ...string) (string, bool) {
+	return Missing(p.Scopes, required...)
+}
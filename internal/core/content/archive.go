@@ -0,0 +1,180 @@
+package content
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+
+// Limits for uploads, checked before anything is written.
+const (
+	MaxMarkdownSize     = 5 << 20
+	MaxArchiveSize      = 50 << 20
+	MaxArchiveExpanded  = 100 << 20
+	MaxArchiveDocuments = 500
+)
+
+
+var (
+	ErrInvalidArchive = errors.New("not a valid zip archive")
+	ErrNotUTF8        = errors.New("file is not valid UTF-8")
+	ErrInvalidHTML    = errors.New("file is not valid HTML")
+	ErrTooLarge       = errors.New("import exceeds the size limits")
+)
+
+
+// MarkdownFile is one imported file converted to Markdown. Folders is the
+// directory path inside an archive, outermost first.
+type MarkdownFile struct {
+	Folders []string
+	Name    string
+	Content string
+	// title is the title declared by the file itself, such as an HTML <title>.
+	title string
+}
+
+
+// Title returns the title declared by the file, its first heading, or its
+// name without the extension, whichever comes first.
+func (f MarkdownFile) Title() string {
+	if f.title != "" {
+		return f.title
+	}
+	if title := MarkdownTitle(f.Content); title != "" {
+		return title
+	}
+	return clampTitle(f.Name)
+}
+
+
+func (f MarkdownFile) path() string {
+	return strings.Join(append(slices.Clone(f.Folders), f.Name), "/")
+}
+
+
+// IsImportName reports whether a file name has the extension of a format
+// that can be imported: Markdown, HTML or a JSON block tree.
+func IsImportName(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown", ".html", ".htm", ".json":
+		return true
+	}
+	return false
+}
+
+
+// ReadFile converts an imported file to Markdown by its extension. HTML is
+// sanitized first, keeping only structural markup; block trees are
+// validated.
+func ReadFile(name string, source []byte) (MarkdownFile, error) {
+	text, ok := NormalizeMarkdown(source)
+	if !ok {
+		return MarkdownFile{}, ErrNotUTF8
+	}
+	file := MarkdownFile{Name: strings.TrimSuffix(name, path.Ext(name)), Content: text}
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm":
+		title, markdown, err := readHTML([]byte(text))
+		if err != nil {
+			return MarkdownFile{}, ErrInvalidHTML
+		}
+		file.title, file.Content = title, markdown
+	case ".json":
+		title, markdown, err := readJSON([]byte(text))
+		if err != nil {
+			return MarkdownFile{}, err
+		}
+		file.title, file.Content = title, markdown
+	}
+	return file, nil
+}
+
+
+// ReadArchive returns the importable files of a zip archive,
+// converted to Markdown and sorted by path. Other files, hidden entries and macOS resource forks are skipped.
+// Sizes are enforced on the decompressed data, not the headers, which an
+// archive can lie about.
+func ReadArchive(reader io.ReaderAt, size int64) ([]MarkdownFile, error) {
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+
+	files := make([]MarkdownFile, 0)
+	var expanded int64
+	for _, entry := range archive.File {
+		segments, ok := archivePath(entry.Name)
+		if !ok || entry.FileInfo().IsDir() || !IsImportName(entry.Name) {
+			continue
+		}
+		if len(files) == MaxArchiveDocuments {
+			return nil, ErrTooLarge
+		}
+
+		source, err := readEntry(entry, MaxArchiveExpanded-expanded)
+		if err != nil {
+			return nil, err
+		}
+		expanded += int64(len(source))
+
+		name := segments[len(segments)-1]
+		file, err := ReadFile(name, source)
+		if err != nil {
+			return nil, err
+		}
+		file.Folders = segments[:len(segments)-1]
+		for i, folder := range file.Folders {
+			file.Folders[i] = clampTitle(folder)
+		}
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path() < files[j].path()
+	})
+	return files, nil
+}
+
+
+// archivePath splits an entry name into clean segments, rejecting absolute
+// paths, parent references and hidden or metadata entries.
+func archivePath(name string) ([]string, bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return nil, false
+	}
+
+	segments := strings.Split(path.Clean(name), "/")
+	for _, segment := range segments {
+		if segment == ".." || segment == "" || strings.HasPrefix(segment, ".") || segment == "__MACOSX" {
+			return nil, false
+		}
+	}
+	return segments, true
+}
+
+
+func readEntry(entry *zip.File, budget int64) ([]byte, error) {
+	limit := min(budget, MaxMarkdownSize)
+
+	file, err := entry.Open()
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+	defer file.Close()
+
+	source, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+	if int64(len(source)) > limit {
+		return nil, ErrTooLarge
+	}
+	return source, nil
+}
@@ -0,0 +1,220 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+
+type archiveEntry struct {
+	name    string
+	content string
+}
+
+
+func buildArchive(t *testing.T, entries ...archiveEntry) *bytes.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		file, err := writer.Create(entry.name)
+		if err != nil {
+			t.Fatalf("Create(%q): %v", entry.name, err)
+		}
+		if _, err := file.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("Write(%q): %v", entry.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+
+func TestArchivePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   []string
+		wantOk bool
+	}{
+		{"a.md", []string{"a.md"}, true},
+		{"notes/a.md", []string{"notes", "a.md"}, true},
+		{"notes/2024/a.md", []string{"notes", "2024", "a.md"}, true},
+		{"./a.md", []string{"a.md"}, true},
+		{"notes//a.md", []string{"notes", "a.md"}, true},
+		{"notes\\a.md", []string{"notes", "a.md"}, true},
+		{"notes/sub/../a.md", []string{"notes", "a.md"}, true},
+
+		{"/etc/passwd.md", nil, false},
+		{"\\evil.md", nil, false},
+		{"../a.md", nil, false},
+		{"notes/../../a.md", nil, false},
+		{"..\\..\\a.md", nil, false},
+		{".hidden.md", nil, false},
+		{"notes/.git/a.md", nil, false},
+		{"__MACOSX/notes/._a.md", nil, false},
+		{"notes/._a.md", nil, false},
+		{"", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := archivePath(tt.name)
+			if ok != tt.wantOk {
+				t.Fatalf("archivePath(%q) ok = %v, want %v", tt.name, ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("archivePath(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+
+func TestReadArchive(t *testing.T) {
+	reader := buildArchive(t,
+		archiveEntry{"notes/b.md", "# Second\n\nbody"},
+		archiveEntry{"a.markdown", "first"},
+		archiveEntry{"notes/page.html", "<title>Page</title><p>hi</p>"},
+		archiveEntry{"notes/", ""},
+		archiveEntry{"notes/image.png", "\x89PNG"},
+		archiveEntry{"../escape.md", "outside"},
+		archiveEntry{"__MACOSX/notes/._b.md", "\x00\x05"},
+		archiveEntry{".DS_Store", "junk"},
+	)
+
+	files, err := ReadArchive(reader, reader.Size())
+	if err != nil {
+		t.Fatalf("ReadArchive: %v", err)
+	}
+
+	type summary struct {
+		Folders []string
+		Name    string
+		Title   string
+	}
+	got := make([]summary, 0, len(files))
+	for _, file := range files {
+		got = append(got, summary{file.Folders, file.Name, file.Title()})
+	}
+	want := []summary{
+		{[]string{}, "a", "a"},
+		{[]string{"notes"}, "b", "Second"},
+		{[]string{"notes"}, "page", "Page"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadArchive() = %+v, want %+v", got, want)
+	}
+}
+
+
+func TestReadArchiveErrors(t *testing.T) {
+	tooMany := make([]archiveEntry, MaxArchiveDocuments+1)
+	for i := range tooMany {
+		tooMany[i] = archiveEntry{fmt.Sprintf("%04d.md", i), "x"}
+	}
+	// Entries that are skipped do not count towards the document limit.
+	skipped := make([]archiveEntry, MaxArchiveDocuments+1)
+	for i := range skipped {
+		skipped[i] = archiveEntry{fmt.Sprintf("%04d.png", i), "x"}
+	}
+
+	// Each file is within MaxMarkdownSize, together they exceed
+	// MaxArchiveExpanded.
+	large := strings.Repeat("a", MaxMarkdownSize)
+	expanded := make([]archiveEntry, MaxArchiveExpanded/MaxMarkdownSize+1)
+	for i := range expanded {
+		expanded[i] = archiveEntry{fmt.Sprintf("%02d.md", i), large}
+	}
+
+	tests := []struct {
+		name    string
+		reader  func(t *testing.T) *bytes.Reader
+		wantErr error
+	}{
+		{
+			name:    "not a zip",
+			reader:  func(*testing.T) *bytes.Reader { return bytes.NewReader([]byte("not a zip")) },
+			wantErr: ErrInvalidArchive,
+		},
+		{
+			name:    "not utf-8",
+			reader:  func(t *testing.T) *bytes.Reader { return buildArchive(t, archiveEntry{"a.md", "\xff\xfe"}) },
+			wantErr: ErrNotUTF8,
+		},
+		{
+			name:    "too many documents",
+			reader:  func(t *testing.T) *bytes.Reader { return buildArchive(t, tooMany...) },
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:   "skipped entries",
+			reader: func(t *testing.T) *bytes.Reader { return buildArchive(t, skipped...) },
+		},
+		{
+			name:    "file too large",
+			reader:  func(t *testing.T) *bytes.Reader { return buildArchive(t, archiveEntry{"a.md", large + "a"}) },
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:   "file at the limit",
+			reader: func(t *testing.T) *bytes.Reader { return buildArchive(t, archiveEntry{"a.md", large}) },
+		},
+		{
+			name:    "expanded size",
+			reader:  func(t *testing.T) *bytes.Reader { return buildArchive(t, expanded...) },
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "header understates the size",
+			reader:  lyingArchive,
+			wantErr: ErrInvalidArchive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := tt.reader(t)
+			_, err := ReadArchive(reader, reader.Size())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadArchive() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+
+// lyingArchive stores an entry whose header claims far less than it holds.
+// Reading stops as soon as the data runs past the declared size.
+func lyingArchive(t *testing.T) *bytes.Reader {
+	t.Helper()
+
+	content := []byte(strings.Repeat("a", MaxMarkdownSize+1))
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	file, err := writer.CreateRaw(&zip.FileHeader{
+		Name:               "a.md",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(content),
+		CompressedSize64:   uint64(len(content)),
+		UncompressedSize64: 1,
+	})
+	if err != nil {
+		t.Fatalf("CreateRaw: %v", err)
+	}
+	if _, err := file.Write(content); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
@@ -0,0 +1,401 @@
+package content
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+
+// Limits of a block tree, checked on every write. MaxBlocksSize bounds the
+// JSON a tree or a batch of operations is read from.
+const (
+	MaxBlockDepth = 32
+	MaxBlockNodes = 50000
+	MaxBlocksSize = 20 << 20
+)
+
+
+// Block is a node of structured document content. A document is a tree
+// with a "doc" root; blockSpecs lists the node types, which fields each may
+// set and what it may contain. Every node except the root and inline nodes
+// has an id that edit operations address it by.
+type Block struct {
+	Type     string   `json:"type"`
+	Id       string   `json:"id,omitempty"`
+	Level    int      `json:"level,omitempty"`
+	Start    int      `json:"start,omitempty"`
+	Language string   `json:"language,omitempty"`
+	Header   bool     `json:"header,omitempty"`
+	Align    string   `json:"align,omitempty"`
+	Src      string   `json:"src,omitempty"`
+	Alt      string   `json:"alt,omitempty"`
+	Text     string   `json:"text,omitempty"`
+	Marks    []Mark   `json:"marks,omitempty"`
+	Content  []*Block `json:"content,omitempty"`
+}
+
+
+// Mark is inline formatting of a text node.
+type Mark struct {
+	Type string `json:"type"`
+	Href string `json:"href,omitempty"`
+}
+
+
+// Block and mark types.
+const (
+	BlockDoc            = "doc"
+	BlockParagraph      = "paragraph"
+	BlockHeading        = "heading"
+	BlockBlockquote     = "blockquote"
+	BlockBulletList     = "bullet_list"
+	BlockOrderedList    = "ordered_list"
+	BlockListItem       = "list_item"
+	BlockCode           = "code_block"
+	BlockHorizontalRule = "horizontal_rule"
+	BlockTable          = "table"
+	BlockTableRow       = "table_row"
+	BlockTableCell      = "table_cell"
+	BlockText           = "text"
+	BlockHardBreak      = "hard_break"
+	BlockImage          = "image"
+
+	MarkBold   = "bold"
+	MarkItalic = "italic"
+	MarkStrike = "strike"
+	MarkCode   = "code"
+	MarkLink   = "link"
+)
+
+
+// Content models: what a node may contain.
+const (
+	containsNothing = iota
+	containsBlocks
+	containsInline
+	containsListItems
+	containsRows
+	containsCells
+)
+
+
+type blockSpec struct {
+	inline   bool
+	contains int
+	fields   []string
+}
+
+
+var blockSpecs = map[string]blockSpec{
+	BlockDoc:            {contains: containsBlocks},
+	BlockParagraph:      {contains: containsInline},
+	BlockHeading:        {contains: containsInline, fields: []string{"level"}},
+	BlockBlockquote:     {contains: containsBlocks},
+	BlockBulletList:     {contains: containsListItems},
+	BlockOrderedList:    {contains: containsListItems, fields: []string{"start"}},
+	BlockListItem:       {contains: containsBlocks},
+	BlockCode:           {fields: []string{"language", "text"}},
+	BlockHorizontalRule: {},
+	BlockTable:          {contains: containsRows},
+	BlockTableRow:       {contains: containsCells},
+	BlockTableCell:      {contains: containsInline, fields: []string{"header", "align"}},
+	BlockText:           {inline: true, fields: []string{"text", "marks"}},
+	BlockHardBreak:      {inline: true},
+	BlockImage:          {inline: true, fields: []string{"src", "alt"}},
+}
+
+
+// markOrder is the nesting order of marks from the outside in; it is also
+// the order marks are kept in.
+var markOrder = []string{MarkLink, MarkBold, MarkItalic, MarkStrike, MarkCode}
+
+
+var blockIdPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+
+// BlockError reports why a block tree is invalid and where.
+type BlockError struct {
+	Path   string
+	Reason string
+}
+
+
+func (e *BlockError) Error() string {
+	if e.Path == "" {
+		return e.Reason
+	}
+	return e.Path + ": " + e.Reason
+}
+
+
+// ParseDocument decodes and validates a block tree with a "doc" root and
+// gives ids to the blocks that have none.
+func ParseDocument(data []byte) (*Block, error) {
+	doc, err := ParseBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Type != BlockDoc {
+		return nil, &BlockError{Reason: "root must be a doc"}
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	doc.AssignIds()
+	return doc, nil
+}
+
+
+// ParseBlock decodes a block without validating it; unknown fields are
+// rejected so typos do not silently drop content.
+func ParseBlock(data []byte) (*Block, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var block Block
+	if err := decoder.Decode(&block); err != nil {
+		return nil, &BlockError{Reason: "malformed JSON: " + err.Error()}
+	}
+	return &block, nil
+}
+
+
+// Validate checks the tree below a "doc" root against blockSpecs.
+func (doc *Block) Validate() error {
+	if doc.Type != BlockDoc {
+		return &BlockError{Reason: "root must be a doc"}
+	}
+
+	nodes := 0
+	ids := make(map[string]bool)
+	return validateBlock(doc, "", 0, &nodes, ids)
+}
+
+
+func validateBlock(block *Block, path string, depth int, nodes *int, ids map[string]bool) error {
+	fail := func(format string, args ...any) error {
+		return &BlockError{Path: path, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if block == nil {
+		return fail("block is null")
+	}
+	*nodes++
+	if *nodes > MaxBlockNodes {
+		return fail("document has more than %d nodes", MaxBlockNodes)
+	}
+	if depth > MaxBlockDepth {
+		return fail("blocks are nested more than %d levels deep", MaxBlockDepth)
+	}
+
+	spec, ok := blockSpecs[block.Type]
+	if !ok {
+		return fail("unknown block type %q", block.Type)
+	}
+	if block.Type == BlockDoc && depth > 0 {
+		return fail("doc can only be the root")
+	}
+	for _, field := range block.setFields() {
+		if !slices.Contains(spec.fields, field) {
+			return fail("%s cannot have %s", block.Type, field)
+		}
+	}
+
+	if spec.inline || block.Type == BlockDoc {
+		if block.Id != "" {
+			return fail("%s cannot have an id", block.Type)
+		}
+	} else if block.Id != "" {
+		if !blockIdPattern.MatchString(block.Id) {
+			return fail("id must be 1 to 64 letters, digits, - or _")
+		}
+		if ids[block.Id] {
+			return fail("duplicate id %q", block.Id)
+		}
+		ids[block.Id] = true
+	}
+
+	switch block.Type {
+	case BlockHeading:
+		if block.Level < 1 || block.Level > 6 {
+			return fail("heading level must be between 1 and 6")
+		}
+	case BlockOrderedList:
+		if block.Start < 0 {
+			return fail("start cannot be negative")
+		}
+	case BlockCode:
+		if block.Language != "" && !languageClass.MatchString("language-"+block.Language) {
+			return fail("invalid language %q", block.Language)
+		}
+	case BlockTableCell:
+		if block.Align != "" && block.Align != "left" && block.Align != "center" && block.Align != "right" {
+			return fail("align must be left, center or right")
+		}
+	case BlockText:
+		if block.Text == "" {
+			return fail("text cannot be empty")
+		}
+		if err := validateMarks(block.Marks); err != nil {
+			return fail("%s", err.Error())
+		}
+	case BlockImage:
+		if _, ok := safeURL(block.Src, "http", "https"); !ok || block.Src == "" {
+			return fail("image src must be an http or https URL")
+		}
+	}
+
+	for i, child := range block.Content {
+		childPath := fmt.Sprintf("%s.content[%d]", path, i)
+		if path == "" {
+			childPath = fmt.Sprintf("content[%d]", i)
+		}
+		if child != nil {
+			if reason := childRule(spec.contains, child.Type); reason != "" {
+				return &BlockError{Path: childPath, Reason: fmt.Sprintf("%s %s", block.Type, reason)}
+			}
+		}
+		if err := validateBlock(child, childPath, depth+1, nodes, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+
+// childRule returns why a node of type child cannot be in content of kind
+// contains, or "" when it can.
+func childRule(contains int, child string) string {
+	spec := blockSpecs[child]
+	switch contains {
+	case containsNothing:
+		return "cannot contain other nodes"
+	case containsBlocks:
+		if spec.inline || slices.Contains([]string{BlockListItem, BlockTableRow, BlockTableCell}, child) {
+			return "cannot contain " + child
+		}
+	case containsInline:
+		if _, known := blockSpecs[child]; known && !spec.inline {
+			return "can only contain inline nodes, not " + child
+		}
+	case containsListItems:
+		if child != BlockListItem {
+			return "can only contain list_item, not " + child
+		}
+	case containsRows:
+		if child != BlockTableRow {
+			return "can only contain table_row, not " + child
+		}
+	case containsCells:
+		if child != BlockTableCell {
+			return "can only contain table_cell, not " + child
+		}
+	}
+	return ""
+}
+
+
+func validateMarks(marks []Mark) error {
+	seen := make(map[string]bool)
+	for _, mark := range marks {
+		if !slices.Contains(markOrder, mark.Type) {
+			return fmt.Errorf("unknown mark %q", mark.Type)
+		}
+		if seen[mark.Type] {
+			return fmt.Errorf("duplicate mark %q", mark.Type)
+		}
+		seen[mark.Type] = true
+
+		if mark.Type == MarkLink {
+			if _, ok := safeURL(mark.Href, "http", "https", "mailto"); !ok || mark.Href == "" {
+				return fmt.Errorf("link href must be a relative, http, https or mailto URL")
+			}
+		} else if mark.Href != "" {
+			return fmt.Errorf("%s mark cannot have href", mark.Type)
+		}
+	}
+	return nil
+}
+
+
+// setFields lists the optional fields of the block that are set.
+func (block *Block) setFields() []string {
+	fields := make([]string, 0)
+	set := map[string]bool{
+		"level":    block.Level != 0,
+		"start":    block.Start != 0,
+		"language": block.Language != "",
+		"header":   block.Header,
+		"align":    block.Align != "",
+		"src":      block.Src != "",
+		"alt":      block.Alt != "",
+		"text":     block.Text != "",
+		"marks":    len(block.Marks) > 0,
+	}
+	for field, isSet := range set {
+		if isSet {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+
+// AssignIds gives every block that needs an id and has none a new one.
+func (doc *Block) AssignIds() {
+	ids := make(map[string]bool)
+	doc.walk(func(block *Block, _ *Block) {
+		if block.Id != "" {
+			ids[block.Id] = true
+		}
+	})
+	doc.walk(func(block *Block, _ *Block) {
+		if block.Id != "" || block.Type == BlockDoc || blockSpecs[block.Type].inline {
+			return
+		}
+		for block.Id == "" || ids[block.Id] {
+			block.Id = newBlockId()
+		}
+		ids[block.Id] = true
+	})
+}
+
+
+func newBlockId() string {
+	id := make([]byte, 6)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+
+// walk calls visit for every node of the tree with its parent, root first.
+func (block *Block) walk(visit func(block *Block, parent *Block)) {
+	var walk func(*Block, *Block)
+	walk = func(node *Block, parent *Block) {
+		visit(node, parent)
+		for _, child := range node.Content {
+			if child != nil {
+				walk(child, node)
+			}
+		}
+	}
+	walk(block, nil)
+}
+
+
+// find returns the block with id and its parent.
+func (doc *Block) find(id string) (*Block, *Block) {
+	var found, foundParent *Block
+	doc.walk(func(block *Block, parent *Block) {
+		if found == nil && block.Id == id {
+			found, foundParent = block, parent
+		}
+	})
+	return found, foundParent
+}
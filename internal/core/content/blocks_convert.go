@@ -0,0 +1,441 @@
+package content
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+
+// BlocksFromMarkdown converts Markdown to a block tree with fresh ids.
+func BlocksFromMarkdown(source string) *Block {
+	nodes, err := sanitizeHTML(renderMarkdown(source))
+	if err != nil {
+		nodes = nil
+	}
+	return blocksFromNodes(nodes)
+}
+
+
+// BlocksFromHTML sanitizes an HTML fragment and converts it to a block tree
+// with fresh ids.
+func BlocksFromHTML(source string) (*Block, error) {
+	nodes, err := sanitizeHTML(source)
+	if err != nil {
+		return nil, ErrInvalidHTML
+	}
+	return blocksFromNodes(nodes), nil
+}
+
+
+func blocksFromNodes(nodes []*html.Node) *Block {
+	doc := &Block{Type: BlockDoc, Content: blockContent(nodes)}
+	doc.AssignIds()
+	return doc
+}
+
+
+// Markdown converts the tree to Markdown.
+func (doc *Block) Markdown() string {
+	markdown := toMarkdown(doc.htmlNodes())
+	if markdown == "" {
+		return ""
+	}
+	return markdown + "\n"
+}
+
+
+// HTML converts the tree to an HTML fragment.
+func (doc *Block) HTML() string {
+	var b strings.Builder
+	for _, node := range doc.htmlNodes() {
+		html.Render(&b, node)
+	}
+	return b.String()
+}
+
+
+// jsonExport is the JSON export of a document: its block tree with the
+// metadata. Imports accept it as well as a bare tree.
+type jsonExport struct {
+	Title     string          `json:"title"`
+	Author    string          `json:"author,omitempty"`
+	CreatedAt *time.Time      `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time      `json:"updatedAt,omitempty"`
+	Blocks    json.RawMessage `json:"blocks"`
+}
+
+
+// writeJSON writes the document as a block tree with its metadata.
+func writeJSON(w io.Writer, document Document) error {
+	blocks, err := json.Marshal(BlocksFromMarkdown(document.Body))
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonExport{
+		Title:     document.Title,
+		Author:    document.Author,
+		CreatedAt: &document.CreatedAt,
+		UpdatedAt: &document.UpdatedAt,
+		Blocks:    blocks,
+	})
+}
+
+
+// readJSON reads a JSON export or a bare block tree and returns its title,
+// empty for a bare tree, and the tree as Markdown.
+func readJSON(source []byte) (string, string, error) {
+	var export jsonExport
+	if err := json.Unmarshal(source, &export); err == nil && len(export.Blocks) > 0 {
+		doc, err := ParseDocument(export.Blocks)
+		if err != nil {
+			return "", "", err
+		}
+		return clampTitle(strings.Join(strings.Fields(export.Title), " ")), doc.Markdown(), nil
+	}
+
+	doc, err := ParseDocument(source)
+	if err != nil {
+		return "", "", err
+	}
+	return "", doc.Markdown(), nil
+}
+
+
+// blockContent converts sanitized nodes in a block context. Sectioning
+// elements are flattened and runs of inline nodes become paragraphs.
+func blockContent(nodes []*html.Node) []*Block {
+	blocks := make([]*Block, 0)
+	run := make([]*html.Node, 0)
+	flush := func() {
+		if inline := inlineContent(run, nil); len(inline) > 0 {
+			blocks = append(blocks, &Block{Type: BlockParagraph, Content: inline})
+		}
+		run = run[:0]
+	}
+
+	for _, node := range nodes {
+		if !isBlockElement(node) {
+			run = append(run, node)
+			continue
+		}
+		flush()
+		blocks = append(blocks, blockFromNode(node)...)
+	}
+	flush()
+	return blocks
+}
+
+
+func blockFromNode(node *html.Node) []*Block {
+	children := childNodes(node)
+	switch node.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(node.Data[1:])
+		return []*Block{{Type: BlockHeading, Level: level, Content: inlineContent(children, nil)}}
+
+	case atom.P:
+		inline := inlineContent(children, nil)
+		if len(inline) == 0 {
+			return nil
+		}
+		return []*Block{{Type: BlockParagraph, Content: inline}}
+
+	case atom.Pre:
+		code := &Block{Type: BlockCode, Text: strings.TrimSuffix(textContent(node), "\n")}
+		for _, child := range children {
+			if class, ok := attributeValue(child, "class"); ok && child.DataAtom == atom.Code {
+				code.Language = strings.TrimPrefix(class, "language-")
+			}
+		}
+		return []*Block{code}
+
+	case atom.Blockquote:
+		return []*Block{{Type: BlockBlockquote, Content: blockContent(children)}}
+
+	case atom.Hr:
+		return []*Block{{Type: BlockHorizontalRule}}
+
+	case atom.Ul, atom.Ol:
+		list := &Block{Type: BlockBulletList, Content: make([]*Block, 0)}
+		if node.DataAtom == atom.Ol {
+			list.Type = BlockOrderedList
+			if start, ok := attributeValue(node, "start"); ok && start != "1" {
+				list.Start, _ = strconv.Atoi(start)
+			}
+		}
+		items, _ := listItems(node, "")
+		for _, item := range items {
+			list.Content = append(list.Content, &Block{Type: BlockListItem, Content: blockContent(childNodes(item))})
+		}
+		return []*Block{list}
+
+	case atom.Table:
+		table := &Block{Type: BlockTable, Content: make([]*Block, 0)}
+		for _, cells := range tableRows(node) {
+			row := &Block{Type: BlockTableRow, Content: make([]*Block, 0, len(cells))}
+			for _, cell := range cells {
+				align, _ := attributeValue(cell, "align")
+				row.Content = append(row.Content, &Block{
+					Type:    BlockTableCell,
+					Header:  cell.DataAtom == atom.Th,
+					Align:   align,
+					Content: inlineContent(childNodes(cell), nil),
+				})
+			}
+			table.Content = append(table.Content, row)
+		}
+		return []*Block{table}
+
+	default:
+		return blockContent(children)
+	}
+}
+
+
+// inlineContent converts sanitized inline nodes to text, hard_break and
+// image nodes, collapsing whitespace the way HTML renders it. Adjacent text
+// with the same marks is merged.
+func inlineContent(nodes []*html.Node, marks []Mark) []*Block {
+	inline := make([]*Block, 0)
+	var collect func(*html.Node, []Mark)
+	collect = func(node *html.Node, marks []Mark) {
+		if node.Type == html.TextNode {
+			text := leadingSpace(node.Data) + collapseSpace(node.Data)
+			if text != "" {
+				inline = append(inline, &Block{Type: BlockText, Text: text, Marks: slices.Clone(marks)})
+			}
+			return
+		}
+
+		var mark *Mark
+		switch node.DataAtom {
+		case atom.Br:
+			inline = append(inline, &Block{Type: BlockHardBreak})
+			return
+		case atom.Img:
+			src, _ := attributeValue(node, "src")
+			alt, _ := attributeValue(node, "alt")
+			inline = append(inline, &Block{Type: BlockImage, Src: src, Alt: alt})
+			return
+		case atom.Strong, atom.B:
+			mark = &Mark{Type: MarkBold}
+		case atom.Em, atom.I:
+			mark = &Mark{Type: MarkItalic}
+		case atom.S, atom.Del:
+			mark = &Mark{Type: MarkStrike}
+		case atom.Code:
+			mark = &Mark{Type: MarkCode}
+		case atom.A:
+			if href, ok := attributeValue(node, "href"); ok && href != "" {
+				mark = &Mark{Type: MarkLink, Href: href}
+			}
+		}
+
+		childMarks := marks
+		if mark != nil {
+			childMarks = addMark(marks, *mark)
+		}
+		for _, child := range childNodes(node) {
+			collect(child, childMarks)
+		}
+	}
+	for _, node := range nodes {
+		collect(node, marks)
+	}
+	return tidyInline(inline)
+}
+
+
+// addMark returns marks with mark added in markOrder, replacing a mark of
+// the same type.
+func addMark(marks []Mark, mark Mark) []Mark {
+	result := make([]Mark, 0, len(marks)+1)
+	for _, markType := range markOrder {
+		if markType == mark.Type {
+			result = append(result, mark)
+			continue
+		}
+		for _, existing := range marks {
+			if existing.Type == markType {
+				result = append(result, existing)
+			}
+		}
+	}
+	return result
+}
+
+
+// tidyInline merges adjacent text with equal marks and trims whitespace at
+// the edges of lines.
+func tidyInline(inline []*Block) []*Block {
+	merged := make([]*Block, 0, len(inline))
+	for _, node := range inline {
+		if last := len(merged) - 1; last >= 0 && node.Type == BlockText && merged[last].Type == BlockText &&
+			slices.Equal(node.Marks, merged[last].Marks) {
+			merged[last].Text = strings.ReplaceAll(merged[last].Text+node.Text, "  ", " ")
+			continue
+		}
+		merged = append(merged, node)
+	}
+
+	for i, node := range merged {
+		if node.Type != BlockText {
+			continue
+		}
+		if i == 0 || merged[i-1].Type == BlockHardBreak {
+			node.Text = strings.TrimLeft(node.Text, " ")
+		}
+		if i == len(merged)-1 || merged[i+1].Type == BlockHardBreak {
+			node.Text = strings.TrimRight(node.Text, " ")
+		}
+	}
+	return slices.DeleteFunc(merged, func(node *Block) bool {
+		return node.Type == BlockText && node.Text == ""
+	})
+}
+
+
+// htmlNodes converts the children of the root to HTML nodes.
+func (doc *Block) htmlNodes() []*html.Node {
+	nodes := make([]*html.Node, 0, len(doc.Content))
+	for _, block := range doc.Content {
+		nodes = append(nodes, blockNode(block)...)
+	}
+	return nodes
+}
+
+
+func element(tag atom.Atom, attributes ...html.Attribute) *html.Node {
+	return &html.Node{Type: html.ElementNode, Data: tag.String(), DataAtom: tag, Attr: attributes}
+}
+
+
+func appendNodes(parent *html.Node, children []*html.Node) *html.Node {
+	for _, child := range children {
+		parent.AppendChild(child)
+	}
+	return parent
+}
+
+
+func blockNode(block *Block) []*html.Node {
+	switch block.Type {
+	case BlockHeading:
+		tag := atom.Lookup([]byte("h" + strconv.Itoa(block.Level)))
+		return []*html.Node{appendNodes(element(tag), inlineNodes(block.Content))}
+
+	case BlockParagraph:
+		return []*html.Node{appendNodes(element(atom.P), inlineNodes(block.Content))}
+
+	case BlockBlockquote:
+		return []*html.Node{appendNodes(element(atom.Blockquote), childBlockNodes(block))}
+
+	case BlockBulletList, BlockOrderedList:
+		list := element(atom.Ul)
+		if block.Type == BlockOrderedList {
+			list = element(atom.Ol)
+			if block.Start > 1 {
+				list.Attr = []html.Attribute{{Key: "start", Val: strconv.Itoa(block.Start)}}
+			}
+		}
+		for _, item := range block.Content {
+			list.AppendChild(appendNodes(element(atom.Li), childBlockNodes(item)))
+		}
+		return []*html.Node{list}
+
+	case BlockCode:
+		code := element(atom.Code)
+		if block.Language != "" {
+			code.Attr = []html.Attribute{{Key: "class", Val: "language-" + block.Language}}
+		}
+		code.AppendChild(&html.Node{Type: html.TextNode, Data: block.Text + "\n"})
+		pre := element(atom.Pre)
+		pre.AppendChild(code)
+		return []*html.Node{pre}
+
+	case BlockHorizontalRule:
+		return []*html.Node{element(atom.Hr)}
+
+	case BlockTable:
+		table := element(atom.Table)
+		for _, row := range block.Content {
+			tr := element(atom.Tr)
+			for _, cell := range row.Content {
+				tag := atom.Td
+				if cell.Header {
+					tag = atom.Th
+				}
+				td := element(tag)
+				if cell.Align != "" {
+					td.Attr = []html.Attribute{{Key: "align", Val: cell.Align}}
+				}
+				tr.AppendChild(appendNodes(td, inlineNodes(cell.Content)))
+			}
+			table.AppendChild(tr)
+		}
+		return []*html.Node{table}
+
+	default:
+		return nil
+	}
+}
+
+
+func childBlockNodes(block *Block) []*html.Node {
+	nodes := make([]*html.Node, 0, len(block.Content))
+	for _, child := range block.Content {
+		nodes = append(nodes, blockNode(child)...)
+	}
+	return nodes
+}
+
+
+// inlineNodes converts inline nodes to HTML, nesting marks in markOrder.
+func inlineNodes(inline []*Block) []*html.Node {
+	nodes := make([]*html.Node, 0, len(inline))
+	for _, node := range inline {
+		switch node.Type {
+		case BlockHardBreak:
+			nodes = append(nodes, element(atom.Br))
+		case BlockImage:
+			attributes := []html.Attribute{{Key: "src", Val: node.Src}}
+			if node.Alt != "" {
+				attributes = append(attributes, html.Attribute{Key: "alt", Val: node.Alt})
+			}
+			nodes = append(nodes, element(atom.Img, attributes...))
+		case BlockText:
+			current := &html.Node{Type: html.TextNode, Data: node.Text}
+			for i := len(node.Marks) - 1; i >= 0; i-- {
+				current = appendNodes(markElement(node.Marks[i]), []*html.Node{current})
+			}
+			nodes = append(nodes, current)
+		}
+	}
+	return nodes
+}
+
+
+func markElement(mark Mark) *html.Node {
+	switch mark.Type {
+	case MarkLink:
+		return element(atom.A, html.Attribute{Key: "href", Val: mark.Href})
+	case MarkBold:
+		return element(atom.Strong)
+	case MarkItalic:
+		return element(atom.Em)
+	case MarkStrike:
+		return element(atom.Del)
+	default:
+		return element(atom.Code)
+	}
+}
@@ -0,0 +1,152 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+
+// Edit operations.
+const (
+	OperationInsert  = "insert"
+	OperationReplace = "replace"
+	OperationDelete  = "delete"
+	OperationMove    = "move"
+)
+
+
+// ErrBlockNotFound is returned when an operation addresses a block that is
+// not in the tree, usually because it was edited in the meantime.
+var ErrBlockNotFound = errors.New("block not found")
+
+
+// Operation edits one block of a tree. Insert and move place the block in
+// ParentId (the root when empty) right after its child AfterId (first when
+// empty); replace and delete address the block Id. A replaced block keeps
+// its id.
+type Operation struct {
+	Op       string
+	Id       string
+	ParentId string
+	AfterId  string
+	Block    *Block
+}
+
+
+// Apply runs the operations in order and validates the result. The tree is
+// only changed when all of them succeed.
+func (doc *Block) Apply(operations []Operation) error {
+	edited := doc.clone()
+	for i, operation := range operations {
+		if err := edited.apply(operation); err != nil {
+			path := fmt.Sprintf("operations[%d]", i)
+			var blockErr *BlockError
+			if errors.As(err, &blockErr) {
+				return &BlockError{Path: path + joinPath(blockErr.Path), Reason: blockErr.Reason}
+			}
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := edited.Validate(); err != nil {
+		return err
+	}
+	edited.AssignIds()
+	*doc = *edited
+	return nil
+}
+
+
+func joinPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return "." + path
+}
+
+
+func (doc *Block) apply(operation Operation) error {
+	switch operation.Op {
+	case OperationInsert:
+		if operation.Block == nil {
+			return &BlockError{Path: "block", Reason: "insert needs a block"}
+		}
+		return doc.insert(operation.ParentId, operation.AfterId, operation.Block.clone())
+
+	case OperationReplace:
+		if operation.Block == nil {
+			return &BlockError{Path: "block", Reason: "replace needs a block"}
+		}
+		block, _ := doc.find(operation.Id)
+		if block == nil || operation.Id == "" {
+			return ErrBlockNotFound
+		}
+		replacement := operation.Block.clone()
+		replacement.Id = block.Id
+		*block = *replacement
+		return nil
+
+	case OperationDelete:
+		block, parent := doc.find(operation.Id)
+		if block == nil || operation.Id == "" {
+			return ErrBlockNotFound
+		}
+		parent.Content = slices.DeleteFunc(parent.Content, func(child *Block) bool { return child == block })
+		return nil
+
+	case OperationMove:
+		block, parent := doc.find(operation.Id)
+		if block == nil || operation.Id == "" {
+			return ErrBlockNotFound
+		}
+		if operation.ParentId != "" {
+			if target, _ := block.find(operation.ParentId); target != nil {
+				return &BlockError{Reason: "a block cannot be moved into itself"}
+			}
+		}
+		parent.Content = slices.DeleteFunc(parent.Content, func(child *Block) bool { return child == block })
+		return doc.insert(operation.ParentId, operation.AfterId, block)
+
+	default:
+		return &BlockError{Path: "op", Reason: fmt.Sprintf("unknown operation %q", operation.Op)}
+	}
+}
+
+
+func (doc *Block) insert(parentId string, afterId string, block *Block) error {
+	parent := doc
+	if parentId != "" {
+		if parent, _ = doc.find(parentId); parent == nil {
+			return ErrBlockNotFound
+		}
+	}
+
+	position := 0
+	if afterId != "" {
+		position = slices.IndexFunc(parent.Content, func(child *Block) bool { return child != nil && child.Id == afterId })
+		if position < 0 {
+			return ErrBlockNotFound
+		}
+		position++
+	}
+	parent.Content = slices.Insert(parent.Content, position, block)
+	return nil
+}
+
+
+// clone returns a deep copy of the block.
+func (block *Block) clone() *Block {
+	if block == nil {
+		return nil
+	}
+	copied := *block
+	copied.Marks = slices.Clone(block.Marks)
+	if block.Content != nil {
+		copied.Content = make([]*Block, len(block.Content))
+		for i, child := range block.Content {
+			copied.Content[i] = child.clone()
+		}
+	}
+	return &copied
+}
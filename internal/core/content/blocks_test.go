@@ -0,0 +1,367 @@
+package content
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+
+// outline lists the ids of the non-inline blocks below block, children in
+// brackets, e.g. "a l[i1[p1] i2[p2]] b".
+func outline(block *Block) string {
+	parts := make([]string, 0, len(block.Content))
+	for _, child := range block.Content {
+		if child == nil || blockSpecs[child.Type].inline {
+			continue
+		}
+		part := child.Id
+		if inner := outline(child); inner != "" {
+			part += "[" + inner + "]"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+
+func nested(depth int) string {
+	return strings.Repeat(`{"type":"blockquote","content":[`, depth) +
+		`{"type":"paragraph"}` + strings.Repeat(`]}`, depth)
+}
+
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		json       string
+		wantPath   string
+		wantReason string
+	}{
+		{
+			name: "valid",
+			json: `{"type":"doc","content":[
+				{"type":"heading","level":2,"content":[{"type":"text","text":"Title","marks":[{"type":"bold"}]}]},
+				{"type":"paragraph","content":[
+					{"type":"text","text":"see "},
+					{"type":"text","text":"docs","marks":[{"type":"link","href":"https://example.com"},{"type":"italic"}]},
+					{"type":"hard_break"},
+					{"type":"image","src":"https://example.com/a.png","alt":"a"}
+				]},
+				{"type":"ordered_list","start":3,"content":[{"type":"list_item","content":[{"type":"paragraph"}]}]},
+				{"type":"code_block","language":"go","text":"x := 1"},
+				{"type":"table","content":[{"type":"table_row","content":[{"type":"table_cell","header":true,"align":"center"}]}]},
+				{"type":"horizontal_rule"}
+			]}`,
+		},
+		{name: "empty doc", json: `{"type":"doc"}`},
+		{name: "nested at the limit", json: `{"type":"doc","content":[` + nested(MaxBlockDepth-1) + `]}`},
+
+		{name: "malformed", json: `{"type":"doc"`, wantReason: "malformed JSON"},
+		{name: "unknown field", json: `{"type":"doc","colour":"red"}`, wantReason: "malformed JSON"},
+		{name: "root not a doc", json: `{"type":"paragraph"}`, wantReason: "root must be a doc"},
+		{name: "unknown type", json: `{"type":"doc","content":[{"type":"video"}]}`, wantPath: "content[0]", wantReason: `unknown block type "video"`},
+		{name: "nested doc", json: `{"type":"doc","content":[{"type":"doc"}]}`, wantPath: "content[0]", wantReason: "doc can only be the root"},
+		{name: "null block", json: `{"type":"doc","content":[null]}`, wantPath: "content[0]", wantReason: "block is null"},
+		{name: "field of another type", json: `{"type":"doc","content":[{"type":"paragraph","level":1}]}`, wantPath: "content[0]", wantReason: "paragraph cannot have level"},
+		{name: "heading level 0", json: `{"type":"doc","content":[{"type":"heading"}]}`, wantPath: "content[0]", wantReason: "heading level"},
+		{name: "heading level 7", json: `{"type":"doc","content":[{"type":"heading","level":7}]}`, wantPath: "content[0]", wantReason: "heading level"},
+		{name: "negative start", json: `{"type":"doc","content":[{"type":"ordered_list","start":-1}]}`, wantPath: "content[0]", wantReason: "start cannot be negative"},
+		{name: "language", json: `{"type":"doc","content":[{"type":"code_block","language":"go onclick"}]}`, wantPath: "content[0]", wantReason: "invalid language"},
+		{name: "align", json: `{"type":"doc","content":[{"type":"table","content":[{"type":"table_row","content":[{"type":"table_cell","align":"justify"}]}]}]}`, wantPath: "content[0].content[0].content[0]", wantReason: "align must be"},
+		{name: "empty text", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text"}]}]}`, wantPath: "content[0].content[0]", wantReason: "text cannot be empty"},
+		{name: "unknown mark", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"x","marks":[{"type":"underline"}]}]}]}`, wantReason: `unknown mark "underline"`},
+		{name: "duplicate mark", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"x","marks":[{"type":"bold"},{"type":"bold"}]}]}]}`, wantReason: `duplicate mark "bold"`},
+		{name: "script link", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"x","marks":[{"type":"link","href":"javascript:alert(1)"}]}]}]}`, wantReason: "link href"},
+		{name: "link without href", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"x","marks":[{"type":"link"}]}]}]}`, wantReason: "link href"},
+		{name: "href on another mark", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"x","marks":[{"type":"bold","href":"https://example.com"}]}]}]}`, wantReason: "bold mark cannot have href"},
+		{name: "script image", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"image","src":"javascript:alert(1)"}]}]}`, wantReason: "image src"},
+		{name: "data image", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"image","src":"data:image/png;base64,AAAA"}]}]}`, wantReason: "image src"},
+		{name: "image without src", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"image"}]}]}`, wantReason: "image src"},
+		{name: "inline at block level", json: `{"type":"doc","content":[{"type":"text","text":"x"}]}`, wantPath: "content[0]", wantReason: "doc cannot contain text"},
+		{name: "block in inline content", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"paragraph"}]}]}`, wantPath: "content[0].content[0]", wantReason: "can only contain inline nodes"},
+		{name: "list without items", json: `{"type":"doc","content":[{"type":"bullet_list","content":[{"type":"paragraph"}]}]}`, wantPath: "content[0].content[0]", wantReason: "can only contain list_item"},
+		{name: "list item outside a list", json: `{"type":"doc","content":[{"type":"list_item"}]}`, wantPath: "content[0]", wantReason: "cannot contain list_item"},
+		{name: "row outside a table", json: `{"type":"doc","content":[{"type":"table_row"}]}`, wantPath: "content[0]", wantReason: "cannot contain table_row"},
+		{name: "children of a leaf", json: `{"type":"doc","content":[{"type":"horizontal_rule","content":[{"type":"paragraph"}]}]}`, wantPath: "content[0].content[0]", wantReason: "cannot contain other nodes"},
+		{name: "invalid id", json: `{"type":"doc","content":[{"type":"paragraph","id":"a b"}]}`, wantPath: "content[0]", wantReason: "id must be"},
+		{name: "duplicate id", json: `{"type":"doc","content":[{"type":"paragraph","id":"a"},{"type":"paragraph","id":"a"}]}`, wantPath: "content[1]", wantReason: `duplicate id "a"`},
+		{name: "id on an inline node", json: `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","id":"t","text":"x"}]}]}`, wantReason: "text cannot have an id"},
+		{name: "id on the root", json: `{"type":"doc","id":"root"}`, wantReason: "doc cannot have an id"},
+		{name: "too deep", json: `{"type":"doc","content":[` + nested(MaxBlockDepth) + `]}`, wantReason: "nested more than"},
+		{name: "too many nodes", json: `{"type":"doc","content":[` + strings.Repeat(`{"type":"horizontal_rule"},`, MaxBlockNodes) + `{"type":"horizontal_rule"}]}`, wantReason: "more than 50000 nodes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument([]byte(tt.json))
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("ParseDocument() error = %v", err)
+				}
+				doc.walk(func(block *Block, _ *Block) {
+					inline := blockSpecs[block.Type].inline || block.Type == BlockDoc
+					if (block.Id == "") != inline {
+						t.Errorf("%s block has id %q after parsing", block.Type, block.Id)
+					}
+				})
+				return
+			}
+
+			var blockErr *BlockError
+			if !errors.As(err, &blockErr) {
+				t.Fatalf("ParseDocument() error = %v, want a BlockError", err)
+			}
+			if tt.wantPath != "" && blockErr.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", blockErr.Path, tt.wantPath)
+			}
+			if !strings.Contains(blockErr.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want it to contain %q", blockErr.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+
+func TestAssignIds(t *testing.T) {
+	doc := &Block{Type: BlockDoc, Content: []*Block{
+		{Type: BlockParagraph, Id: "kept"},
+		{Type: BlockParagraph},
+		{Type: BlockParagraph, Content: []*Block{{Type: BlockText, Text: "x"}}},
+	}}
+	doc.AssignIds()
+
+	ids := make(map[string]bool)
+	doc.walk(func(block *Block, _ *Block) {
+		if block.Type == BlockDoc || block.Type == BlockText {
+			if block.Id != "" {
+				t.Errorf("%s got id %q", block.Type, block.Id)
+			}
+			return
+		}
+		if !blockIdPattern.MatchString(block.Id) || ids[block.Id] {
+			t.Errorf("invalid or duplicate id %q", block.Id)
+		}
+		ids[block.Id] = true
+	})
+	if doc.Content[0].Id != "kept" {
+		t.Errorf("existing id changed to %q", doc.Content[0].Id)
+	}
+}
+
+
+const operationsDoc = `{"type":"doc","content":[
+	{"type":"paragraph","id":"a","content":[{"type":"text","text":"A"}]},
+	{"type":"bullet_list","id":"l","content":[
+		{"type":"list_item","id":"i1","content":[{"type":"paragraph","id":"p1"}]},
+		{"type":"list_item","id":"i2","content":[{"type":"paragraph","id":"p2"}]}
+	]},
+	{"type":"paragraph","id":"b"}
+]}`
+
+
+func TestApply(t *testing.T) {
+	paragraph := func(id string) *Block {
+		return &Block{Type: BlockParagraph, Id: id}
+	}
+	item := func(id string) *Block {
+		return &Block{Type: BlockListItem, Id: id, Content: []*Block{paragraph(id + "p")}}
+	}
+
+	tests := []struct {
+		name       string
+		operations []Operation
+		want       string
+		wantErr    error
+		wantReason string
+	}{
+		{
+			name:       "insert first",
+			operations: []Operation{{Op: OperationInsert, Block: paragraph("n")}},
+			want:       "n a l[i1[p1] i2[p2]] b",
+		},
+		{
+			name:       "insert after",
+			operations: []Operation{{Op: OperationInsert, AfterId: "a", Block: paragraph("n")}},
+			want:       "a n l[i1[p1] i2[p2]] b",
+		},
+		{
+			name:       "insert into a block",
+			operations: []Operation{{Op: OperationInsert, ParentId: "i1", AfterId: "p1", Block: paragraph("n")}},
+			want:       "a l[i1[p1 n] i2[p2]] b",
+		},
+		{
+			name:       "insert list item",
+			operations: []Operation{{Op: OperationInsert, ParentId: "l", Block: item("i0")}},
+			want:       "a l[i0[i0p] i1[p1] i2[p2]] b",
+		},
+		{
+			name:       "delete",
+			operations: []Operation{{Op: OperationDelete, Id: "i2"}},
+			want:       "a l[i1[p1]] b",
+		},
+		{
+			name:       "move into another block",
+			operations: []Operation{{Op: OperationMove, Id: "b", ParentId: "i1", AfterId: "p1"}},
+			want:       "a l[i1[p1 b] i2[p2]]",
+		},
+		{
+			name:       "move to the front",
+			operations: []Operation{{Op: OperationMove, Id: "b"}},
+			want:       "b a l[i1[p1] i2[p2]]",
+		},
+		{
+			name:       "reorder siblings",
+			operations: []Operation{{Op: OperationMove, Id: "i1", ParentId: "l", AfterId: "i2"}},
+			want:       "a l[i2[p2] i1[p1]] b",
+		},
+		{
+			name: "operations see earlier ones",
+			operations: []Operation{
+				{Op: OperationInsert, AfterId: "b", Block: paragraph("n")},
+				{Op: OperationMove, Id: "a", AfterId: "n"},
+				{Op: OperationDelete, Id: "l"},
+			},
+			want: "b n a",
+		},
+		{
+			name:       "replace keeps the id",
+			operations: []Operation{{Op: OperationReplace, Id: "b", Block: &Block{Type: BlockHeading, Id: "other", Level: 2}}},
+			want:       "a l[i1[p1] i2[p2]] b",
+		},
+
+		{
+			name:       "unknown id",
+			operations: []Operation{{Op: OperationDelete, Id: "missing"}},
+			wantErr:    ErrBlockNotFound,
+		},
+		{
+			name:       "empty id",
+			operations: []Operation{{Op: OperationReplace, Block: paragraph("")}},
+			wantErr:    ErrBlockNotFound,
+		},
+		{
+			name:       "unknown parent",
+			operations: []Operation{{Op: OperationInsert, ParentId: "missing", Block: paragraph("n")}},
+			wantErr:    ErrBlockNotFound,
+		},
+		{
+			name:       "unknown sibling",
+			operations: []Operation{{Op: OperationInsert, AfterId: "p1", Block: paragraph("n")}},
+			wantErr:    ErrBlockNotFound,
+		},
+		{
+			name:       "unknown operation",
+			operations: []Operation{{Op: "copy", Id: "a"}},
+			wantReason: `unknown operation "copy"`,
+		},
+		{
+			name:       "insert without a block",
+			operations: []Operation{{Op: OperationInsert}},
+			wantReason: "insert needs a block",
+		},
+		{
+			name:       "move into itself",
+			operations: []Operation{{Op: OperationMove, Id: "l", ParentId: "i1"}},
+			wantReason: "cannot be moved into itself",
+		},
+		{
+			name:       "result is validated",
+			operations: []Operation{{Op: OperationInsert, Block: item("n")}},
+			wantReason: "doc cannot contain list_item",
+		},
+		{
+			name:       "duplicate id",
+			operations: []Operation{{Op: OperationInsert, Block: paragraph("a")}},
+			wantReason: `duplicate id "a"`,
+		},
+		{
+			name: "all or nothing",
+			operations: []Operation{
+				{Op: OperationDelete, Id: "a"},
+				{Op: OperationDelete, Id: "a"},
+			},
+			wantErr: ErrBlockNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument([]byte(operationsDoc))
+			if err != nil {
+				t.Fatalf("ParseDocument: %v", err)
+			}
+			before := outline(doc)
+
+			err = doc.Apply(tt.operations)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantReason != "":
+				var blockErr *BlockError
+				if !errors.As(err, &blockErr) || !strings.Contains(blockErr.Reason, tt.wantReason) {
+					t.Fatalf("Apply() error = %v, want one containing %q", err, tt.wantReason)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Apply() error = %v", err)
+				}
+				if got := outline(doc); got != tt.want {
+					t.Errorf("Apply() = %q, want %q", got, tt.want)
+				}
+				return
+			}
+
+			if got := outline(doc); got != before {
+				t.Errorf("failed Apply() changed the document to %q", got)
+			}
+		})
+	}
+}
+
+
+func TestApplyPaths(t *testing.T) {
+	doc, err := ParseDocument([]byte(operationsDoc))
+	if err != nil {
+		t.Fatalf("ParseDocument: %v", err)
+	}
+
+	err = doc.Apply([]Operation{
+		{Op: OperationDelete, Id: "b"},
+		{Op: "copy"},
+	})
+	var blockErr *BlockError
+	if !errors.As(err, &blockErr) || blockErr.Path != "operations[1].op" {
+		t.Errorf("Apply() error = %v, want one at operations[1].op", err)
+	}
+
+	err = doc.Apply([]Operation{{Op: OperationDelete, Id: "missing"}})
+	if err == nil || !strings.HasPrefix(err.Error(), "operations[0]: ") {
+		t.Errorf("Apply() error = %v, want it prefixed with operations[0]", err)
+	}
+}
+
+
+func TestApplyReplace(t *testing.T) {
+	doc, err := ParseDocument([]byte(operationsDoc))
+	if err != nil {
+		t.Fatalf("ParseDocument: %v", err)
+	}
+	replacement := &Block{Type: BlockHeading, Level: 2, Content: []*Block{{Type: BlockText, Text: "B"}}}
+
+	if err := doc.Apply([]Operation{{Op: OperationReplace, Id: "b", Block: replacement}}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	replaced, _ := doc.find("b")
+	if replaced == nil || replaced.Type != BlockHeading || replaced.Level != 2 {
+		t.Fatalf("replaced block = %+v, want a level 2 heading", replaced)
+	}
+
+	// The operation's block is copied, not adopted into the tree.
+	replacement.Content[0].Text = "changed"
+	if replaced.Content[0].Text != "B" {
+		t.Error("changing the operation's block changed the document")
+	}
+}
@@ -0,0 +1,256 @@
+package content
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+
+// readHTML sanitizes an HTML document and converts its body to Markdown,
+// the form documents are stored in. title is the text of the <title>
+// element, if any.
+func readHTML(source []byte) (string, string, error) {
+	document, err := html.Parse(bytes.NewReader(source))
+	if err != nil {
+		return "", "", err
+	}
+
+	title := ""
+	var body *html.Node
+	var find func(*html.Node)
+	find = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.Namespace == "" {
+				switch {
+				case child.DataAtom == atom.Title && title == "":
+					title = clampTitle(strings.Join(strings.Fields(textContent(child)), " "))
+				case child.DataAtom == atom.Body && body == nil:
+					body = child
+				}
+			}
+			find(child)
+		}
+	}
+	find(document)
+
+	if body == nil {
+		return title, "", nil
+	}
+	return title, toMarkdown(sanitizeNodes(childNodes(body))) + "\n", nil
+}
+
+
+// toMarkdown converts sanitized nodes to Markdown.
+func toMarkdown(nodes []*html.Node) string {
+	return renderBlockNodes(nodes, markdownBlock, writeMarkdownInline, escapeBlockStart)
+}
+
+
+func markdownBlock(node *html.Node) string {
+	switch node.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(node.Data[1:])
+		text := strings.ReplaceAll(markdownInline(childNodes(node)), "\n", " ")
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", level) + " " + text
+
+	case atom.Hr:
+		return "---"
+
+	case atom.Pre:
+		code := strings.TrimSuffix(textContent(node), "\n")
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		language := ""
+		for _, child := range childNodes(node) {
+			if class, ok := attributeValue(child, "class"); ok && child.DataAtom == atom.Code {
+				language = strings.TrimPrefix(class, "language-")
+			}
+		}
+		return fence + language + "\n" + code + "\n" + fence
+
+	case atom.Blockquote:
+		return prefixLines(toMarkdown(childNodes(node)), "> ", "> ")
+
+	case atom.Ul, atom.Ol:
+		items, markers := listItems(node, "- ")
+		lines := make([]string, 0, len(items))
+		for i, item := range items {
+			text := toMarkdown(childNodes(item))
+			lines = append(lines, prefixLines(text, markers[i], strings.Repeat(" ", len(markers[i]))))
+		}
+		return strings.Join(lines, "\n")
+
+	case atom.Table:
+		return markdownTable(node)
+
+	case atom.P:
+		return escapeBlockStart(markdownInline(childNodes(node)))
+
+	default:
+		return toMarkdown(childNodes(node))
+	}
+}
+
+
+func markdownTable(table *html.Node) string {
+	rows := tableRows(table)
+	columns := 0
+	for _, cells := range rows {
+		columns = max(columns, len(cells))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	writeRow := func(b *strings.Builder, cells []string) {
+		b.WriteString("|")
+		for column := range columns {
+			cell := ""
+			if column < len(cells) {
+				cell = cells[column]
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	var b strings.Builder
+	for i, cells := range rows {
+		texts := make([]string, 0, len(cells))
+		for _, cell := range cells {
+			text := strings.ReplaceAll(markdownInline(childNodes(cell)), "\n", " ")
+			texts = append(texts, strings.ReplaceAll(text, "|", "\\|"))
+		}
+		writeRow(&b, texts)
+		if i == 0 {
+			delimiters := make([]string, columns)
+			for column := range delimiters {
+				align := ""
+				if column < len(cells) {
+					align, _ = attributeValue(cells[column], "align")
+				}
+				switch align {
+				case "left":
+					delimiters[column] = ":--"
+				case "center":
+					delimiters[column] = ":-:"
+				case "right":
+					delimiters[column] = "--:"
+				default:
+					delimiters[column] = "---"
+				}
+			}
+			writeRow(&b, delimiters)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+
+func markdownInline(nodes []*html.Node) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		writeMarkdownInline(&b, node)
+	}
+	return trimLines(b.String())
+}
+
+
+func writeMarkdownInline(b *strings.Builder, node *html.Node) {
+	if node.Type == html.TextNode {
+		b.WriteString(leadingSpace(node.Data) + escapeMarkdown(collapseSpace(node.Data)))
+		return
+	}
+
+	wrap := func(delimiter string) {
+		text := markdownInline(childNodes(node))
+		if text != "" {
+			b.WriteString(delimiter + text + delimiter)
+		}
+	}
+
+	switch node.DataAtom {
+	case atom.Br:
+		b.WriteString("\\\n")
+	case atom.Strong, atom.B:
+		wrap("**")
+	case atom.Em, atom.I:
+		wrap("*")
+	case atom.S, atom.Del:
+		wrap("~~")
+	case atom.Code:
+		code := strings.Join(strings.Fields(textContent(node)), " ")
+		fence := "`"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+			code = " " + code + " "
+		}
+		b.WriteString(fence + code + fence)
+	case atom.A:
+		text := markdownInline(childNodes(node))
+		href, ok := attributeValue(node, "href")
+		if !ok {
+			b.WriteString(text)
+			return
+		}
+		b.WriteString("[" + text + "](" + markdownDestination(href) + ")")
+	case atom.Img:
+		src, _ := attributeValue(node, "src")
+		alt, _ := attributeValue(node, "alt")
+		b.WriteString("![" + escapeMarkdown(alt) + "](" + markdownDestination(src) + ")")
+	default:
+		for _, child := range childNodes(node) {
+			writeMarkdownInline(b, child)
+		}
+	}
+}
+
+
+func markdownDestination(url string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+
+
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_",
+	"[", "\\[", "]", "\\]", "<", "\\<", "~", "\\~",
+)
+
+
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+
+// escapeBlockStart escapes text at the start of a paragraph that would
+// otherwise be read as a heading, quote, list item or rule.
+func escapeBlockStart(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case line == "":
+		case strings.ContainsRune("#>+-=|", rune(line[0])):
+			lines[i] = "\\" + line
+		default:
+			digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+			if digits > 0 && digits < len(line) && (line[digits] == '.' || line[digits] == ')') {
+				lines[i] = line[:digits] + "\\" + line[digits:]
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
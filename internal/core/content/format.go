@@ -0,0 +1,60 @@
+package content
+
+import (
+	"io"
+	"mime"
+	"strings"
+	"unicode"
+)
+
+
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatText     = "txt"
+	FormatJSON     = "json"
+)
+
+
+// Format is a file format documents can be exported to.
+type Format struct {
+	ContentType string
+	Extension   string
+	Write       func(w io.Writer, document Document) error
+}
+
+
+var formats = map[string]Format{
+	FormatMarkdown: {ContentType: "text/markdown; charset=utf-8", Extension: "md", Write: writeMarkdown},
+	FormatHTML:     {ContentType: "text/html; charset=utf-8", Extension: "html", Write: writeHTML},
+	FormatText:     {ContentType: "text/plain; charset=utf-8", Extension: "txt", Write: writeText},
+	FormatJSON:     {ContentType: "application/json", Extension: "json", Write: writeJSON},
+}
+
+
+// LookupFormat returns the export format registered under name.
+func LookupFormat(name string) (Format, bool) {
+	format, ok := formats[name]
+	return format, ok
+}
+
+
+// Disposition returns a Content-Disposition header offering the document as
+// a download named after its title.
+func (f Format) Disposition(title string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_', r == ' ':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(title))
+
+	if name == "" {
+		name = "document"
+	} else if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name + "." + f.Extension})
+}
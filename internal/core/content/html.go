@@ -0,0 +1,352 @@
+package content
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+
+// Document is what an export writes: the stored Markdown body with the
+// metadata shown in the header of rich formats.
+type Document struct {
+	Title     string
+	Body      string
+	Author    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+
+// exportTime formats metadata dates; exports are read outside the app, so
+// they carry the zone rather than relying on the reader's.
+const exportTime = "2006-01-02 15:04 MST"
+
+
+// The exported page loads nothing: styles are inline and the CSP keeps
+// anything that slipped through from running or phoning home.
+var htmlPage = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="Content-Security-Policy" content="default-src 'none'; img-src https: http:; style-src 'unsafe-inline'">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{- with .Author}}
+<meta name="author" content="{{.}}">
+{{- end}}
+<title>{{.Title}}</title>
+<style>
+body { max-width: 48rem; margin: 2rem auto; padding: 0 1rem; font: 16px/1.6 system-ui, sans-serif; color: #1f2328; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 1.5rem; }
+.meta { color: #59636e; font-size: 0.875rem; }
+pre { background: #f6f8fa; padding: 1rem; overflow: auto; }
+code { font-family: ui-monospace, monospace; font-size: 0.875em; }
+blockquote { margin: 0; padding-left: 1rem; border-left: 4px solid #d0d7de; color: #59636e; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 0.25rem 0.75rem; }
+img { max-width: 100%; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p class="meta">{{with .Author}}By {{.}} · {{end}}Created {{.Created}} · Updated {{.Updated}}</p>
+</header>
+<main>
+{{.Body}}
+</main>
+</body>
+</html>
+`))
+
+
+// writeHTML writes the document as a standalone, sanitized HTML page.
+func writeHTML(w io.Writer, document Document) error {
+	nodes, err := sanitizeHTML(renderMarkdown(withoutTitle(document.Title, document.Body)))
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	for _, node := range nodes {
+		if err := html.Render(&body, node); err != nil {
+			return err
+		}
+	}
+
+	return htmlPage.Execute(w, map[string]any{
+		"Title":   document.Title,
+		"Author":  document.Author,
+		"Created": document.CreatedAt.UTC().Format(exportTime),
+		"Updated": document.UpdatedAt.UTC().Format(exportTime),
+		// The body has been through sanitizeNodes and is safe to embed.
+		"Body": template.HTML(body.String()),
+	})
+}
+
+
+// writeText writes the document as plain text with the Markdown markup
+// removed.
+func writeText(w io.Writer, document Document) error {
+	nodes, err := sanitizeHTML(renderMarkdown(withoutTitle(document.Title, document.Body)))
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(document.Title + "\n")
+	b.WriteString(strings.Repeat("=", max(3, len([]rune(document.Title)))) + "\n\n")
+	if document.Author != "" {
+		b.WriteString("Author: " + document.Author + "\n")
+	}
+	b.WriteString("Created: " + document.CreatedAt.UTC().Format(exportTime) + "\n")
+	b.WriteString("Updated: " + document.UpdatedAt.UTC().Format(exportTime) + "\n")
+	if text := plainText(nodes); text != "" {
+		b.WriteString("\n" + text + "\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+
+// withoutTitle drops the leading heading of body when it repeats the title,
+// which the rich formats already show in their header.
+func withoutTitle(title string, body string) string {
+	trimmed := strings.TrimLeft(body, "\n")
+	if !startsWithHeading(trimmed) || MarkdownTitle(trimmed) != title {
+		return body
+	}
+
+	lines := strings.SplitN(trimmed, "\n", 3)
+	if _, ok := atxHeading(strings.TrimSpace(lines[0])); ok {
+		return strings.Join(lines[1:], "\n")
+	}
+	if len(lines) < 3 {
+		return ""
+	}
+	return lines[2]
+}
+
+
+// isBlockElement reports whether node starts a block of its own in text
+// renderings.
+func isBlockElement(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+	switch node.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.P, atom.Pre,
+		atom.Blockquote, atom.Ul, atom.Ol, atom.Hr, atom.Table, atom.Div, atom.Section,
+		atom.Article, atom.Header, atom.Footer, atom.Main, atom.Aside, atom.Figure,
+		atom.Figcaption, atom.Dl, atom.Dt, atom.Dd:
+		return true
+	}
+	return false
+}
+
+
+// renderBlockNodes renders a sequence of sanitized nodes block by block,
+// joining the blocks with blank lines. Runs of inline nodes between blocks
+// form a block of their own, finished by paragraph.
+func renderBlockNodes(
+	nodes []*html.Node,
+	block func(*html.Node) string,
+	inline func(*strings.Builder, *html.Node),
+	paragraph func(string) string,
+) string {
+	blocks := make([]string, 0)
+	var run strings.Builder
+	flush := func() {
+		if text := trimLines(run.String()); text != "" {
+			blocks = append(blocks, paragraph(text))
+		}
+		run.Reset()
+	}
+
+	for _, node := range nodes {
+		if !isBlockElement(node) {
+			inline(&run, node)
+			continue
+		}
+		flush()
+		if text := block(node); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+	flush()
+	return strings.Join(blocks, "\n\n")
+}
+
+
+// trimLines trims every line of inline text and the text as a whole.
+func trimLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+
+// collapseSpace collapses whitespace runs the way HTML renders text.
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ") + trailingSpace(text)
+}
+
+
+func trailingSpace(text string) string {
+	if text != "" && strings.TrimSpace(text) != "" && strings.TrimRight(text, " \t\n\r\f") != text {
+		return " "
+	}
+	return ""
+}
+
+
+func leadingSpace(text string) string {
+	if text != "" && strings.TrimLeft(text, " \t\n\r\f") != text {
+		return " "
+	}
+	return ""
+}
+
+
+// prefixLines prefixes the first line of text with first and the others
+// with rest; blank lines are left without trailing spaces.
+func prefixLines(text string, first string, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		lines[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+
+// listItems returns the li children of a list with the marker of each.
+func listItems(list *html.Node, bullet string) ([]*html.Node, []string) {
+	start := 1
+	if value, ok := attributeValue(list, "start"); ok {
+		start, _ = strconv.Atoi(value)
+	}
+
+	items := make([]*html.Node, 0)
+	markers := make([]string, 0)
+	for _, child := range childNodes(list) {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+		marker := bullet
+		if list.DataAtom == atom.Ol {
+			marker = strconv.Itoa(start+len(items)) + ". "
+		}
+		items = append(items, child)
+		markers = append(markers, marker)
+	}
+	return items, markers
+}
+
+
+// tableRows returns the cells of every row of a table, header rows first.
+func tableRows(table *html.Node) [][]*html.Node {
+	rows := make([][]*html.Node, 0)
+	var visit func(*html.Node)
+	visit = func(node *html.Node) {
+		for _, child := range childNodes(node) {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Thead, atom.Tbody:
+				visit(child)
+			case atom.Tr:
+				cells := make([]*html.Node, 0)
+				for _, cell := range childNodes(child) {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Th || cell.DataAtom == atom.Td) {
+						cells = append(cells, cell)
+					}
+				}
+				rows = append(rows, cells)
+			}
+		}
+	}
+	visit(table)
+	return rows
+}
+
+
+// plainText renders sanitized nodes as plain text.
+func plainText(nodes []*html.Node) string {
+	return renderBlockNodes(nodes, plainTextBlock, writePlainInline, strings.TrimSpace)
+}
+
+
+func plainTextBlock(node *html.Node) string {
+	switch node.DataAtom {
+	case atom.Hr:
+		return "----------"
+
+	case atom.Pre:
+		return strings.TrimSuffix(textContent(node), "\n")
+
+	case atom.Blockquote:
+		return prefixLines(plainText(childNodes(node)), "> ", "> ")
+
+	case atom.Ul, atom.Ol:
+		items, markers := listItems(node, "- ")
+		lines := make([]string, 0, len(items))
+		for i, item := range items {
+			text := plainText(childNodes(item))
+			lines = append(lines, prefixLines(text, markers[i], strings.Repeat(" ", len(markers[i]))))
+		}
+		return strings.Join(lines, "\n")
+
+	case atom.Table:
+		rows := make([]string, 0)
+		for _, cells := range tableRows(node) {
+			texts := make([]string, 0, len(cells))
+			for _, cell := range cells {
+				texts = append(texts, strings.ReplaceAll(plainText(childNodes(cell)), "\n", " "))
+			}
+			rows = append(rows, strings.Join(texts, " | "))
+		}
+		return strings.Join(rows, "\n")
+
+	default:
+		return plainText(childNodes(node))
+	}
+}
+
+
+func writePlainInline(b *strings.Builder, node *html.Node) {
+	switch {
+	case node.Type == html.TextNode:
+		b.WriteString(leadingSpace(node.Data) + collapseSpace(node.Data))
+	case node.DataAtom == atom.Br:
+		b.WriteString("\n")
+	case node.DataAtom == atom.Img:
+		alt, _ := attributeValue(node, "alt")
+		b.WriteString(alt)
+	case node.DataAtom == atom.A:
+		start := b.Len()
+		for _, child := range childNodes(node) {
+			writePlainInline(b, child)
+		}
+		text := strings.TrimSpace(b.String()[start:])
+		if href, ok := attributeValue(node, "href"); ok && href != text && !strings.HasPrefix(href, "#") {
+			b.WriteString(" (" + strings.TrimPrefix(href, "mailto:") + ")")
+		}
+	default:
+		for _, child := range childNodes(node) {
+			writePlainInline(b, child)
+		}
+	}
+}
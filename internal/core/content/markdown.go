@@ -0,0 +1,138 @@
+// Package content converts document content between the stored form and the
+// file formats documents are imported from and exported to.
+package content
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+
+// MaxTitleLength caps titles taken from imported files, in runes.
+const MaxTitleLength = 255
+
+
+// NormalizeMarkdown prepares an imported file for storage: it drops a UTF-8
+// byte order mark and converts line endings to \n. ok is false when source
+// is not valid UTF-8.
+func NormalizeMarkdown(source []byte) (string, bool) {
+	if !utf8.Valid(source) {
+		return "", false
+	}
+	text := strings.TrimPrefix(string(source), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n"), true
+}
+
+
+// MarkdownTitle returns the text of the first heading in source, ATX ("# x")
+// or setext ("x" underlined with === or ---), or "" when there is none.
+// Headings inside fenced code blocks and YAML front matter are ignored.
+func MarkdownTitle(source string) string {
+	lines := strings.Split(source, "\n")
+	start := skipFrontMatter(lines)
+
+	fence := ""
+	previous := ""
+	for _, line := range lines[start:] {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if indent < 4 && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			previous = ""
+			continue
+		}
+
+		if indent < 4 {
+			if title, ok := atxHeading(trimmed); ok {
+				return clampTitle(title)
+			}
+			if previous != "" && isSetextUnderline(trimmed) {
+				return clampTitle(previous)
+			}
+		}
+		previous = trimmed
+	}
+	return ""
+}
+
+
+func skipFrontMatter(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if line := strings.TrimSpace(lines[i]); line == "---" || line == "..." {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+
+func atxHeading(line string) (string, bool) {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return "", false
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return "", false
+	}
+
+	// A closing sequence of #s is only stripped when preceded by a space.
+	rest = strings.TrimSpace(rest)
+	if trimmed := strings.TrimRight(rest, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		rest = strings.TrimSpace(trimmed)
+	}
+	return rest, rest != ""
+}
+
+
+func isSetextUnderline(line string) bool {
+	if line == "" {
+		return false
+	}
+	return strings.Trim(line, "=") == "" || strings.Trim(line, "-") == ""
+}
+
+
+func clampTitle(title string) string {
+	if utf8.RuneCountInString(title) <= MaxTitleLength {
+		return title
+	}
+	return string([]rune(title)[:MaxTitleLength])
+}
+
+
+// writeMarkdown writes the document as Markdown. The title becomes a level one
+// heading unless the body already starts with it, so an imported file exports
+// unchanged.
+func writeMarkdown(w io.Writer, document Document) error {
+	if withoutTitle(document.Title, document.Body) == document.Body {
+		if _, err := io.WriteString(w, "# "+document.Title+"\n\n"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, document.Body)
+	return err
+}
+
+
+// startsWithHeading reports whether the first non-blank line of body is an
+// ATX heading or the text of a setext heading.
+func startsWithHeading(body string) bool {
+	lines := strings.Split(strings.TrimLeft(body, "\n"), "\n")
+	if _, ok := atxHeading(strings.TrimSpace(lines[0])); ok {
+		return true
+	}
+	return len(lines) > 1 && isSetextUnderline(strings.TrimSpace(lines[1]))
+}
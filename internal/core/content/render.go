@@ -0,0 +1,815 @@
+package content
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+
+// renderMarkdown converts Markdown to HTML. It covers the CommonMark blocks
+// and inlines documents are written with, plus GFM tables and strikethrough.
+// Raw HTML blocks are passed through, so the result must be sanitized before
+// it is served.
+func renderMarkdown(source string) string {
+	lines := strings.Split(source, "\n")
+	fenced := false
+	for i, line := range lines {
+		if isFence(strings.TrimSpace(line)) {
+			fenced = !fenced
+		} else if !fenced {
+			lines[i] = expandTabs(line)
+		}
+	}
+
+	var b strings.Builder
+	renderBlocks(&b, lines, false)
+	return b.String()
+}
+
+
+// expandTabs replaces tabs in the indentation of a line with four spaces.
+// Fenced code keeps its tabs.
+func expandTabs(line string) string {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if !strings.Contains(line[:indent], "\t") {
+		return line
+	}
+	return strings.ReplaceAll(line[:indent], "\t", "    ") + line[indent:]
+}
+
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+
+// renderBlocks writes the blocks of lines. In a tight list paragraphs are
+// written without <p> tags.
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case leadingSpaces(line) >= 4:
+			start := i
+			for i < len(lines) && (isBlank(lines[i]) || leadingSpaces(lines[i]) >= 4) {
+				i++
+			}
+			end := i
+			for end > start && isBlank(lines[end-1]) {
+				end--
+			}
+			code := make([]string, 0, end-start)
+			for _, codeLine := range lines[start:end] {
+				code = append(code, strings.TrimPrefix(codeLine, "    "))
+			}
+			writeCodeBlock(b, "", code)
+
+		case isFence(trimmed):
+			marker := trimmed[:fenceLength(trimmed)]
+			info := strings.TrimSpace(trimmed[len(marker):])
+			indent := leadingSpaces(line)
+			i++
+
+			code := make([]string, 0)
+			for i < len(lines) {
+				closing := strings.TrimSpace(lines[i])
+				if strings.HasPrefix(closing, marker) && strings.Trim(closing, marker[:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, trimIndent(lines[i], indent))
+				i++
+			}
+			writeCodeBlock(b, info, code)
+
+		case isATXHeading(trimmed):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			text, _ := atxHeading(trimmed)
+			writeHeading(b, level, text)
+			i++
+
+		case isThematicBreak(trimmed):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			quote := make([]string, 0)
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				inner := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(inner, " "))
+				i++
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quote, false)
+			b.WriteString("</blockquote>\n")
+
+		case isListItem(line):
+			i = renderList(b, lines, i)
+
+		case isHTMLBlock(trimmed):
+			for i < len(lines) && !isBlank(lines[i]) {
+				b.WriteString(lines[i])
+				b.WriteString("\n")
+				i++
+			}
+
+		case i+1 < len(lines) && strings.Contains(trimmed, "|") && isTableDelimiter(lines[i+1]):
+			i = renderTable(b, lines, i)
+
+		default:
+			i = renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+
+func renderParagraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	paragraph := make([]string, 0)
+	for i < len(lines) {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			break
+		}
+
+		if len(paragraph) > 0 && leadingSpaces(line) < 4 {
+			if isSetextUnderline(trimmed) {
+				level := 1
+				if trimmed[0] == '-' {
+					level = 2
+				}
+				writeHeading(b, level, strings.Join(paragraph, "\n"))
+				return i + 1
+			}
+			if interruptsParagraph(line) {
+				break
+			}
+		}
+
+		paragraph = append(paragraph, strings.TrimLeft(line, " "))
+		i++
+	}
+
+	text := strings.TrimRight(strings.Join(paragraph, "\n"), " ")
+	if !tight {
+		b.WriteString("<p>")
+	}
+	renderInline(b, text)
+	if !tight {
+		b.WriteString("</p>")
+	}
+	b.WriteString("\n")
+	return i
+}
+
+
+func interruptsParagraph(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return isATXHeading(trimmed) || isFence(trimmed) || isThematicBreak(trimmed) ||
+		strings.HasPrefix(trimmed, ">") || isListItem(line) || isHTMLBlock(trimmed)
+}
+
+
+func writeHeading(b *strings.Builder, level int, text string) {
+	tag := "h" + strconv.Itoa(level)
+	b.WriteString("<" + tag + ">")
+	renderInline(b, strings.TrimSpace(text))
+	b.WriteString("</" + tag + ">\n")
+}
+
+
+func writeCodeBlock(b *strings.Builder, info string, code []string) {
+	b.WriteString("<pre><code")
+	if language, _, _ := strings.Cut(info, " "); language != "" {
+		b.WriteString(` class="language-` + html.EscapeString(language) + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+}
+
+
+func trimIndent(line string, indent int) string {
+	return line[min(indent, leadingSpaces(line)):]
+}
+
+
+func fenceLength(line string) int {
+	return len(line) - len(strings.TrimLeft(line, line[:1]))
+}
+
+
+func isFence(line string) bool {
+	if !strings.HasPrefix(line, "```") && !strings.HasPrefix(line, "~~~") {
+		return false
+	}
+	// A backtick fence cannot have backticks in its info string.
+	return line[0] == '~' || !strings.Contains(line[fenceLength(line):], "`")
+}
+
+
+func isATXHeading(line string) bool {
+	_, ok := atxHeading(line)
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	return ok || (level >= 1 && level <= 6 && strings.Trim(line, "# ") == "")
+}
+
+
+func isThematicBreak(line string) bool {
+	if line == "" || !strings.ContainsRune("-*_", rune(line[0])) {
+		return false
+	}
+	compact := strings.ReplaceAll(line, " ", "")
+	return len(compact) >= 3 && strings.Trim(compact, line[:1]) == ""
+}
+
+
+// isHTMLBlock reports whether line starts with a comment, declaration or a
+// tag name ending the way a tag does. Autolinks such as <https://…> do not.
+func isHTMLBlock(line string) bool {
+	if len(line) < 2 || line[0] != '<' {
+		return false
+	}
+	if line[1] == '!' {
+		return true
+	}
+
+	name := strings.TrimPrefix(line[1:], "/")
+	end := 0
+	for end < len(name) {
+		c := name[end]
+		letter := ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+		if !letter && (end == 0 || !(('0' <= c && c <= '9') || c == '-')) {
+			break
+		}
+		end++
+	}
+	return end > 0 && (end == len(name) || strings.IndexByte(" \t/>", name[end]) >= 0)
+}
+
+
+type listMarker struct {
+	ordered bool
+	// delimiter is the bullet character or the . or ) after the number.
+	delimiter byte
+	start     int
+	// contentIndent is the column the content of the item starts at.
+	contentIndent int
+}
+
+
+func parseListMarker(line string) (listMarker, bool) {
+	indent := leadingSpaces(line)
+	if indent >= 4 {
+		return listMarker{}, false
+	}
+	rest := line[indent:]
+
+	var marker listMarker
+	width := 0
+	switch {
+	case rest != "" && strings.ContainsRune("-+*", rune(rest[0])):
+		marker.delimiter = rest[0]
+		width = 1
+	default:
+		digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+		if digits == 0 || digits > 9 || digits == len(rest) || (rest[digits] != '.' && rest[digits] != ')') {
+			return listMarker{}, false
+		}
+		marker.ordered = true
+		marker.delimiter = rest[digits]
+		marker.start, _ = strconv.Atoi(rest[:digits])
+		width = digits + 1
+	}
+
+	if width == len(rest) {
+		marker.contentIndent = indent + width + 1
+		return marker, true
+	}
+	if rest[width] != ' ' {
+		return listMarker{}, false
+	}
+	marker.contentIndent = indent + width + 1
+	return marker, true
+}
+
+
+func isListItem(line string) bool {
+	_, ok := parseListMarker(line)
+	return ok && !isThematicBreak(strings.TrimSpace(line))
+}
+
+
+func renderList(b *strings.Builder, lines []string, i int) int {
+	first, _ := parseListMarker(lines[i])
+
+	items := make([][]string, 0)
+	loose := false
+	for i < len(lines) {
+		marker, ok := parseListMarker(lines[i])
+		if !ok || isThematicBreak(strings.TrimSpace(lines[i])) ||
+			marker.ordered != first.ordered || marker.delimiter != first.delimiter {
+			break
+		}
+
+		item := []string{strings.TrimLeft(lines[i][min(marker.contentIndent, len(lines[i])):], " ")}
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				next := i
+				for next < len(lines) && isBlank(lines[next]) {
+					next++
+				}
+				if next < len(lines) && leadingSpaces(lines[next]) >= marker.contentIndent {
+					for ; i < next; i++ {
+						item = append(item, "")
+					}
+					loose = true
+					continue
+				}
+				break
+			}
+
+			if leadingSpaces(line) >= marker.contentIndent {
+				item = append(item, line[marker.contentIndent:])
+			} else if !interruptsParagraph(line) && !isBlank(item[len(item)-1]) {
+				// A lazy continuation of the paragraph in the item.
+				item = append(item, line)
+			} else {
+				break
+			}
+			i++
+		}
+		items = append(items, item)
+
+		// Blank lines between items make the list loose.
+		next := i
+		for next < len(lines) && isBlank(lines[next]) {
+			next++
+		}
+		if next > i && next < len(lines) {
+			if marker, ok := parseListMarker(lines[next]); ok && marker.ordered == first.ordered && marker.delimiter == first.delimiter {
+				loose = true
+				i = next
+			}
+		}
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(first.start) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		b.WriteString("<li>")
+		var inner strings.Builder
+		renderBlocks(&inner, item, !loose)
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+
+func isTableDelimiter(line string) bool {
+	cells := splitTableRow(line)
+	if len(cells) == 0 {
+		return false
+	}
+	for _, cell := range cells {
+		cell = strings.Trim(cell, ":")
+		if cell == "" || strings.Trim(cell, "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+
+// splitTableRow splits a table row into trimmed cells; escaped pipes stay in
+// the cell.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	cells := make([]string, 0)
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	return append(cells, strings.TrimSpace(line[start:]))
+}
+
+
+func renderTable(b *strings.Builder, lines []string, i int) int {
+	header := splitTableRow(lines[i])
+	aligns := make([]string, len(header))
+	for column, cell := range splitTableRow(lines[i+1]) {
+		if column >= len(aligns) {
+			break
+		}
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns[column] = "center"
+		case strings.HasSuffix(cell, ":"):
+			aligns[column] = "right"
+		case strings.HasPrefix(cell, ":"):
+			aligns[column] = "left"
+		}
+	}
+
+	writeRow := func(cells []string, tag string) {
+		b.WriteString("<tr>\n")
+		for column := range header {
+			b.WriteString("<" + tag)
+			if aligns[column] != "" {
+				b.WriteString(` align="` + aligns[column] + `"`)
+			}
+			b.WriteString(">")
+			if column < len(cells) {
+				renderInline(b, strings.ReplaceAll(cells[column], "\\|", "|"))
+			}
+			b.WriteString("</" + tag + ">\n")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	writeRow(header, "th")
+	b.WriteString("</thead>\n")
+
+	i += 2
+	if i < len(lines) && !isBlank(lines[i]) && strings.Contains(lines[i], "|") {
+		b.WriteString("<tbody>\n")
+		for i < len(lines) && !isBlank(lines[i]) && strings.Contains(lines[i], "|") {
+			writeRow(splitTableRow(lines[i]), "td")
+			i++
+		}
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+	return i
+}
+
+
+// renderInline writes the inline content of a block: code spans, links,
+// images, autolinks, emphasis, strikethrough, hard breaks and escapes.
+func renderInline(b *strings.Builder, s string) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+
+		case c == '`':
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			end := findBacktickRun(s, i+run, run)
+			if end < 0 {
+				b.WriteString(s[i : i+run])
+				i += run
+				continue
+			}
+			code := strings.ReplaceAll(s[i+run:end], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+			i = end + run
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			text, destination, title, end, ok := parseLink(s, i+1)
+			if !ok {
+				b.WriteString("!")
+				i++
+				continue
+			}
+			b.WriteString(`<img src="` + html.EscapeString(destination) + `" alt="` + html.EscapeString(inlineText(text)) + `"` + titleAttribute(title) + `>`)
+			i = end
+
+		case c == '[':
+			text, destination, title, end, ok := parseLink(s, i)
+			if !ok {
+				b.WriteString("[")
+				i++
+				continue
+			}
+			b.WriteString(`<a href="` + html.EscapeString(destination) + `"` + titleAttribute(title) + `>`)
+			renderInline(b, text)
+			b.WriteString("</a>")
+			i = end
+
+		case c == '<':
+			end := strings.IndexByte(s[i:], '>')
+			target := ""
+			if end > 0 {
+				target = s[i+1 : i+end]
+			}
+			if !isAutolink(target) {
+				b.WriteString("&lt;")
+				i++
+				continue
+			}
+			href := target
+			if !strings.Contains(target, ":") {
+				href = "mailto:" + target
+			}
+			b.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(target) + "</a>")
+			i += end + 1
+
+		case c == '*' || c == '_' || (c == '~' && strings.HasPrefix(s[i:], "~~")):
+			i = renderDelimited(b, s, i)
+
+		case c == ' ':
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], " "))
+			if i+run < len(s) && s[i+run] == '\n' {
+				if run >= 2 {
+					b.WriteString("<br>")
+				}
+				b.WriteString("\n")
+				i += run + 1
+				continue
+			}
+			b.WriteString(s[i : i+run])
+			i += run
+
+		default:
+			b.WriteString(html.EscapeString(s[i : i+1]))
+			i++
+		}
+	}
+}
+
+
+// renderDelimited writes emphasis, strong emphasis or strikethrough opened
+// at i, or the delimiters as text when they are not closed.
+func renderDelimited(b *strings.Builder, s string, i int) int {
+	c := s[i]
+	run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+	literal := func() int {
+		b.WriteString(s[i : i+run])
+		return i + run
+	}
+
+	// The opening run must be followed by text, and underscores do not
+	// emphasise inside words.
+	if i+run >= len(s) || s[i+run] == ' ' || s[i+run] == '\n' {
+		return literal()
+	}
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return literal()
+	}
+
+	var tags []string
+	var delimiter string
+	switch {
+	case c == '~' && run == 2:
+		delimiter, tags = "~~", []string{"del"}
+	case c == '~':
+		return literal()
+	case run >= 3:
+		delimiter, tags = strings.Repeat(string(c), 3), []string{"strong", "em"}
+	case run == 2:
+		delimiter, tags = strings.Repeat(string(c), 2), []string{"strong"}
+	default:
+		delimiter, tags = string(c), []string{"em"}
+	}
+
+	start := i + len(delimiter)
+	end := findClosing(s, start, delimiter)
+	if end < 0 {
+		return literal()
+	}
+
+	for _, tag := range tags {
+		b.WriteString("<" + tag + ">")
+	}
+	renderInline(b, s[start:end])
+	for j := len(tags) - 1; j >= 0; j-- {
+		b.WriteString("</" + tags[j] + ">")
+	}
+	return end + len(delimiter)
+}
+
+
+// findClosing returns the index of the delimiter run closing one opened
+// before start, or -1. A closing run follows text and is exactly as long as
+// the delimiter.
+func findClosing(s string, start int, delimiter string) int {
+	c := delimiter[0]
+	for i := start; i < len(s); {
+		switch {
+		case s[i] == '\\':
+			i += 2
+		case s[i] == '`':
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			if end := findBacktickRun(s, i+run, run); end >= 0 {
+				i = end + run
+			} else {
+				i += run
+			}
+		case s[i] == c:
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(c)))
+			closes := run == len(delimiter) && i > start && s[i-1] != ' ' && s[i-1] != '\n'
+			if closes && c == '_' && i+run < len(s) && isWordByte(s[i+run]) {
+				closes = false
+			}
+			if closes {
+				return i
+			}
+			i += run
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+
+func findBacktickRun(s string, from int, length int) int {
+	for i := from; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+		if run == length {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+
+// parseLink parses [text](destination "title") starting at the bracket at
+// i and returns the text, destination, title and the index after it.
+func parseLink(s string, i int) (string, string, string, int, bool) {
+	depth := 0
+	closeBracket := -1
+	for j := i; j < len(s) && closeBracket < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeBracket = j
+			}
+		}
+	}
+	if closeBracket < 0 || closeBracket+1 >= len(s) || s[closeBracket+1] != '(' {
+		return "", "", "", 0, false
+	}
+
+	depth = 0
+	closeParen := -1
+	for j := closeBracket + 1; j < len(s) && closeParen < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				closeParen = j
+			}
+		case '\n':
+			return "", "", "", 0, false
+		}
+	}
+	if closeParen < 0 {
+		return "", "", "", 0, false
+	}
+
+	inside := strings.TrimSpace(s[closeBracket+2 : closeParen])
+	destination, rest := inside, ""
+	if strings.HasPrefix(inside, "<") {
+		end := strings.IndexByte(inside, '>')
+		if end < 0 {
+			return "", "", "", 0, false
+		}
+		destination, rest = inside[1:end], inside[end+1:]
+	} else if space := strings.IndexAny(inside, " \t"); space >= 0 {
+		destination, rest = inside[:space], inside[space:]
+	}
+
+	title, ok := linkTitle(strings.TrimSpace(rest))
+	if !ok {
+		return "", "", "", 0, false
+	}
+	return s[i+1 : closeBracket], unescapeMarkdown(destination), title, closeParen + 1, true
+}
+
+
+// linkTitle reads what follows a link destination: nothing, or a title in
+// double quotes, single quotes or parentheses.
+func linkTitle(s string) (string, bool) {
+	if s == "" {
+		return "", true
+	}
+	closing := map[byte]byte{'"': '"', '\'': '\'', '(': ')'}[s[0]]
+	if len(s) < 2 || closing == 0 || s[len(s)-1] != closing {
+		return "", false
+	}
+	return unescapeMarkdown(s[1 : len(s)-1]), true
+}
+
+
+func titleAttribute(title string) string {
+	if title == "" {
+		return ""
+	}
+	return ` title="` + html.EscapeString(title) + `"`
+}
+
+
+func isAutolink(target string) bool {
+	if target == "" || strings.ContainsAny(target, " <>\n") {
+		return false
+	}
+	lower := strings.ToLower(target)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:") {
+		return true
+	}
+	at := strings.IndexByte(target, '@')
+	return at > 0 && strings.Contains(target[at:], ".")
+}
+
+
+// inlineText returns Markdown inline content as plain text, for alt texts.
+func inlineText(s string) string {
+	var b strings.Builder
+	renderInline(&b, s)
+	nodes, err := html.ParseFragment(strings.NewReader(b.String()), bodyContext())
+	if err != nil {
+		return s
+	}
+	var text strings.Builder
+	for _, node := range nodes {
+		text.WriteString(textContent(node))
+	}
+	return text.String()
+}
+
+
+func unescapeMarkdown(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
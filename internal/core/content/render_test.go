@@ -0,0 +1,249 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+
+// renderSafe runs Markdown through the same steps as the HTML export body.
+func renderSafe(t testing.TB, source string) string {
+	t.Helper()
+
+	nodes, err := sanitizeHTML(renderMarkdown(source))
+	if err != nil {
+		t.Fatalf("sanitizeHTML(%q): %v", source, err)
+	}
+	var b strings.Builder
+	for _, node := range nodes {
+		if err := html.Render(&b, node); err != nil {
+			t.Fatalf("html.Render: %v", err)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "emphasis",
+			source: "**bold** _em_ ~~del~~",
+			want:   "<p><strong>bold</strong> <em>em</em> <del>del</del></p>",
+		},
+		{
+			name:   "underscores inside words",
+			source: "snake_case_name",
+			want:   "<p>snake_case_name</p>",
+		},
+		{
+			name:   "unclosed emphasis",
+			source: "**open",
+			want:   "<p>**open</p>",
+		},
+		{
+			name:   "nested bullet lists",
+			source: "- a\n  - b\n    - c\n- d",
+			want:   "<ul>\n<li>a\n<ul>\n<li>b\n<ul>\n<li>c</li>\n</ul></li>\n</ul></li>\n<li>d</li>\n</ul>",
+		},
+		{
+			name:   "bullet list in ordered list",
+			source: "1. a\n2. b\n   - c",
+			want:   "<ol>\n<li>a</li>\n<li>b\n<ul>\n<li>c</li>\n</ul></li>\n</ol>",
+		},
+		{
+			name:   "ordered list start",
+			source: "3. c\n4. d",
+			want:   "<ol start=\"3\">\n<li>c</li>\n<li>d</li>\n</ol>",
+		},
+		{
+			name:   "fenced code keeps html as text",
+			source: "```html\n<script>alert(1)</script>\n<b onclick=x>\n```",
+			want:   "<pre><code class=\"language-html\">&lt;script&gt;alert(1)&lt;/script&gt;\n&lt;b onclick=x&gt;\n</code></pre>",
+		},
+		{
+			name:   "fence info string is not an attribute",
+			source: "```go\" onclick=\"alert(1)\nx\n```",
+			want:   "<pre><code>x\n</code></pre>",
+		},
+		{
+			name:   "unterminated fence",
+			source: "```\n<img src=x onerror=alert(1)>",
+			want:   "<pre><code>&lt;img src=x onerror=alert(1)&gt;\n</code></pre>",
+		},
+		{
+			name:   "code span keeps html as text",
+			source: "`<b>x</b>`",
+			want:   "<p><code>&lt;b&gt;x&lt;/b&gt;</code></p>",
+		},
+		{
+			name:   "link title with double quotes",
+			source: `[x](https://example.com "a \"q\" b")`,
+			want:   `<p><a href="https://example.com" title="a &#34;q&#34; b">x</a></p>`,
+		},
+		{
+			name:   "link title with a quote in single quotes",
+			source: `[x](https://example.com 'it"s')`,
+			want:   `<p><a href="https://example.com" title="it&#34;s">x</a></p>`,
+		},
+		{
+			name:   "link title in parentheses",
+			source: `[x](https://example.com (note))`,
+			want:   `<p><a href="https://example.com" title="note">x</a></p>`,
+		},
+		{
+			name:   "link title breaking out of the attribute",
+			source: `[x](https://example.com "\"><script>alert(1)</script>")`,
+			want:   `<p><a href="https://example.com" title="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">x</a></p>`,
+		},
+		{
+			name:   "image with title",
+			source: `![alt *text*](https://example.com/a.png "t")`,
+			want:   `<p><img src="https://example.com/a.png" alt="alt text" title="t"/></p>`,
+		},
+		{
+			name:   "destination in angle brackets",
+			source: "[x](<https://example.com/a b>)",
+			want:   `<p><a href="https://example.com/a b">x</a></p>`,
+		},
+		{
+			name:   "text after destination is not a link",
+			source: "[x](https://example.com nope)",
+			want:   "<p>[x](https://example.com nope)</p>",
+		},
+		{
+			name:   "relative and mailto links",
+			source: "[a](/documents/2) [b](mailto:a@example.com)",
+			want:   `<p><a href="/documents/2">a</a> <a href="mailto:a@example.com">b</a></p>`,
+		},
+		{
+			name:   "autolink at line start",
+			source: "<https://example.com>",
+			want:   `<p><a href="https://example.com">https://example.com</a></p>`,
+		},
+		{
+			name:   "email autolink",
+			source: "mail <a@example.com>",
+			want:   `<p>mail <a href="mailto:a@example.com">a@example.com</a></p>`,
+		},
+		{
+			name:   "script autolink stays text",
+			source: "<javascript:alert(1)>",
+			want:   "<p>&lt;javascript:alert(1)&gt;</p>",
+		},
+		{
+			name:   "table with alignment",
+			source: "| a | b |\n|---|:-:|\n| 1 | 2 |",
+			want:   "<table>\n<thead>\n<tr>\n<th>a</th>\n<th align=\"center\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td align=\"center\">2</td>\n</tr>\n</tbody>\n</table>",
+		},
+		{
+			name:   "html in a heading is text",
+			source: "# Title <script>x</script>",
+			want:   "<h1>Title &lt;script&gt;x&lt;/script&gt;</h1>",
+		},
+		{
+			name:   "html in a blockquote is text",
+			source: "> quote <img src=x onerror=alert(1)>",
+			want:   "<blockquote>\n<p>quote &lt;img src=x onerror=alert(1)&gt;</p>\n</blockquote>",
+		},
+		{
+			name:   "hard line break",
+			source: "a  \nb",
+			want:   "<p>a<br/>\nb</p>",
+		},
+		{
+			name:   "escaped punctuation",
+			source: `\*not em\* \<b\>`,
+			want:   "<p>*not em* &lt;b&gt;</p>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := renderSafe(t, test.source); got != test.want {
+				t.Errorf("render(%q)\n got: %q\nwant: %q", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+
+// assertInert fails when rendered HTML could run script: a script element,
+// an event handler attribute or a URL with a scriptable scheme. Attributes
+// are checked on the parsed output, since the same words are harmless as
+// escaped text.
+func assertInert(t *testing.T, source string, rendered string) {
+	t.Helper()
+
+	if strings.Contains(strings.ToLower(rendered), "<script") {
+		t.Fatalf("render(%q) kept a script element: %q", source, rendered)
+	}
+
+	nodes, err := html.ParseFragment(strings.NewReader(rendered), bodyContext())
+	if err != nil {
+		t.Fatalf("reparsing %q: %v", rendered, err)
+	}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if _, ok := allowedTags[node.DataAtom]; !ok || node.Namespace != "" {
+				t.Fatalf("render(%q) kept element <%s>: %q", source, node.Data, rendered)
+			}
+			for _, attribute := range node.Attr {
+				if strings.HasPrefix(strings.ToLower(attribute.Key), "on") {
+					t.Fatalf("render(%q) kept %s=: %q", source, attribute.Key, rendered)
+				}
+				if attribute.Key == "href" || attribute.Key == "src" {
+					compact := strings.ToLower(strings.Map(func(r rune) rune {
+						if r <= ' ' || r == 0x7f {
+							return -1
+						}
+						return r
+					}, attribute.Val))
+					for _, scheme := range []string{"javascript:", "vbscript:", "data:"} {
+						if strings.HasPrefix(compact, scheme) {
+							t.Fatalf("render(%q) kept a %s URL: %q", source, scheme, rendered)
+						}
+					}
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+}
+
+
+func FuzzRender(f *testing.F) {
+	for _, seed := range []string{
+		"[x](javascript:alert(1))",
+		"[x](JaVa\tScRiPt:alert(1))",
+		`<a href="jav&#x09;ascript:alert(1)">x</a>`,
+		`<a href="&#106;avascript:alert(1)">x</a>`,
+		`<img src=x onerror=alert(1)>`,
+		"<svg><script>alert(1)</script></svg>",
+		"<math><mtext><img src=x onerror=alert(1)></mtext></math>",
+		"<scr<script>ipt>alert(1)</script>",
+		"<noscript><p title=\"</noscript><img src=x onerror=alert(1)>\"></noscript>",
+		"```\n<script>\n```",
+		"- a\n  - <b onclick=x>b</b>",
+		"| <i onmouseover=x> | b |\n|---|---|",
+		`![a](https://example.com/a.png "t\" onerror=\"x")`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		assertInert(t, source, renderSafe(t, source))
+	})
+}
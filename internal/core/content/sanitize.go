@@ -0,0 +1,206 @@
+package content
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+
+// allowedTags are the structural elements kept by sanitizeNodes, with the
+// attributes each may keep. Other elements are unwrapped to their content.
+var allowedTags = map[atom.Atom][]string{
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Blockquote: nil, atom.Pre: nil,
+	atom.Div: nil, atom.Section: nil, atom.Article: nil, atom.Header: nil, atom.Footer: nil,
+	atom.Main: nil, atom.Aside: nil, atom.Figure: nil, atom.Figcaption: nil,
+	atom.Dl: nil, atom.Dt: nil, atom.Dd: nil, atom.Span: nil,
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil,
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil,
+	atom.S: nil, atom.Del: nil, atom.Sup: nil, atom.Sub: nil,
+	atom.Code: {"class"},
+	atom.A:    {"href", "title"},
+	atom.Img:  {"src", "alt", "title"},
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil,
+	atom.Th: {"align", "colspan", "rowspan"},
+	atom.Td: {"align", "colspan", "rowspan"},
+}
+
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Head: true, atom.Title: true, atom.Meta: true, atom.Link: true, atom.Base: true,
+	atom.Iframe: true, atom.Frame: true, atom.Frameset: true, atom.Object: true,
+	atom.Embed: true, atom.Applet: true, atom.Canvas: true,
+	atom.Audio: true, atom.Video: true, atom.Source: true, atom.Track: true,
+	atom.Form: true, atom.Input: true, atom.Button: true, atom.Select: true, atom.Textarea: true,
+}
+
+
+var languageClass = regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]{1,32}$`)
+
+
+func bodyContext() *html.Node {
+	return &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+}
+
+
+// sanitizeHTML parses an HTML fragment and returns what survives
+// sanitizeNodes.
+func sanitizeHTML(source string) ([]*html.Node, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(source), bodyContext())
+	if err != nil {
+		return nil, err
+	}
+	return sanitizeNodes(nodes), nil
+}
+
+
+// sanitizeNodes copies nodes keeping the allowed elements with their allowed
+// attributes. Scripts, styles, embedded content, SVG, MathML and comments
+// are dropped; any other element is replaced by its sanitized children.
+func sanitizeNodes(nodes []*html.Node) []*html.Node {
+	clean := make([]*html.Node, 0, len(nodes))
+	for _, node := range nodes {
+		clean = append(clean, sanitizeNode(node)...)
+	}
+	return clean
+}
+
+
+func sanitizeNode(node *html.Node) []*html.Node {
+	switch node.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: node.Data}}
+
+	case html.DocumentNode:
+		return sanitizeNodes(childNodes(node))
+
+	case html.ElementNode:
+		if node.Namespace != "" || droppedTags[node.DataAtom] {
+			return nil
+		}
+		children := sanitizeNodes(childNodes(node))
+		allowed, ok := allowedTags[node.DataAtom]
+		if !ok {
+			return children
+		}
+
+		clean := &html.Node{
+			Type:     html.ElementNode,
+			Data:     node.DataAtom.String(),
+			DataAtom: node.DataAtom,
+			Attr:     sanitizeAttributes(node, allowed),
+		}
+		if node.DataAtom == atom.Img && !hasAttribute(clean, "src") {
+			return nil
+		}
+		for _, child := range children {
+			clean.AppendChild(child)
+		}
+		return []*html.Node{clean}
+
+	default:
+		return nil
+	}
+}
+
+
+func sanitizeAttributes(node *html.Node, allowed []string) []html.Attribute {
+	attributes := make([]html.Attribute, 0)
+	for _, attribute := range node.Attr {
+		if attribute.Namespace != "" || !slices.Contains(allowed, attribute.Key) {
+			continue
+		}
+
+		value, ok := attribute.Val, true
+		switch attribute.Key {
+		case "href":
+			value, ok = safeURL(value, "http", "https", "mailto")
+		case "src":
+			value, ok = safeURL(value, "http", "https")
+			ok = ok && strings.Contains(value, ":")
+		case "class":
+			ok = languageClass.MatchString(value)
+		case "start", "colspan", "rowspan":
+			number, err := strconv.Atoi(value)
+			ok = err == nil && number >= 0 && number <= 1000
+		case "align":
+			ok = value == "left" || value == "center" || value == "right"
+		}
+		if ok {
+			attributes = append(attributes, html.Attribute{Key: attribute.Key, Val: value})
+		}
+	}
+	return attributes
+}
+
+
+// safeURL accepts relative URLs and absolute ones with one of schemes.
+// Browsers ignore whitespace and control characters inside a scheme, so
+// they are removed before it is checked.
+func safeURL(value string, schemes ...string) (string, bool) {
+	value = strings.TrimSpace(value)
+	compact := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+
+	parsed, err := url.Parse(compact)
+	if err != nil {
+		return "", false
+	}
+	if parsed.Scheme == "" {
+		// Reject anything the browser could still read as a scheme.
+		colon := strings.IndexByte(compact, ':')
+		return value, colon < 0 || strings.ContainsAny(compact[:colon], "/?#")
+	}
+	return value, slices.Contains(schemes, strings.ToLower(parsed.Scheme))
+}
+
+
+func childNodes(node *html.Node) []*html.Node {
+	children := make([]*html.Node, 0)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		children = append(children, child)
+	}
+	return children
+}
+
+
+func hasAttribute(node *html.Node, key string) bool {
+	_, ok := attributeValue(node, key)
+	return ok
+}
+
+
+func attributeValue(node *html.Node, key string) (string, bool) {
+	for _, attribute := range node.Attr {
+		if attribute.Key == key {
+			return attribute.Val, true
+		}
+	}
+	return "", false
+}
+
+
+// textContent returns all text inside node.
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
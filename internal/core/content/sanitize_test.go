@@ -0,0 +1,124 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+
+func sanitizeString(t *testing.T, source string) string {
+	t.Helper()
+
+	nodes, err := sanitizeHTML(source)
+	if err != nil {
+		t.Fatalf("sanitizeHTML(%q): %v", source, err)
+	}
+	var b strings.Builder
+	for _, node := range nodes {
+		if err := html.Render(&b, node); err != nil {
+			t.Fatalf("html.Render: %v", err)
+		}
+	}
+	return b.String()
+}
+
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		// Script URLs, however they are spelled.
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"mixed case scheme", `<a href="JaVaScRiPt:alert(1)">x</a>`, "<a>x</a>"},
+		{"leading whitespace", `<a href="  javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"embedded space", `<a href="java script:alert(1)">x</a>`, "<a>x</a>"},
+		{"embedded tab", "<a href=\"java\tscript:alert(1)\">x</a>", "<a>x</a>"},
+		{"embedded newline", "<a href=\"java\nscript:alert(1)\">x</a>", "<a>x</a>"},
+		{"tab entity", `<a href="java&#x09;script:alert(1)">x</a>`, "<a>x</a>"},
+		{"newline entity", `<a href="java&NewLine;script:alert(1)">x</a>`, "<a>x</a>"},
+		{"decimal entity", `<a href="&#106;avascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"hex entity", `<a href="jav&#x61;script:alert(1)">x</a>`, "<a>x</a>"},
+		{"colon entity", `<a href="javascript&colon;alert(1)">x</a>`, "<a>x</a>"},
+		{"nul byte", "<a href=\"java\x00script:alert(1)\">x</a>", "<a>x</a>"},
+		{"data href", `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, "<a>x</a>"},
+		{"vbscript href", `<a href="vbscript:msgbox(1)">x</a>`, "<a>x</a>"},
+		{"data image", `<img src="data:image/svg+xml;base64,PHN2Zz4=">`, ""},
+		{"javascript image", `<img src="javascript:alert(1)">`, ""},
+		{"relative image", `<img src="a.png">`, ""},
+
+		// Event handlers and other attributes.
+		{"onerror", `<img src="https://example.com/a.png" onerror="alert(1)">`, `<img src="https://example.com/a.png"/>`},
+		{"onclick and style", `<p onclick="alert(1)" style="color:red">hi</p>`, "<p>hi</p>"},
+		{"onmouseover on a link", `<a href="https://example.com" onmouseover=alert(1)>x</a>`, `<a href="https://example.com">x</a>`},
+		{"ontoggle on an unknown element", `<details open ontoggle=alert(1)>x</details>`, "x"},
+		{"class outside code", `<p class="x">hi</p>`, "<p>hi</p>"},
+		{"language class", `<code class="language-go">x</code>`, `<code class="language-go">x</code>`},
+		{"class smuggling a second token", `<code class="language-go onclick">x</code>`, "<code>x</code>"},
+		{"title breaking out", `<a href="https://example.com" title="&quot;><script>">x</a>`, `<a href="https://example.com" title="&#34;&gt;&lt;script&gt;">x</a>`},
+		{"oversized colspan", `<table><tr><td colspan="99999">x</td></tr></table>`, "<table><tbody><tr><td>x</td></tr></tbody></table>"},
+
+		// Foreign content, scripts and embedded documents.
+		{"svg", `<svg onload=alert(1)><script>alert(1)</script></svg>`, ""},
+		{"svg animate", `<svg><a><animate attributeName=href values=javascript:alert(1) /><text>x</text></a></svg>`, ""},
+		{"math", `<math><mtext><img src=x onerror=alert(1)></mtext></math>`, ""},
+		{"math breaking out", `<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`, ""},
+		{"script", `<script>alert(1)</script>text`, "text"},
+		{"style", `<style>body{}</style>text`, "text"},
+		{"iframe", `<iframe src="https://example.com"></iframe>`, ""},
+		{"form controls", `<form><input onfocus=alert(1) autofocus></form>`, ""},
+		{"comment", `<!-- <script>alert(1)</script> -->`, ""},
+		{"noscript", `<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`, "&#34;&gt;"},
+
+		// Malformed markup.
+		{"unclosed script", `<div><script>alert(1)`, "<div></div>"},
+		{"unclosed tags", `<p><b>bold`, "<p><b>bold</b></p>"},
+		{"misnested tags", `<b><i>nested</b></i>`, "<b><i>nested</i></b>"},
+		{"tag split by a script", `<scr<script>ipt>alert(1)</script>`, "ipt&gt;alert(1)"},
+		{"unknown wrapper", `<custom-element><em>kept</em></custom-element>`, "<em>kept</em>"},
+		{"nested lists", `<ul><li>a<ol start="2"><li>b</li></ol></li></ul>`, `<ul><li>a<ol start="2"><li>b</li></ol></li></ul>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sanitizeString(t, test.source); got != test.want {
+				t.Errorf("sanitize(%q)\n got: %q\nwant: %q", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"https://example.com/a", true},
+		{"HTTP://example.com", true},
+		{"mailto:a@example.com", true},
+		{"/documents/2", true},
+		{"//example.com/a", true},
+		{"#section", true},
+		{"?q=a:b", true},
+		{"a/b:c", true},
+		{"javascript:alert(1)", false},
+		{" JAVASCRIPT:alert(1)", false},
+		{"java\tscript:alert(1)", false},
+		{"java\x00script:alert(1)", false},
+		{"\x01javascript:alert(1)", false},
+		{"vbscript:x", false},
+		{"data:text/html,x", false},
+		{"file:///etc/passwd", false},
+		{"ftp://example.com", false},
+	}
+
+	for _, test := range tests {
+		if _, ok := safeURL(test.value, "http", "https", "mailto"); ok != test.ok {
+			t.Errorf("safeURL(%q) = %v, want %v", test.value, ok, test.ok)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package content
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+
+// WordsPerMinute is the reading speed reading times are estimated with.
+const WordsPerMinute = 200
+
+
+// Heading is an entry of the outline of a document.
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+
+// Stats describe the text of a document, without its markup. Characters
+// include spaces but not line breaks; text in code blocks counts like any
+// other text.
+type Stats struct {
+	Words      int
+	Characters int
+	Outline    []Heading
+}
+
+
+// MarkdownStats counts the words and characters of a Markdown document and
+// collects its headings in document order.
+func MarkdownStats(source string) Stats {
+	stats := Stats{Outline: make([]Heading, 0)}
+
+	var text func(block *Block, b *strings.Builder)
+	text = func(block *Block, b *strings.Builder) {
+		switch block.Type {
+		case BlockText, BlockCode:
+			b.WriteString(block.Text)
+		case BlockHardBreak:
+			b.WriteString("\n")
+		}
+		for _, child := range block.Content {
+			text(child, b)
+		}
+	}
+
+	BlocksFromMarkdown(source).walk(func(block *Block, parent *Block) {
+		spec, ok := blockSpecs[block.Type]
+		if !ok || (spec.contains != containsInline && block.Type != BlockCode) {
+			return
+		}
+
+		var b strings.Builder
+		text(block, &b)
+		content := b.String()
+
+		stats.Words += len(strings.Fields(content))
+		stats.Characters += utf8.RuneCountInString(content) - strings.Count(content, "\n")
+		if block.Type == BlockHeading {
+			if heading := strings.Join(strings.Fields(content), " "); heading != "" {
+				stats.Outline = append(stats.Outline, Heading{Level: block.Level, Text: heading})
+			}
+		}
+	})
+	return stats
+}
+
+
+// ReadingMinutes estimates how long reading words takes, rounded up.
+func ReadingMinutes(words int) int {
+	return int(math.Ceil(float64(words) / WordsPerMinute))
+}
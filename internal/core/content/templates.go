@@ -0,0 +1,84 @@
+package content
+
+import (
+	"embed"
+	"regexp"
+)
+
+
+//go:embed builtin/*.md
+var builtinFiles embed.FS
+
+
+// BuiltinTemplate is a template shipped with the application. Its content
+// is builtin/<key>.md.
+type BuiltinTemplate struct {
+	Key         string
+	Name        string
+	Description string
+	Title       string
+	Content     string
+}
+
+
+var builtinTemplates = []BuiltinTemplate{
+	{Key: "meeting-notes", Name: "Meeting notes", Description: "Attendees, agenda, decisions and action items.", Title: "Meeting notes {{date}}"},
+	{Key: "rfc", Name: "RFC", Description: "Propose a change and collect feedback on it.", Title: "RFC {{date}}"},
+	{Key: "retro", Name: "Retrospective", Description: "What went well, what did not and what to change.", Title: "Retrospective {{date}}"},
+}
+
+
+func init() {
+	for i, template := range builtinTemplates {
+		source, err := builtinFiles.ReadFile("builtin/" + template.Key + ".md")
+		if err != nil {
+			panic(err)
+		}
+		builtinTemplates[i].Content = string(source)
+	}
+}
+
+
+// BuiltinTemplates returns the built-in templates in display order.
+func BuiltinTemplates() []BuiltinTemplate {
+	return append([]BuiltinTemplate(nil), builtinTemplates...)
+}
+
+
+// LookupBuiltinTemplate returns the built-in template with key.
+func LookupBuiltinTemplate(key string) (BuiltinTemplate, bool) {
+	for _, template := range builtinTemplates {
+		if template.Key == key {
+			return template, true
+		}
+	}
+	return BuiltinTemplate{}, false
+}
+
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(date|author|title)\s*\}\}`)
+
+
+// Placeholders are the values substituted for {{date}}, {{author}} and
+// {{title}} when a document is created from a template. Other text in
+// braces is left alone.
+type Placeholders struct {
+	Date   string
+	Author string
+	Title  string
+}
+
+
+// Expand substitutes the placeholders in text.
+func (p Placeholders) Expand(text string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		switch placeholderPattern.FindStringSubmatch(match)[1] {
+		case "date":
+			return p.Date
+		case "author":
+			return p.Author
+		default:
+			return p.Title
+		}
+	})
+}
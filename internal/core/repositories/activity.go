@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// ActivityChannel is the Postgres NOTIFY channel announcing new or bumped feed
+// entries; the payload is {"id": ..., "document_id": ...}.
+const ActivityChannel = "document_activity"
+
+
+// EditBurstWindow is how long after the last save another save by the same
+// user still belongs to the same edit burst.
+const EditBurstWindow = 10 * time.Minute
+
+
+// writeActivity appends a feed entry inside the caller's transaction. An edit
+// is folded into the latest entry of the document when that entry is an edit
+// by the same actor from the current burst, so "Alice edited" stays one line
+// until someone else does something in between.
+func writeActivity(ctx context.Context, db execer, activity models.NewActivityModel) error {
+	data, err := json.Marshal(activity.Data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH last AS (
+			SELECT id, actor_id, kind, updated_at
+			FROM document_activity
+			WHERE document_id = $1
+			ORDER BY id DESC
+			LIMIT 1
+			FOR UPDATE
+		),
+		bumped AS (
+			UPDATE document_activity a
+			SET count = a.count + 1, data = $4::jsonb, updated_at = now()
+			FROM last
+			WHERE a.id = last.id
+				AND $5::boolean
+				AND last.kind = $3::text
+				AND last.actor_id = $2::int
+				AND last.updated_at > now() - $6::int * interval '1 second'
+			RETURNING a.id
+		),
+		inserted AS (
+			INSERT INTO document_activity (document_id, actor_id, kind, data)
+			SELECT $1::int, $2::int, $3::text, $4::jsonb
+			WHERE NOT EXISTS (SELECT 1 FROM bumped)
+			RETURNING id
+		)
+		SELECT pg_notify('` + ActivityChannel + `', json_build_object('id', e.id, 'document_id', $1::int)::text)
+		FROM (SELECT id FROM bumped UNION ALL SELECT id FROM inserted) e
+	`
+	mergeable := activity.Kind == utils.ActivityEdited
+	_, err = db.Exec(
+		ctx, query, activity.DocumentId, activity.ActorId, activity.Kind, data,
+		mergeable, int(EditBurstWindow.Seconds()),
+	)
+	return err
+}
+
+
+type ActivityRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+const activitySelect = `
+	SELECT
+		a.id, a.document_id, a.kind, a.count, a.data, a.created_at, a.updated_at,
+		u.id, u.username, u.email
+	FROM document_activity a
+	LEFT JOIN users u ON u.id = a.actor_id
+`
+
+
+func scanActivity(row pgx.Row, activity *models.ActivityModel) error {
+	var actorId *int
+	var actorName, actorEmail *string
+
+	err := row.Scan(
+		&activity.Id, &activity.DocumentId, &activity.Kind, &activity.Count, &activity.Data,
+		&activity.CreatedAt, &activity.UpdatedAt, &actorId, &actorName, &actorEmail,
+	)
+	if err != nil {
+		return err
+	}
+	if actorId != nil {
+		activity.Actor = &models.BaseUserModel{Id: *actorId, Username: *actorName, Email: *actorEmail}
+	}
+	return nil
+}
+
+
+func (r *ActivityRepository) GetActivity(ctx context.Context, activityId int64) (*models.ActivityModel, error) {
+	var activity models.ActivityModel
+
+	query := activitySelect + ` WHERE a.id = $1`
+	if err := scanActivity(r.DB.QueryRow(ctx, query, activityId), &activity); err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
+
+// GetDocumentActivity returns the feed of a document, newest first.
+func (r *ActivityRepository) GetDocumentActivity(
+	ctx context.Context,
+	documentId int,
+	limit int,
+	offset int,
+) ([]*models.ActivityModel, error) {
+	query := activitySelect + `
+		WHERE a.document_id = $1
+		ORDER BY a.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.DB.Query(ctx, query, documentId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feed := make([]*models.ActivityModel, 0)
+	for rows.Next() {
+		var activity models.ActivityModel
+		if err := scanActivity(rows, &activity); err != nil {
+			return nil, err
+		}
+		feed = append(feed, &activity)
+	}
+	return feed, rows.Err()
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"golang/internal/infrastructure/database/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+type ApiKeyRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+func (r *ApiKeyRepository) CreateApiKey(
+	ctx context.Context,
+	userId int,
+	form models.CreateApiKeyModel,
+	prefix string,
+	keyHash string,
+) (*models.ApiKeyModel, error) {
+	var apiKey models.ApiKeyModel
+
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, name, prefix, scopes, last_used_at, revoked_at, created_at
+	`
+	err := r.DB.QueryRow(ctx, query, userId, form.Name, prefix, keyHash, form.Scopes).Scan(
+		&apiKey.Id, &apiKey.Name, &apiKey.Prefix, &apiKey.Scopes,
+		&apiKey.LastUsedAt, &apiKey.RevokedAt, &apiKey.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+
+func (r *ApiKeyRepository) GetUserApiKeys(ctx context.Context, userId int) ([]*models.ApiKeyModel, error) {
+	query := `
+		SELECT id, name, prefix, scopes, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.DB.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := make([]*models.ApiKeyModel, 0)
+	for rows.Next() {
+		var apiKey models.ApiKeyModel
+		err := rows.Scan(
+			&apiKey.Id, &apiKey.Name, &apiKey.Prefix, &apiKey.Scopes,
+			&apiKey.LastUsedAt, &apiKey.RevokedAt, &apiKey.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, &apiKey)
+	}
+	return apiKeys, rows.Err()
+}
+
+
+func (r *ApiKeyRepository) RevokeApiKey(ctx context.Context, userId int, apiKeyId int) error {
+	query := `
+		UPDATE api_keys SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	rows, err := r.DB.Exec(ctx, query, apiKeyId, userId)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+
+func (r *ApiKeyRepository) GetApiKeyByPrefix(ctx context.Context, prefix string) (*models.ApiKeyCredentialModel, error) {
+	var apiKey models.ApiKeyCredentialModel
+
+	query := `
+		SELECT
+			k.id, k.name, k.prefix, k.scopes, k.last_used_at, k.revoked_at, k.created_at, k.key_hash,
+			u.id, u.username, u.email
+		FROM api_keys AS k
+		JOIN users AS u ON u.id = k.user_id
+		WHERE k.prefix = $1
+	`
+	err := r.DB.QueryRow(ctx, query, prefix).Scan(
+		&apiKey.Id, &apiKey.Name, &apiKey.Prefix, &apiKey.Scopes,
+		&apiKey.LastUsedAt, &apiKey.RevokedAt, &apiKey.CreatedAt, &apiKey.KeyHash,
+		&apiKey.User.Id, &apiKey.User.Username, &apiKey.User.Email,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+
+// TouchApiKey records usage at most once a minute so busy scripts do not turn
+// every request into a write.
+func (r *ApiKeyRepository) TouchApiKey(ctx context.Context, apiKeyId int) error {
+	query := `
+		UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`
+	_, err := r.DB.Exec(ctx, query, apiKeyId)
+	return err
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"golang/internal/infrastructure/database/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+type AttachmentRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+const attachmentColumns = `
+	id, document_id, filename, content_type, size, uploaded_by, created_at, storage_key
+`
+
+
+func scanAttachment(row interface{ Scan(...any) error }) (*models.AttachmentModel, error) {
+	var attachment models.AttachmentModel
+	err := row.Scan(
+		&attachment.Id, &attachment.DocumentId, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.UploadedBy, &attachment.CreatedAt, &attachment.StorageKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+
+// CreateAttachment records a blob already stored under attachment.StorageKey.
+// The user must still be allowed to edit the document.
+func (r *AttachmentRepository) CreateAttachment(
+	ctx context.Context,
+	userId int,
+	attachment models.AttachmentModel,
+) (*models.AttachmentModel, error) {
+	query := `
+		INSERT INTO attachments (document_id, uploaded_by, storage_key, filename, content_type, size)
+		SELECT d.id, $2, $3, $4, $5, $6
+		FROM documents d
+		WHERE d.id = $1 AND document_role(d.id, $2) IN ('owner', 'editor')
+		RETURNING ` + attachmentColumns
+	row := r.DB.QueryRow(
+		ctx, query, attachment.DocumentId, userId, attachment.StorageKey,
+		attachment.Filename, attachment.ContentType, attachment.Size,
+	)
+	return scanAttachment(row)
+}
+
+
+func (r *AttachmentRepository) GetAttachments(ctx context.Context, documentId int) ([]*models.AttachmentModel, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE document_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.DB.Query(ctx, query, documentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]*models.AttachmentModel, 0)
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+
+func (r *AttachmentRepository) GetAttachment(ctx context.Context, documentId int, attachmentId int) (*models.AttachmentModel, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE id = $1 AND document_id = $2
+	`
+	return scanAttachment(r.DB.QueryRow(ctx, query, attachmentId, documentId))
+}
+
+
+// DeleteAttachment removes an attachment of a document the user may edit
+// and reports whether there was one. The blob is queued for the cleaner.
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, documentId int, attachmentId int, userId int) (bool, error) {
+	query := `
+		DELETE FROM attachments
+		WHERE id = $1 AND document_id = $2 AND document_role(document_id, $3) IN ('owner', 'editor')
+	`
+	result, err := r.DB.Exec(ctx, query, attachmentId, documentId, userId)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+
+// ClaimDeletions locks up to limit queued blob deletions for the duration of
+// tx. Other cleaners skip locked rows.
+func (r *AttachmentRepository) ClaimDeletions(ctx context.Context, tx pgx.Tx, limit int) ([]models.AttachmentDeletionModel, error) {
+	query := `
+		SELECT id, storage_key FROM attachment_deletions
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[models.AttachmentDeletionModel])
+}
+
+
+func (r *AttachmentRepository) CompleteDeletions(ctx context.Context, tx pgx.Tx, ids []int) error {
+	_, err := tx.Exec(ctx, `DELETE FROM attachment_deletions WHERE id = ANY($1)`, ids)
+	return err
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang/internal/core/audit"
+	"golang/internal/infrastructure/database/models"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+type AuditRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+func (r *AuditRepository) CreateAuditEntry(ctx context.Context, entry models.AuditEntryModel) error {
+	return writeAudit(ctx, r.DB, entry)
+}
+
+
+// writeAudit appends an audit entry using db, so it can share the transaction
+// of the change it records. Ip and UserAgent default to the request stored in
+// ctx by the HTTP layer.
+func writeAudit(ctx context.Context, db execer, entry models.AuditEntryModel) error {
+	request := audit.FromContext(ctx)
+	if entry.Ip == "" {
+		entry.Ip = request.Ip
+	}
+	if entry.UserAgent == "" {
+		entry.UserAgent = request.UserAgent
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_id, action, ip, user_agent, target_type, target_id, document_id, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := db.Exec(
+		ctx, query,
+		entry.ActorId, entry.Action, entry.Ip, entry.UserAgent, entry.TargetType, entry.TargetId,
+		entry.DocumentId, nullableJSON(entry.Before), nullableJSON(entry.After),
+	)
+	return err
+}
+
+
+// auditValues marshals the before/after state of an audited change; nil
+// values are stored as NULL.
+func auditValues(before any, after any) (json.RawMessage, json.RawMessage, error) {
+	var beforeJSON, afterJSON json.RawMessage
+	var err error
+	if before != nil {
+		if beforeJSON, err = json.Marshal(before); err != nil {
+			return nil, nil, err
+		}
+	}
+	if after != nil {
+		if afterJSON, err = json.Marshal(after); err != nil {
+			return nil, nil, err
+		}
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+
+func auditFilter(filter models.AuditFilterModel) (string, []any) {
+	var clauses []string
+	var args []any
+	add := func(clause string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.DocumentId != nil {
+		add("document_id = $%d", *filter.DocumentId)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.Since != nil {
+		add("created_at >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		add("created_at < $%d", *filter.Until)
+	}
+
+	if len(clauses) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+
+const auditColumns = `
+	id, actor_id, action, ip, user_agent, target_type, target_id,
+	document_id, before, after, created_at
+`
+
+
+func scanAuditEntry(rows pgx.Rows) (*models.AuditEntryModel, error) {
+	var entry models.AuditEntryModel
+	err := rows.Scan(
+		&entry.Id, &entry.ActorId, &entry.Action, &entry.Ip, &entry.UserAgent,
+		&entry.TargetType, &entry.TargetId, &entry.DocumentId,
+		&entry.Before, &entry.After, &entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+
+// GetAuditEntries returns matching entries, newest first.
+func (r *AuditRepository) GetAuditEntries(
+	ctx context.Context,
+	filter models.AuditFilterModel,
+	limit int,
+	offset int,
+) ([]*models.AuditEntryModel, error) {
+	where, args := auditFilter(filter)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM audit_log
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, auditColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := r.DB.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.AuditEntryModel, 0)
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+
+// StreamAuditEntries calls fn for every matching entry in insertion order
+// without loading the whole result into memory.
+func (r *AuditRepository) StreamAuditEntries(
+	ctx context.Context,
+	filter models.AuditFilterModel,
+	fn func(*models.AuditEntryModel) error,
+) error {
+	where, args := auditFilter(filter)
+	query := fmt.Sprintf(`SELECT %s FROM audit_log %s ORDER BY id`, auditColumns, where)
+
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+
+func nullableJSON(value []byte) any {
+	if len(value) == 0 {
+		return nil
+	}
+	return value
+}
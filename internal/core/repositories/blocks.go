@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+
+// ErrDocumentChanged is returned when a write names a revision other than
+// the current one.
+var ErrDocumentChanged = errors.New("document changed since the given revision")
+
+
+// GetDocumentBlocks returns the stored block tree of a document. Blocks is
+// nil when the content was last written as Markdown; Content is set so the
+// caller can derive them.
+func (r *DocumentRepository) GetDocumentBlocks(ctx context.Context, documentId int) (*models.DocumentBlocksModel, error) {
+	var blocks models.DocumentBlocksModel
+
+	query := `
+		SELECT id, blocks, COALESCE(content, ''), updated_at
+		FROM documents
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	err := r.DB.QueryRow(ctx, query, documentId).Scan(
+		&blocks.DocumentId, &blocks.Blocks, &blocks.Content, &blocks.Revision,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &blocks, nil
+}
+
+
+// StoreDerivedBlocks keeps blocks derived from the content of revision, so
+// their ids stay stable across reads. Nothing is stored when the document
+// was written in the meantime.
+func (r *DocumentRepository) StoreDerivedBlocks(
+	ctx context.Context,
+	documentId int,
+	blocks []byte,
+	revision time.Time,
+) error {
+	query := `
+		UPDATE documents SET blocks = $2
+		WHERE id = $1 AND blocks IS NULL AND updated_at = $3
+	`
+	_, err := r.DB.Exec(ctx, query, documentId, blocks, revision)
+	return err
+}
+
+
+// UpdateDocumentBlocks stores a block tree with the Markdown rendered from
+// it. With a revision the write only succeeds while the document is still
+// at that revision. Writing the tree the document already has changes
+// nothing.
+func (r *DocumentRepository) UpdateDocumentBlocks(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	blocks []byte,
+	markdown string,
+	revision *time.Time,
+) (*models.DocumentBlocksModel, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var current time.Time
+	var unchanged bool
+	lockQuery := `
+		SELECT updated_at, blocks IS NOT DISTINCT FROM $3::jsonb
+		FROM documents
+		WHERE id = $1 AND document_role(id, $2) IN ('owner', 'editor')
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, lockQuery, documentId, userId, blocks).Scan(&current, &unchanged); err != nil {
+		return nil, err
+	}
+	if revision != nil && !revision.Equal(current) {
+		return nil, ErrDocumentChanged
+	}
+	if unchanged {
+		return &models.DocumentBlocksModel{DocumentId: documentId, Blocks: blocks, Revision: current}, nil
+	}
+
+	var document models.BaseDocumentModel
+	query := `
+		UPDATE documents
+		SET blocks = $1, content = $2, updated_at = now()
+		WHERE id = $3
+		RETURNING id, title, content, is_public, workspace_id, folder_id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, blocks, markdown, documentId).Scan(
+		&document.Id, &document.Title, &document.Content,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writeDocumentStats(ctx, tx, document.Id, document.Content, userId); err != nil {
+		return nil, err
+	}
+
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentUpdated, &document, userId, userId); err != nil {
+		return nil, err
+	}
+
+	if err := writeDocumentActivity(ctx, tx, utils.ActivityEdited, &document, userId); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &models.DocumentBlocksModel{DocumentId: documentId, Blocks: blocks, Revision: document.UpdatedAt}, nil
+}
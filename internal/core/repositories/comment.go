@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -87,20 +89,26 @@ func (r *CommentRepository) CreateComment(
 ) (*models.CommentModel, error) {
 	var comment models.CommentModel
 
+	tx, err := r.DB.BeginTx(context, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context)
+
 	query := `
+		WITH c AS (
+			INSERT INTO comments (user_id, document_id, content, parent_id)
+			VALUES ($1, $2, $3, NULLIF($4, 0))
+			RETURNING id, user_id, document_id, parent_id, content, created_at, updated_at
+		)
 		SELECT 
-			c.id, c.userId, c.documentId, c.content, c.createdAt, c.updatedAt,
+			c.id, c.user_id, c.document_id, COALESCE(c.parent_id, 0), c.content, c.created_at, c.updated_at,
 			u.id, u.username, u.email
-		FROM 
-			(
-				INSERT INTO comments (user_id, document_id, content)
-				VALUES ($1, $2, $3)
-				RETURNING id, user_id, document_id, content, created_at, updatedAt
-			) as c
+		FROM c
 		JOIN users u ON u.id = c.user_id
 	`
-	err := r.DB.QueryRow(context, query, userId, documentId, commentForm.Content).Scan(
-		&comment.Id, &comment.UserId, &comment.DocumentId,
+	err = tx.QueryRow(context, query, userId, documentId, commentForm.Content, commentForm.ParentId).Scan(
+		&comment.Id, &comment.UserId, &comment.DocumentId, &comment.ParentId,
 		&comment.Content, &comment.CreatedAt, &comment.UpdatedAt,
 		&comment.User.Id, &comment.User.Username, &comment.User.Email,
 	)
@@ -108,10 +116,95 @@ func (r *CommentRepository) CreateComment(
 		return nil, err
 	}
 
+	payload := models.CommentEventPayload{
+		Id:         comment.Id,
+		DocumentId: comment.DocumentId,
+		ParentId:   comment.ParentId,
+		UserId:     comment.UserId,
+		Content:    comment.Content,
+	}
+	if err := writeEvent(context, tx, utils.EventCommentCreated, "comment", comment.Id, &userId, &documentId, payload); err != nil {
+		return nil, err
+	}
+
+	if err := notifyCommentRecipients(context, tx, &comment); err != nil {
+		return nil, err
+	}
+
+	err = writeActivity(context, tx, models.NewActivityModel{
+		DocumentId: documentId,
+		ActorId:    userId,
+		Kind:       utils.ActivityCommented,
+		Data: map[string]any{
+			"comment_id": comment.Id,
+			"parent_id":  comment.ParentId,
+			"excerpt":    utils.Excerpt(comment.Content, 140),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(context); err != nil {
+		return nil, err
+	}
 	return &comment, nil
 }
 
 
+// notifyCommentRecipients notifies the author of the parent comment (folded
+// per thread) and every mentioned user who can access the document.
+func notifyCommentRecipients(ctx context.Context, tx pgx.Tx, comment *models.CommentModel) error {
+	data := map[string]any{
+		"comment_id": comment.Id,
+		"parent_id":  comment.ParentId,
+		"excerpt":    utils.Excerpt(comment.Content, 140),
+	}
+
+	if comment.ParentId != 0 {
+		var parentAuthorId int
+		err := tx.QueryRow(ctx, `SELECT user_id FROM comments WHERE id = $1`, comment.ParentId).Scan(&parentAuthorId)
+		if err != nil && err != pgx.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			err = writeNotifications(ctx, tx, []int{parentAuthorId}, models.NewNotificationModel{
+				Type:       utils.NotificationReply,
+				GroupKey:   fmt.Sprintf("reply:%d", comment.ParentId),
+				DocumentId: &comment.DocumentId,
+				ActorId:    comment.UserId,
+				Data:       data,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	mentions := utils.ParseMentions(comment.Content)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT COALESCE(array_agg(u.id), '{}')
+		FROM users u
+		WHERE u.username = ANY($1) AND document_role($2, u.id) IS NOT NULL
+	`
+	var recipients []int
+	if err := tx.QueryRow(ctx, query, mentions, comment.DocumentId).Scan(&recipients); err != nil {
+		return err
+	}
+	return writeNotifications(ctx, tx, recipients, models.NewNotificationModel{
+		Type:       utils.NotificationMention,
+		GroupKey:   fmt.Sprintf("mention:%d", comment.Id),
+		DocumentId: &comment.DocumentId,
+		ActorId:    comment.UserId,
+		Data:       data,
+	})
+}
+
+
 func (r *CommentRepository) GetCommentsByDocument(
 	ctx context.Context,
 	documentId int,
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"golang/internal/infrastructure/database/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+type DigestRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+// ClaimDueRecipients stamps last_digest_at on up to limit users whose digest
+// is due and returns them with the start of their reporting period. Claiming
+// before sending keeps concurrent runners from mailing the same user twice.
+func (r *DigestRepository) ClaimDueRecipients(ctx context.Context, limit int) ([]*models.DigestRecipientModel, error) {
+	query := `
+		WITH due AS (
+			SELECT
+				id,
+				CASE digest_frequency WHEN 'weekly' THEN interval '7 days' ELSE interval '1 day' END AS period,
+				last_digest_at
+			FROM users
+			WHERE digest_frequency <> 'off'
+				AND (
+					last_digest_at IS NULL
+					OR last_digest_at <= now() - CASE digest_frequency WHEN 'weekly' THEN interval '7 days' ELSE interval '1 day' END
+				)
+			ORDER BY last_digest_at NULLS FIRST
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE users u SET last_digest_at = now()
+		FROM due
+		WHERE u.id = due.id
+		RETURNING u.id, u.username, u.email, u.locale, u.digest_frequency, COALESCE(due.last_digest_at, now() - due.period)
+	`
+	rows, err := r.DB.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []*models.DigestRecipientModel
+	for rows.Next() {
+		var recipient models.DigestRecipientModel
+		err := rows.Scan(
+			&recipient.User.Id, &recipient.User.Username, &recipient.User.Email,
+			&recipient.Locale, &recipient.Frequency, &recipient.Since,
+		)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, &recipient)
+	}
+	return recipients, rows.Err()
+}
+
+
+// GetDocumentActivity summarises edits and comments by other users on the
+// documents userId owns or belongs to, read from document_activity. Edit
+// entries cover a burst of saves, so their counts are summed. Access is
+// checked once per document after grouping rather than for every entry.
+func (r *DigestRepository) GetDocumentActivity(ctx context.Context, recipient *models.DigestRecipientModel) ([]*models.DigestDocumentModel, error) {
+	query := `
+		SELECT d.id, d.title, s.edits, s.editors, s.comments, s.commenters
+		FROM (
+			SELECT
+				da.document_id,
+				COALESCE(sum(da.count) FILTER (WHERE da.kind = 'edited'), 0),
+				COALESCE(array_agg(DISTINCT a.username) FILTER (WHERE da.kind = 'edited'), '{}'),
+				COALESCE(sum(da.count) FILTER (WHERE da.kind = 'commented'), 0),
+				COALESCE(array_agg(DISTINCT a.username) FILTER (WHERE da.kind = 'commented'), '{}'),
+				max(da.updated_at)
+			FROM document_activity da
+			JOIN users a ON a.id = da.actor_id
+			WHERE da.updated_at > $2
+				AND da.kind IN ('edited', 'commented')
+				AND da.actor_id <> $1
+			GROUP BY da.document_id
+		) s (document_id, edits, editors, comments, commenters, last_at)
+		JOIN documents d ON d.id = s.document_id
+		WHERE document_role(d.id, $1) IS NOT NULL
+		ORDER BY s.last_at DESC
+	`
+	rows, err := r.DB.Query(ctx, query, recipient.User.Id, recipient.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []*models.DigestDocumentModel
+	for rows.Next() {
+		var document models.DigestDocumentModel
+		err := rows.Scan(
+			&document.DocumentId, &document.Title,
+			&document.Edits, &document.Editors,
+			&document.Comments, &document.Commenters,
+		)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, &document)
+	}
+	return documents, rows.Err()
+}
+
+
+// GetUnreadMentions lists mentions the user has not marked read yet,
+// regardless of when they happened.
+func (r *DigestRepository) GetUnreadMentions(ctx context.Context, userId int, limit int) ([]*models.DigestMentionModel, error) {
+	query := `
+		SELECT COALESCE(d.title, ''), COALESCE(a.username, ''), COALESCE(n.data->>'excerpt', '')
+		FROM notifications n
+		LEFT JOIN documents d ON d.id = n.document_id
+		LEFT JOIN users a ON a.id = n.actor_id
+		WHERE n.user_id = $1 AND n.type = 'mention' AND n.read_at IS NULL
+		ORDER BY n.updated_at DESC
+		LIMIT $2
+	`
+	rows, err := r.DB.Query(ctx, query, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mentions []*models.DigestMentionModel
+	for rows.Next() {
+		var mention models.DigestMentionModel
+		if err := rows.Scan(&mention.DocumentTitle, &mention.Author, &mention.Excerpt); err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, &mention)
+	}
+	return mentions, rows.Err()
+}
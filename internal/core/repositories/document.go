@@ -3,14 +3,23 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"golang/internal/infrastructure/database/models"
 	"golang/internal/utils"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrNotDocumentMember = errors.New("new owner must already have access to the document")
+	ErrAlreadyOwner      = errors.New("user already owns the document")
+)
+
+
 type DocumentRepository struct {
 	DB *pgxpool.Pool
 }
@@ -19,30 +28,31 @@ func (r *DocumentRepository) UpdateComment(ctx context.Context, commentId int, c
 	panic("unimplemented")
 }
 
+// CheckDocumentAccess reports whether the user may open the document at all:
+// as its owner, a member of the document or a member of its workspace. It is
+// backed by the document_role SQL function, which every permission check and
+// query filter uses.
 func (r *DocumentRepository) CheckDocumentAccess(ctx context.Context, userId int, documentId int) (bool, error) {
-	var hasAccess bool
+	return r.CheckDocumentRole(ctx, userId, documentId)
+}
+
+// CheckDocumentRole reports whether the effective role of the user is one of
+// roles; without roles any access counts.
+func (r *DocumentRepository) CheckDocumentRole(ctx context.Context, userId int, documentId int, roles ...string) (bool, error) {
+	if roles == nil {
+		roles = []string{}
+	}
+
+	var hasRole bool
 	err := r.DB.QueryRow(ctx, `
-        SELECT EXISTS(
-			SELECT 1 
-			FROM documents d
-			JOIN documents_users du ON du.document_id = d.id
-			WHERE d.id = $1 AND du.user_id = $2 AND d.is_public = true
-		)
-    `, documentId, userId).Scan(&hasAccess)
-	return hasAccess, err
+		SELECT role IS NOT NULL AND (cardinality($3::text[]) = 0 OR role = ANY($3::text[]))
+		FROM document_role($1, $2) AS role
+	`, documentId, userId, roles).Scan(&hasRole)
+	return hasRole, err
 }
 
 func (r *DocumentRepository) CheckIsOwner(ctx context.Context, documentId int, userId int) (bool, error) {
-	var hasAccess bool
-	err := r.DB.QueryRow(ctx, `
-        SELECT EXISTS(
-			SELECT 1 
-			FROM documents d
-			JOIN documents_users du ON du.document_id = d.id
-			WHERE d.id = $1 AND du.owner_id = $2
-		)
-    `, documentId, userId).Scan(&hasAccess)
-	return hasAccess, err
+	return r.CheckDocumentRole(ctx, userId, documentId, utils.RoleOwner)
 }
 
 func (r *DocumentRepository) CreateDocument(
@@ -50,22 +60,44 @@ func (r *DocumentRepository) CreateDocument(
 	form models.CreateDocumentModel,
 	userId int,
 ) (*models.BaseDocumentModel, error) {
-	var document models.BaseDocumentModel
-
 	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
+	document, err := createDocument(ctx, tx, form, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+
+// createDocument inserts the document with its creator as the first member
+// and records the creation, all inside tx.
+func createDocument(
+	ctx context.Context,
+	tx pgx.Tx,
+	form models.CreateDocumentModel,
+	userId int,
+) (*models.BaseDocumentModel, error) {
+	var document models.BaseDocumentModel
+
 	query := `
-		INSERT INTO documents (title, owner_id, is_public)
-		VALUES ($1, $2, $3)
-		RETURNING id, title, content, is_public, created_at, updated_at
+		INSERT INTO documents (title, content, owner_id, is_public, workspace_id, folder_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, title, content, is_public, workspace_id, folder_id, created_at, updated_at
 	`
-	insertDocErr := tx.QueryRow(ctx, query, form.Title, userId, form.IsPublic).Scan(
+	insertDocErr := tx.QueryRow(
+		ctx, query, form.Title, form.Content, userId, form.IsPublic, form.WorkspaceId, form.FolderId,
+	).Scan(
 		&document.Id, &document.Title, &document.Content,
-		&document.IsPublic, &document.CreatedAt, &document.UpdatedAt,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
 	)
 	if insertDocErr != nil {
 		return nil, insertDocErr
@@ -80,9 +112,84 @@ func (r *DocumentRepository) CreateDocument(
 		return nil, insertDocUserErr
 	}
 
+	if _, err := writeDocumentStats(ctx, tx, document.Id, document.Content, userId); err != nil {
+		return nil, err
+	}
+
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentCreated, &document, userId, userId); err != nil {
+		return nil, err
+	}
+
+	after := map[string]any{"title": document.Title, "is_public": document.IsPublic}
+	if err := writeDocumentAudit(ctx, tx, utils.AuditDocumentCreate, document.Id, userId, nil, after); err != nil {
+		return nil, err
+	}
+
+	if err := writeDocumentActivity(ctx, tx, utils.ActivityCreated, &document, userId); err != nil {
+		return nil, err
+	}
 	return &document, nil
 }
 
+
+func writeDocumentEvent(
+	ctx context.Context,
+	db execer,
+	eventType string,
+	document *models.BaseDocumentModel,
+	ownerId int,
+	actorId int,
+) error {
+	payload := models.DocumentEventPayload{
+		Id:       document.Id,
+		Title:    document.Title,
+		IsPublic: document.IsPublic,
+		OwnerId:  ownerId,
+	}
+	return writeEvent(ctx, db, eventType, "document", document.Id, &actorId, &document.Id, payload)
+}
+
+
+func writeDocumentActivity(
+	ctx context.Context,
+	db execer,
+	kind string,
+	document *models.BaseDocumentModel,
+	actorId int,
+) error {
+	return writeActivity(ctx, db, models.NewActivityModel{
+		DocumentId: document.Id,
+		ActorId:    actorId,
+		Kind:       kind,
+		Data:       map[string]any{"title": document.Title},
+	})
+}
+
+
+func writeDocumentAudit(
+	ctx context.Context,
+	db execer,
+	action string,
+	documentId int,
+	actorId int,
+	before any,
+	after any,
+) error {
+	beforeJSON, afterJSON, err := auditValues(before, after)
+	if err != nil {
+		return err
+	}
+	return writeAudit(ctx, db, models.AuditEntryModel{
+		ActorId:    &actorId,
+		Action:     action,
+		TargetType: "document",
+		TargetId:   strconv.Itoa(documentId),
+		DocumentId: &documentId,
+		Before:     beforeJSON,
+		After:      afterJSON,
+	})
+}
+
 func (r *DocumentRepository) GetDocumentById(
 	ctx context.Context,
 	documentId int,
@@ -90,10 +197,16 @@ func (r *DocumentRepository) GetDocumentById(
 ) (*models.DocumentModel, error) {
 	var document models.DocumentModel
 	var members []byte
+	var wordCount, characterCount, editorId *int
+	var outline []byte
+	var editorName, editorEmail *string
 
 	query := `
 		SELECT 
-			d.id, d.title, d.content, d.created_at, d.updated_at, owner.id, owner.username, owner.email,
+			d.id, d.title, d.content, d.is_public, d.workspace_id, d.folder_id, d.created_at, d.updated_at,
+			owner.id, owner.username, owner.email,
+			d.word_count, d.character_count, d.outline,
+			editor.id, editor.username, editor.email,
 			json_agg(
 				json_build_object(
 					'id', u.id, 
@@ -105,11 +218,16 @@ func (r *DocumentRepository) GetDocumentById(
 			JOIN users owner ON d.owner_id = owner.id
 			JOIN documents_users AS d_u ON d.id = d_u.document_id
 			JOIN users AS u ON u.id = d_u.user_id
-		WHERE d.id = $1
+			LEFT JOIN users editor ON editor.id = d.last_editor_id
+		WHERE d.id = $1 AND d.deleted_at IS NULL
+		GROUP BY d.id, owner.id, editor.id
 	`
-	err := r.DB.QueryRow(ctx, query, documentId, userId).Scan(
-		&document.Id, &document.Title, &document.Content, &document.CreatedAt, &document.UpdatedAt,
-		&document.Owner.Id, &document.Owner.Username, &document.Owner.Email, &members,
+	err := r.DB.QueryRow(ctx, query, documentId).Scan(
+		&document.Id, &document.Title, &document.Content, &document.IsPublic,
+		&document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+		&document.Owner.Id, &document.Owner.Username, &document.Owner.Email,
+		&wordCount, &characterCount, &outline,
+		&editorId, &editorName, &editorEmail, &members,
 	)
 	if err != nil {
 		return nil, err
@@ -118,6 +236,28 @@ func (r *DocumentRepository) GetDocumentById(
 	if err := json.Unmarshal(members, &document.Members); err != nil {
 		return nil, err
 	}
+
+	if editorId != nil {
+		document.LastEditor = &models.BaseUserModel{Id: *editorId, Username: *editorName, Email: *editorEmail}
+	}
+	if document.Stats, err = scanDocumentStats(wordCount, characterCount, outline); err != nil {
+		return nil, err
+	}
+	if document.Stats == nil {
+		document.Stats, err = r.deriveDocumentStats(ctx, document.Id, document.Content, document.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	document.Path = []models.BreadcrumbModel{}
+	if document.FolderId != nil {
+		path, err := getFolderPath(ctx, r.DB, *document.FolderId, userId)
+		if err != nil {
+			return nil, err
+		}
+		document.Path = path
+	}
 	return &document, nil
 }
 
@@ -129,25 +269,65 @@ func (r *DocumentRepository) UpdateDocument(
 ) (*models.BaseDocumentModel, error) {
 	var document models.BaseDocumentModel
 	clauses, args := utils.GetSetParams(documentFormEncoded)
+	if clauses != "" {
+		clauses += ", "
+	}
 
 	query := fmt.Sprintf(`
 		UPDATE documents 
-		SET %s 
-		WHERE id = $%d AND owner_id = $%d
-		RETURNING id, title, content, is_public, created_at, updated_at
-	`, clauses, documentId, userId)
+		SET %supdated_at = now()
+		WHERE id = $%d AND document_role(id, $%d) = 'owner'
+		RETURNING id, title, content, is_public, workspace_id, folder_id, created_at, updated_at
+	`, clauses, len(args)+1, len(args)+2)
+	args = append(args, documentId, userId)
+
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var wasPublic bool
+	lockQuery := `SELECT is_public FROM documents WHERE id = $1 AND document_role(id, $2) = 'owner' FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, documentId, userId).Scan(&wasPublic); err != nil {
+		return nil, err
+	}
 
-	documentErr := r.DB.QueryRow(ctx, query, args...).Scan(
+	documentErr := tx.QueryRow(ctx, query, args...).Scan(
 		&document.Id, &document.Title, &document.Content,
-		&document.IsPublic, &document.CreatedAt, &document.UpdatedAt,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
 	)
 
 	if documentErr != nil {
 		return nil, documentErr
 	}
+
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentUpdated, &document, userId, userId); err != nil {
+		return nil, err
+	}
+
+	if err := writeDocumentActivity(ctx, tx, utils.ActivityEdited, &document, userId); err != nil {
+		return nil, err
+	}
+
+	if wasPublic != document.IsPublic {
+		err := writeDocumentAudit(
+			ctx, tx, utils.AuditDocumentVisibility, documentId, userId,
+			map[string]bool{"is_public": wasPublic}, map[string]bool{"is_public": document.IsPublic},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 	return &document, nil
 }
 
+// DeleteDocument moves a document to the trash. Comments, snapshots and
+// members stay untouched until the document is purged.
 func (r *DocumentRepository) DeleteDocument(ctx context.Context, documentId int, userId int) error {
 	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
@@ -155,135 +335,1023 @@ func (r *DocumentRepository) DeleteDocument(ctx context.Context, documentId int,
 	}
 	defer tx.Rollback(ctx)
 
-	rows, err := tx.Exec(ctx, "DELETE FROM documents WHERE id = $1 AND owner_id = $2", documentId, userId)
-	if err != nil {
+	var title string
+	var members []int
+	query := `
+		SELECT d.title, COALESCE(array_agg(du.user_id) FILTER (WHERE du.user_id IS NOT NULL), '{}')
+		FROM documents d
+		LEFT JOIN documents_users du ON du.document_id = d.id
+		WHERE d.id = $1 AND document_role(d.id, $2) = 'owner'
+		GROUP BY d.id
+	`
+	if err := tx.QueryRow(ctx, query, documentId, userId).Scan(&title, &members); err != nil {
 		return err
 	}
-	if rows.RowsAffected() == 0 {
-		return pgx.ErrNoRows
+
+	query = `UPDATE documents SET deleted_at = now(), deleted_by = $2 WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, documentId, userId); err != nil {
+		return err
 	}
 
-	query := `
-		DELETE FROM documents_users
-		WHERE document_id = $1 AND user_id = $2
-	`
-	_, err = tx.Exec(ctx, query, documentId, userId)
-	if err != nil {
+	payload := models.DocumentEventPayload{Id: documentId, Title: title, OwnerId: userId}
+	if err := writeEvent(ctx, tx, utils.EventDocumentDeleted, "document", documentId, &userId, &documentId, payload); err != nil {
 		return err
 	}
-	return nil
-}
 
-func (r *DocumentRepository) UpdateDocumentContent(
-	ctx context.Context,
-	userId int,
-	documentId int,
-	content string,
-) (*models.DocumentModel, error) {
-	var document models.DocumentModel
+	before := map[string]any{"title": title, "members": members}
+	if err := writeDocumentAudit(ctx, tx, utils.AuditDocumentDelete, documentId, userId, before, nil); err != nil {
+		return err
+	}
 
-	query := `
-		UPDATE documents 
-		SET content = $1
-		WHERE id = $2 AND owner_id = $3
-		RETURNING id, title, content, is_public, created_at, updated_at
-	`
-	err := r.DB.QueryRow(ctx, query, content, documentId, userId).Scan(
-		&document.Id, &document.Title, &document.Content,
-		&document.IsPublic, &document.CreatedAt, &document.UpdatedAt,
-	)
+	err = writeActivity(ctx, tx, models.NewActivityModel{
+		DocumentId: documentId,
+		ActorId:    userId,
+		Kind:       utils.ActivityTrashed,
+		Data:       map[string]string{"title": title},
+	})
+	if err != nil {
+		return err
+	}
 
+	err = writeNotifications(ctx, tx, members, models.NewNotificationModel{
+		Type:     utils.NotificationDocumentDeleted,
+		GroupKey: fmt.Sprintf("document_deleted:%d", documentId),
+		ActorId:  userId,
+		Data:     map[string]any{"document_id": documentId, "title": title},
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &document, nil
+
+	return tx.Commit(ctx)
 }
 
-func (r *DocumentRepository) GetDocumentsByUserId(ctx context.Context, userId int) ([]models.DocumentModel, error) {
-	var documents []models.DocumentModel
 
+// GetTrash lists the trashed documents the user may restore, most recently
+// deleted first.
+func (r *DocumentRepository) GetTrash(
+	ctx context.Context,
+	userId int,
+	limit int,
+	offset int,
+) ([]*models.TrashedDocumentModel, error) {
 	query := `
-		SELECT d.id, d.title, d.content, d.is_public, d.created_at, d.updated_at
+		SELECT
+			d.id, d.title, d.content, d.is_public, d.workspace_id, d.folder_id, d.created_at, d.updated_at,
+			d.deleted_at, d.deleted_by
 		FROM documents AS d
-		JOIN documents_users AS d_u ON d_u.user_id = $1
-		WHERE d_u.user_id = $1 AND d.is_public = true
+		WHERE d.deleted_at IS NOT NULL AND document_role_ignoring_trash(d.id, $1) = 'owner'
+		ORDER BY d.deleted_at DESC, d.id
+		LIMIT $2 OFFSET $3
 	`
-	rows, err := r.DB.Query(ctx, query, userId)
+	rows, err := r.DB.Query(ctx, query, userId, limit, offset)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
+	documents := make([]*models.TrashedDocumentModel, 0)
 	for rows.Next() {
-		var document models.DocumentModel
+		var document models.TrashedDocumentModel
 		err := rows.Scan(
 			&document.Id, &document.Title, &document.Content,
-			&document.IsPublic, &document.CreatedAt, &document.UpdatedAt,
+			&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+			&document.DeletedAt, &document.DeletedBy,
 		)
 		if err != nil {
 			return nil, err
 		}
-		documents = append(documents, document)
+		documents = append(documents, &document)
 	}
-
-	return documents, nil
+	return documents, rows.Err()
 }
 
-func (r *DocumentRepository) GetUserDocuments(
-	ctx context.Context,
-	userId int,
-	limit int,
-	offset int,
-) ([]*models.BaseDocumentModel, error) {
+
+// RestoreDocument takes a document out of the trash with its comments,
+// snapshots and members as they were.
+func (r *DocumentRepository) RestoreDocument(ctx context.Context, documentId int, userId int) (*models.BaseDocumentModel, error) {
+	var document models.BaseDocumentModel
+
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		SELECT d.id, d.title, d.content, d.is_public, d.created_at, d.updated_at
-		FROM documents AS d
-		JOIN documents_users AS d_u ON d_u.document_id = d.id
-		WHERE d_u.user_id = $1
-		LIMIT $2 OFFSET $3
+		UPDATE documents
+		SET deleted_at = NULL, deleted_by = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL AND document_role_ignoring_trash(id, $2) = 'owner'
+		RETURNING id, title, content, is_public, workspace_id, folder_id, created_at, updated_at
 	`
-	rows, err := r.DB.Query(ctx, query, userId, limit, offset)
+	err = tx.QueryRow(ctx, query, documentId, userId).Scan(
+		&document.Id, &document.Title, &document.Content,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	defer rows.Close()
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentRestored, &document, userId, userId); err != nil {
+		return nil, err
+	}
 
-	var documents []*models.BaseDocumentModel
+	after := map[string]string{"title": document.Title}
+	if err := writeDocumentAudit(ctx, tx, utils.AuditDocumentRestore, documentId, userId, nil, after); err != nil {
+		return nil, err
+	}
+
+	if err := writeDocumentActivity(ctx, tx, utils.ActivityRestored, &document, userId); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+
+// purgeDocument removes a document and everything hanging off it. Activity
+// and webhooks go with it through ON DELETE CASCADE. Deleting the attachment
+// rows queues their blobs for the attachment cleaner.
+func purgeDocument(ctx context.Context, db execer, documentId int) error {
+	statements := []string{
+		`DELETE FROM documents_tags WHERE document_id = $1`,
+		`DELETE FROM attachments WHERE document_id = $1`,
+		`DELETE FROM comments WHERE document_id = $1`,
+		`DELETE FROM document_snapshots WHERE document_id = $1`,
+		`DELETE FROM documents_users WHERE document_id = $1`,
+		`DELETE FROM documents WHERE id = $1`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(ctx, statement, documentId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+
+// PurgeDocument permanently deletes a trashed document.
+func (r *DocumentRepository) PurgeDocument(ctx context.Context, documentId int, userId int) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var title string
+	query := `
+		SELECT title FROM documents
+		WHERE id = $1 AND deleted_at IS NOT NULL AND document_role_ignoring_trash(id, $2) = 'owner'
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, query, documentId, userId).Scan(&title); err != nil {
+		return err
+	}
+
+	if err := purgeDocument(ctx, tx, documentId); err != nil {
+		return err
+	}
+
+	before := map[string]string{"title": title}
+	if err := writeDocumentAudit(ctx, tx, utils.AuditDocumentPurge, documentId, userId, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+
+// PurgeExpired permanently deletes up to limit documents trashed before
+// cutoff and returns how many it removed. Rows are claimed with SKIP LOCKED,
+// so several instances can purge at once.
+func (r *DocumentRepository) PurgeExpired(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		SELECT id, title FROM documents
+		WHERE deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	expired := make(map[int]string)
 	for rows.Next() {
-		var document models.BaseDocumentModel
-		err := rows.Scan(
-			&document.Id, &document.Title, &document.Content,
-			&document.IsPublic, &document.CreatedAt, &document.UpdatedAt,
-		)
+		var documentId int
+		var title string
+		if err := rows.Scan(&documentId, &title); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired[documentId] = title
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for documentId, title := range expired {
+		if err := purgeDocument(ctx, tx, documentId); err != nil {
+			return 0, err
+		}
+
+		// Retention purges have no actor.
+		before, _, err := auditValues(map[string]string{"title": title}, nil)
 		if err != nil {
-			return nil, err
+			return 0, err
+		}
+		err = writeAudit(ctx, tx, models.AuditEntryModel{
+			Action:     utils.AuditDocumentPurge,
+			TargetType: "document",
+			TargetId:   strconv.Itoa(documentId),
+			DocumentId: &documentId,
+			Before:     before,
+		})
+		if err != nil {
+			return 0, err
 		}
-		documents = append(documents, &document)
 	}
-	return documents, nil
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(expired), nil
 }
 
-func (r *DocumentRepository) AddDocumentSnapshot(
+func (r *DocumentRepository) UpdateDocumentContent(
 	ctx context.Context,
-	documentId int,
 	userId int,
-) (*models.BaseSnapshotModel, error) {
-	query := `
-		INSERT INTO document_snapshots (document_id, user_id, content)
-		SELECT d.id, d_u.user_id, d.content
-		FROM documents AS d
-		JOIN documents_users AS d_u ON d_u.document_id = d.id
-		WHERE d.id = $1 AND d_u.user_id = $2
-		RETURNING id, document_id, user_id, created_at
-	`
-
-	var snapshot models.BaseSnapshotModel
+	documentId int,
+	content string,
+) (*models.DocumentModel, error) {
+	var document models.DocumentModel
 
-	err := r.DB.QueryRow(ctx, query, documentId, userId).Scan(
-		&snapshot.Id, &snapshot.DocumentId, &snapshot.UserId, &snapshot.CreatedAt,
-	)
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return nil, err
 	}
-	return &snapshot, nil
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE documents 
+		SET content = $1, updated_at = now()
+		WHERE id = $2 AND document_role(id, $3) IN ('owner', 'editor')
+		RETURNING id, title, content, is_public, workspace_id, folder_id, created_at, updated_at,
+			(SELECT username FROM users WHERE id = $3), (SELECT email FROM users WHERE id = $3)
+	`
+	editor := models.BaseUserModel{Id: userId}
+	err = tx.QueryRow(ctx, query, content, documentId, userId).Scan(
+		&document.Id, &document.Title, &document.Content,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+		&editor.Username, &editor.Email,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if document.Stats, err = writeDocumentStats(ctx, tx, document.Id, document.Content, userId); err != nil {
+		return nil, err
+	}
+	document.LastEditor = &editor
+
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentUpdated, &document.BaseDocumentModel, userId, userId); err != nil {
+		return nil, err
+	}
+
+	if err := writeDocumentActivity(ctx, tx, utils.ActivityEdited, &document.BaseDocumentModel, userId); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// AddDocumentMember adds a user to the document members and records
+// member.added in the same transaction. action is the audit action the
+// membership is recorded under, e.g. an accepted invite.
+func (r *DocumentRepository) AddDocumentMember(
+	ctx context.Context,
+	documentId int,
+	userId int,
+	actorId int,
+	action string,
+) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Invites to a trashed document cannot be redeemed.
+	var exists bool
+	query := `SELECT true FROM documents WHERE id = $1 AND deleted_at IS NULL FOR SHARE`
+	if err := tx.QueryRow(ctx, query, documentId).Scan(&exists); err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO documents_users (document_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	rows, err := tx.Exec(ctx, query, documentId, userId)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return tx.Commit(ctx)
+	}
+
+	payload := models.MemberEventPayload{DocumentId: documentId, UserId: userId}
+	if err := writeEvent(ctx, tx, utils.EventMemberAdded, "document", documentId, &actorId, &documentId, payload); err != nil {
+		return err
+	}
+
+	// The member joins on their own behalf; actorId is who let them in.
+	after := map[string]int{"user_id": userId, "added_by": actorId}
+	if err := writeDocumentAudit(ctx, tx, action, documentId, userId, nil, after); err != nil {
+		return err
+	}
+
+	err = writeActivity(ctx, tx, models.NewActivityModel{
+		DocumentId: documentId,
+		ActorId:    userId,
+		Kind:       utils.ActivityMemberJoined,
+		Data:       map[string]int{"user_id": userId},
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateMemberRole changes the role of an existing member, records
+// member.role_changed and notifies the member.
+func (r *DocumentRepository) UpdateMemberRole(ctx context.Context, documentId int, userId int, role string, actorId int) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var previousRole string
+	query := `
+		SELECT COALESCE(ro.name, '')
+		FROM documents_users du
+		LEFT JOIN roles ro ON ro.id = du.role_id
+		WHERE du.document_id = $1 AND du.user_id = $2
+		FOR UPDATE OF du
+	`
+	if err := tx.QueryRow(ctx, query, documentId, userId).Scan(&previousRole); err != nil {
+		return err
+	}
+
+	query = `
+		WITH r AS (
+			INSERT INTO roles (name) VALUES ($3)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		)
+		UPDATE documents_users SET role_id = (SELECT id FROM r)
+		WHERE document_id = $1 AND user_id = $2
+		RETURNING (SELECT title FROM documents WHERE id = $1)
+	`
+	var title string
+	if err := tx.QueryRow(ctx, query, documentId, userId, role).Scan(&title); err != nil {
+		return err
+	}
+
+	payload := models.MemberEventPayload{DocumentId: documentId, UserId: userId, Role: role}
+	if err := writeEvent(ctx, tx, utils.EventMemberRoleChanged, "document", documentId, &actorId, &documentId, payload); err != nil {
+		return err
+	}
+
+	err = writeDocumentAudit(
+		ctx, tx, utils.AuditMemberRole, documentId, actorId,
+		map[string]any{"user_id": userId, "role": previousRole}, map[string]any{"user_id": userId, "role": role},
+	)
+	if err != nil {
+		return err
+	}
+
+	err = writeActivity(ctx, tx, models.NewActivityModel{
+		DocumentId: documentId,
+		ActorId:    actorId,
+		Kind:       utils.ActivityRoleChanged,
+		Data:       map[string]any{"user_id": userId, "role": role},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeNotifications(ctx, tx, []int{userId}, models.NewNotificationModel{
+		Type:       utils.NotificationRoleChanged,
+		GroupKey:   fmt.Sprintf("role:%d", documentId),
+		DocumentId: &documentId,
+		ActorId:    actorId,
+		Data:       map[string]any{"role": role, "title": title},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DocumentRepository) GetDocumentsByUserId(ctx context.Context, userId int) ([]models.DocumentModel, error) {
+	var documents []models.DocumentModel
+
+	query := `
+		SELECT d.id, d.title, d.content, d.is_public, d.workspace_id, d.folder_id, d.created_at, d.updated_at
+		FROM documents AS d
+		JOIN documents_users AS d_u ON d_u.user_id = $1
+		WHERE d_u.user_id = $1 AND d.is_public = true AND d.deleted_at IS NULL
+	`
+	rows, err := r.DB.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var document models.DocumentModel
+		err := rows.Scan(
+			&document.Id, &document.Title, &document.Content,
+			&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
+
+// GetUserDocuments lists the documents the user is a member of. With a
+// folderId it lists the documents filed in that folder instead, which the
+// user can open through the folder.
+func (r *DocumentRepository) GetUserDocuments(
+	ctx context.Context,
+	userId int,
+	folderId *int,
+	limit int,
+	offset int,
+) ([]*models.BaseDocumentModel, error) {
+	query := `
+		SELECT d.id, d.title, d.content, d.is_public, d.workspace_id, d.folder_id, d.created_at, d.updated_at
+		FROM documents AS d
+		WHERE d.deleted_at IS NULL AND CASE
+			WHEN $4::int IS NULL THEN
+				EXISTS(SELECT 1 FROM documents_users d_u WHERE d_u.document_id = d.id AND d_u.user_id = $1)
+			ELSE d.folder_id = $4 AND document_role(d.id, $1) IS NOT NULL
+		END
+		ORDER BY d.id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.DB.Query(ctx, query, userId, limit, offset, folderId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var documents []*models.BaseDocumentModel
+	for rows.Next() {
+		var document models.BaseDocumentModel
+		err := rows.Scan(
+			&document.Id, &document.Title, &document.Content,
+			&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, &document)
+	}
+	return documents, nil
+}
+
+func (r *DocumentRepository) GetWorkspaceDocuments(
+	ctx context.Context,
+	workspaceId int,
+	limit int,
+	offset int,
+) ([]*models.BaseDocumentModel, error) {
+	query := `
+		SELECT d.id, d.title, d.content, d.is_public, d.workspace_id, d.folder_id, d.created_at, d.updated_at
+		FROM documents AS d
+		WHERE d.workspace_id = $1 AND d.deleted_at IS NULL
+		ORDER BY d.updated_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.DB.Query(ctx, query, workspaceId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := make([]*models.BaseDocumentModel, 0)
+	for rows.Next() {
+		var document models.BaseDocumentModel
+		err := rows.Scan(
+			&document.Id, &document.Title, &document.Content,
+			&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, &document)
+	}
+	return documents, rows.Err()
+}
+
+// MoveDocument moves a document into workspaceId, or into the personal space
+// of userId when workspaceId is nil; userId then becomes its owner. The
+// document leaves its folder, which belongs to the old space. Only an owner of
+// the document may move it.
+func (r *DocumentRepository) MoveDocument(
+	ctx context.Context,
+	documentId int,
+	workspaceId *int,
+	userId int,
+) (*models.BaseDocumentModel, error) {
+	var document models.BaseDocumentModel
+
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous *int
+	lockQuery := `SELECT workspace_id FROM documents WHERE id = $1 AND document_role(id, $2) = 'owner' FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, documentId, userId).Scan(&previous); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE documents
+		SET workspace_id = $3,
+			owner_id = CASE WHEN $3::int IS NULL THEN $2 ELSE owner_id END,
+			folder_id = NULL,
+			updated_at = now()
+		WHERE id = $1
+		RETURNING id, title, content, is_public, workspace_id, folder_id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, documentId, userId, workspaceId).Scan(
+		&document.Id, &document.Title, &document.Content,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if workspaceId == nil {
+		query = `
+			INSERT INTO documents_users (document_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := tx.Exec(ctx, query, documentId, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentUpdated, &document, userId, userId); err != nil {
+		return nil, err
+	}
+
+	err = writeDocumentAudit(
+		ctx, tx, utils.AuditDocumentTransfer, documentId, userId,
+		map[string]*int{"workspace_id": previous}, map[string]*int{"workspace_id": workspaceId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// MoveDocumentToFolder files a document into folderId, or takes it out of its
+// folder when folderId is nil. The folder must belong to the same space as the
+// document; only an owner of the document may file it.
+func (r *DocumentRepository) MoveDocumentToFolder(
+	ctx context.Context,
+	documentId int,
+	folderId *int,
+	userId int,
+) (*models.BaseDocumentModel, error) {
+	var document models.BaseDocumentModel
+
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous, workspaceId *int
+	lockQuery := `SELECT folder_id, workspace_id FROM documents WHERE id = $1 AND document_role(id, $2) = 'owner' FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, documentId, userId).Scan(&previous, &workspaceId); err != nil {
+		return nil, err
+	}
+
+	if folderId != nil {
+		var folderWorkspaceId *int
+		query := `SELECT workspace_id FROM folders WHERE id = $1 FOR SHARE`
+		if err := tx.QueryRow(ctx, query, *folderId).Scan(&folderWorkspaceId); err != nil {
+			return nil, err
+		}
+		if !utils.SameId(workspaceId, folderWorkspaceId) {
+			return nil, ErrFolderSpaceMismatch
+		}
+	}
+
+	query := `
+		UPDATE documents
+		SET folder_id = $2, updated_at = now()
+		WHERE id = $1
+		RETURNING id, title, content, is_public, workspace_id, folder_id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, documentId, folderId).Scan(
+		&document.Id, &document.Title, &document.Content,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentUpdated, &document, userId, userId); err != nil {
+		return nil, err
+	}
+
+	// The folder decides who else inherits access, so filing is audited.
+	err = writeDocumentAudit(
+		ctx, tx, utils.AuditDocumentFolder, documentId, userId,
+		map[string]*int{"folder_id": previous}, map[string]*int{"folder_id": folderId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// ensureRole returns the id of the role called name, creating it if needed.
+func ensureRole(ctx context.Context, tx pgx.Tx, name string) (int, error) {
+	query := `
+		INSERT INTO roles (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id
+	`
+	var roleId int
+	err := tx.QueryRow(ctx, query, name).Scan(&roleId)
+	return roleId, err
+}
+
+
+// DuplicateDocument copies the content, block tree and tags of a document
+// the user can read into a new document owned by them, filed as form says.
+// With includeMembers, which callers only allow the owner of the original,
+// its members are added to the copy with their roles; its owner becomes an
+// editor. Comments, snapshots and
+// history stay with the original.
+func (r *DocumentRepository) DuplicateDocument(
+	ctx context.Context,
+	sourceId int,
+	userId int,
+	form models.CreateDocumentModel,
+	includeMembers bool,
+) (*models.BaseDocumentModel, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		SELECT COALESCE(content, '')
+		FROM documents
+		WHERE id = $1 AND document_role(id, $2) IS NOT NULL
+		FOR SHARE
+	`
+	if err := tx.QueryRow(ctx, query, sourceId, userId).Scan(&form.Content); err != nil {
+		return nil, err
+	}
+
+	document, err := createDocument(ctx, tx, form, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE documents SET blocks = (SELECT blocks FROM documents WHERE id = $1) WHERE id = $2`
+	if _, err := tx.Exec(ctx, query, sourceId, document.Id); err != nil {
+		return nil, err
+	}
+
+	query = `
+		INSERT INTO documents_tags (document_id, tag_id)
+		SELECT $2, tag_id FROM documents_tags WHERE document_id = $1
+	`
+	if _, err := tx.Exec(ctx, query, sourceId, document.Id); err != nil {
+		return nil, err
+	}
+
+	if includeMembers {
+		editorId, err := ensureRole(ctx, tx, utils.RoleEditor)
+		if err != nil {
+			return nil, err
+		}
+
+		// The owner of the original has no role of their own on it.
+		query = `
+			INSERT INTO documents_users (document_id, user_id, role_id)
+			SELECT $2, du.user_id, CASE WHEN du.user_id = d.owner_id THEN $4 ELSE du.role_id END
+			FROM documents_users du
+			JOIN documents d ON d.id = du.document_id
+			WHERE du.document_id = $1 AND du.user_id <> $3
+			ON CONFLICT DO NOTHING
+			RETURNING user_id
+		`
+		rows, err := tx.Query(ctx, query, sourceId, document.Id, userId, editorId)
+		if err != nil {
+			return nil, err
+		}
+		members, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, memberId := range members {
+			payload := models.MemberEventPayload{DocumentId: document.Id, UserId: memberId}
+			if err := writeEvent(ctx, tx, utils.EventMemberAdded, "document", document.Id, &userId, &document.Id, payload); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = writeDocumentAudit(
+		ctx, tx, utils.AuditDocumentDuplicate, document.Id, userId,
+		nil, map[string]any{"source_id": sourceId, "include_members": includeMembers},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+
+// TransferOwnership makes newOwnerId, who must already have access to the
+// document, its owner. The previous owner stays on as an editor. Only an
+// owner of the document may hand it over.
+func (r *DocumentRepository) TransferOwnership(
+	ctx context.Context,
+	documentId int,
+	newOwnerId int,
+	actorId int,
+) (*models.BaseDocumentModel, error) {
+	var document models.BaseDocumentModel
+
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var previousOwner *int
+	var hasAccess bool
+	lockQuery := `
+		SELECT owner_id, document_role(id, $3) IS NOT NULL
+		FROM documents
+		WHERE id = $1 AND document_role(id, $2) = 'owner'
+		FOR UPDATE
+	`
+	if err := tx.QueryRow(ctx, lockQuery, documentId, actorId, newOwnerId).Scan(&previousOwner, &hasAccess); err != nil {
+		return nil, err
+	}
+	if previousOwner != nil && *previousOwner == newOwnerId {
+		return nil, ErrAlreadyOwner
+	}
+	if !hasAccess {
+		return nil, ErrNotDocumentMember
+	}
+
+	query := `
+		UPDATE documents
+		SET owner_id = $2, updated_at = now()
+		WHERE id = $1
+		RETURNING id, title, content, is_public, workspace_id, folder_id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, documentId, newOwnerId).Scan(
+		&document.Id, &document.Title, &document.Content,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Owners have no role of their own, like the creator of a document.
+	query = `
+		INSERT INTO documents_users (document_id, user_id, role_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, document_id) DO UPDATE SET role_id = EXCLUDED.role_id
+	`
+	if _, err := tx.Exec(ctx, query, documentId, newOwnerId, nil); err != nil {
+		return nil, err
+	}
+
+	changes := []models.MemberEventPayload{{DocumentId: documentId, UserId: newOwnerId, Role: utils.RoleOwner}}
+	if previousOwner != nil {
+		editorId, err := ensureRole(ctx, tx, utils.RoleEditor)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, query, documentId, *previousOwner, editorId); err != nil {
+			return nil, err
+		}
+		changes = append(changes, models.MemberEventPayload{DocumentId: documentId, UserId: *previousOwner, Role: utils.RoleEditor})
+	}
+
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentUpdated, &document, newOwnerId, actorId); err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if err := writeEvent(ctx, tx, utils.EventMemberRoleChanged, "document", documentId, &actorId, &documentId, change); err != nil {
+			return nil, err
+		}
+
+		err = writeNotifications(ctx, tx, []int{change.UserId}, models.NewNotificationModel{
+			Type:       utils.NotificationRoleChanged,
+			GroupKey:   fmt.Sprintf("role:%d", documentId),
+			DocumentId: &documentId,
+			ActorId:    actorId,
+			Data:       map[string]any{"role": change.Role, "title": document.Title},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = writeDocumentAudit(
+		ctx, tx, utils.AuditDocumentOwner, documentId, actorId,
+		map[string]*int{"owner_id": previousOwner}, map[string]*int{"owner_id": &newOwnerId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeActivity(ctx, tx, models.NewActivityModel{
+		DocumentId: documentId,
+		ActorId:    actorId,
+		Kind:       utils.ActivityOwnerChanged,
+		Data:       map[string]*int{"from": previousOwner, "to": &newOwnerId},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+
+func (r *DocumentRepository) AddDocumentSnapshot(
+	ctx context.Context,
+	documentId int,
+	userId int,
+) (*models.BaseSnapshotModel, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO document_snapshots (document_id, user_id, content)
+		SELECT d.id, $2, COALESCE(d.content, '')
+		FROM documents AS d
+		WHERE d.id = $1 AND document_role(d.id, $2) IN ('owner', 'editor')
+		RETURNING id, document_id, user_id, created_at
+	`
+
+	var snapshot models.BaseSnapshotModel
+
+	err = tx.QueryRow(ctx, query, documentId, userId).Scan(
+		&snapshot.Id, &snapshot.DocumentId, &snapshot.UserId, &snapshot.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeActivity(ctx, tx, models.NewActivityModel{
+		DocumentId: documentId,
+		ActorId:    userId,
+		Kind:       utils.ActivitySnapshotCreated,
+		Data:       map[string]int{"snapshot_id": snapshot.Id},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+
+// RestoreSnapshot replaces the document content with a snapshot of the same
+// document. The content being replaced is kept as a new snapshot first, so a
+// restore can itself be undone.
+func (r *DocumentRepository) RestoreSnapshot(
+	ctx context.Context,
+	documentId int,
+	snapshotId int,
+	userId int,
+) (*models.DocumentModel, error) {
+	var document models.DocumentModel
+
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var backupId int
+	query := `
+		INSERT INTO document_snapshots (document_id, user_id, content)
+		SELECT d.id, $2, COALESCE(d.content, '')
+		FROM documents d
+		WHERE d.id = $1 AND document_role(d.id, $2) IN ('owner', 'editor')
+			AND EXISTS(SELECT 1 FROM document_snapshots s WHERE s.id = $3 AND s.document_id = d.id)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, query, documentId, userId, snapshotId).Scan(&backupId); err != nil {
+		return nil, err
+	}
+
+	query = `
+		UPDATE documents d
+		SET content = s.content, updated_at = now()
+		FROM document_snapshots s
+		WHERE d.id = $1 AND s.id = $2 AND s.document_id = d.id
+		RETURNING d.id, d.title, d.content, d.is_public, d.workspace_id, d.folder_id, d.created_at, d.updated_at,
+			(SELECT username FROM users WHERE id = $3), (SELECT email FROM users WHERE id = $3)
+	`
+	editor := models.BaseUserModel{Id: userId}
+	err = tx.QueryRow(ctx, query, documentId, snapshotId, userId).Scan(
+		&document.Id, &document.Title, &document.Content,
+		&document.IsPublic, &document.WorkspaceId, &document.FolderId, &document.CreatedAt, &document.UpdatedAt,
+		&editor.Username, &editor.Email,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if document.Stats, err = writeDocumentStats(ctx, tx, document.Id, document.Content, userId); err != nil {
+		return nil, err
+	}
+	document.LastEditor = &editor
+
+	if err := writeDocumentEvent(ctx, tx, utils.EventDocumentUpdated, &document.BaseDocumentModel, userId, userId); err != nil {
+		return nil, err
+	}
+
+	err = writeActivity(ctx, tx, models.NewActivityModel{
+		DocumentId: documentId,
+		ActorId:    userId,
+		Kind:       utils.ActivitySnapshotRestored,
+		Data:       map[string]int{"snapshot_id": snapshotId, "backup_snapshot_id": backupId},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &document, nil
 }
@@ -0,0 +1,521 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// MaxFolderDepth is the deepest a folder may be nested, counting the root
+// folder as 1.
+const MaxFolderDepth = 32
+
+
+var (
+	ErrFolderCycle         = errors.New("folder cannot be moved into itself or its subfolders")
+	ErrFolderTooDeep       = errors.New("folders are nested too deeply")
+	ErrFolderSpaceMismatch = errors.New("folder belongs to a different workspace")
+)
+
+
+type FolderRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+func writeFolderAudit(
+	ctx context.Context,
+	db execer,
+	action string,
+	folderId int,
+	actorId int,
+	before any,
+	after any,
+) error {
+	beforeJSON, afterJSON, err := auditValues(before, after)
+	if err != nil {
+		return err
+	}
+	return writeAudit(ctx, db, models.AuditEntryModel{
+		ActorId:    &actorId,
+		Action:     action,
+		TargetType: "folder",
+		TargetId:   strconv.Itoa(folderId),
+		Before:     beforeJSON,
+		After:      afterJSON,
+	})
+}
+
+
+// lockFolderTree serialises structural changes to folders. Two concurrent
+// moves could otherwise each pass the cycle check and together form a loop.
+func lockFolderTree(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('folders.tree'))`)
+	return err
+}
+
+
+// checkFolderPlacement verifies that folderId (0 for a new folder) can be put
+// under parentId without creating a cycle or exceeding MaxFolderDepth.
+func checkFolderPlacement(ctx context.Context, tx pgx.Tx, folderId int, parentId int) error {
+	query := `
+		WITH RECURSIVE up AS (
+			SELECT id, parent_id, 1 AS depth FROM folders WHERE id = $2
+			UNION ALL
+			SELECT f.id, f.parent_id, up.depth + 1
+			FROM folders f
+			JOIN up ON f.id = up.parent_id
+			WHERE up.depth <= $3
+		),
+		down AS (
+			SELECT id, 1 AS depth FROM folders WHERE id = $1
+			UNION ALL
+			SELECT f.id, down.depth + 1
+			FROM folders f
+			JOIN down ON f.parent_id = down.id
+			WHERE down.depth <= $3
+		)
+		SELECT
+			EXISTS(SELECT 1 FROM up WHERE id = $1),
+			COALESCE((SELECT max(depth) FROM up), 0) + COALESCE((SELECT max(depth) FROM down), 1)
+	`
+	var cycle bool
+	var depth int
+	if err := tx.QueryRow(ctx, query, folderId, parentId, MaxFolderDepth).Scan(&cycle, &depth); err != nil {
+		return err
+	}
+	if cycle {
+		return ErrFolderCycle
+	}
+	if depth > MaxFolderDepth {
+		return ErrFolderTooDeep
+	}
+	return nil
+}
+
+
+// folderDepth returns how deep folderId is nested, counting a root folder as 1.
+func folderDepth(ctx context.Context, tx pgx.Tx, folderId int) (int, error) {
+	query := `
+		WITH RECURSIVE up AS (
+			SELECT id, parent_id, 1 AS depth FROM folders WHERE id = $1
+			UNION ALL
+			SELECT f.id, f.parent_id, up.depth + 1
+			FROM folders f
+			JOIN up ON f.id = up.parent_id
+			WHERE up.depth <= $2
+		)
+		SELECT COALESCE(max(depth), 0) FROM up
+	`
+	var depth int
+	err := tx.QueryRow(ctx, query, folderId, MaxFolderDepth).Scan(&depth)
+	return depth, err
+}
+
+
+// getFolderPath returns the breadcrumbs from the outermost folder down to
+// folderId. Ancestors the user cannot open are left out; since access is
+// inherited downwards they can only be missing from the top of the path.
+func getFolderPath(ctx context.Context, db *pgxpool.Pool, folderId int, userId int) ([]models.BreadcrumbModel, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, name, parent_id, 1 AS depth FROM folders WHERE id = $1
+			UNION ALL
+			SELECT f.id, f.name, f.parent_id, chain.depth + 1
+			FROM folders f
+			JOIN chain ON f.id = chain.parent_id
+			WHERE chain.depth < $3
+		)
+		SELECT id, name
+		FROM chain
+		WHERE folder_role(id, $2) IS NOT NULL
+		ORDER BY depth DESC
+	`
+	rows, err := db.Query(ctx, query, folderId, userId, MaxFolderDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	path := make([]models.BreadcrumbModel, 0)
+	for rows.Next() {
+		var crumb models.BreadcrumbModel
+		if err := rows.Scan(&crumb.Id, &crumb.Name); err != nil {
+			return nil, err
+		}
+		path = append(path, crumb)
+	}
+	return path, rows.Err()
+}
+
+
+// CheckFolderRole reports whether the effective role of the user on the
+// folder is one of roles; without roles any access counts.
+func (r *FolderRepository) CheckFolderRole(ctx context.Context, userId int, folderId int, roles ...string) (bool, error) {
+	if roles == nil {
+		roles = []string{}
+	}
+
+	var hasRole bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT role IS NOT NULL AND (cardinality($3::text[]) = 0 OR role = ANY($3::text[]))
+		FROM folder_role($1, $2) AS role
+	`, folderId, userId, roles).Scan(&hasRole)
+	return hasRole, err
+}
+
+
+const folderSelect = `
+	SELECT f.id, f.name, f.parent_id, f.workspace_id, folder_role(f.id, $1), f.created_at, f.updated_at
+	FROM folders f
+`
+
+
+func scanFolder(row pgx.Row, folder *models.FolderModel) error {
+	return row.Scan(
+		&folder.Id, &folder.Name, &folder.ParentId, &folder.WorkspaceId,
+		&folder.Role, &folder.CreatedAt, &folder.UpdatedAt,
+	)
+}
+
+
+// CreateFolder creates a folder owned by userId. A subfolder always lives in
+// the workspace of its parent.
+func (r *FolderRepository) CreateFolder(
+	ctx context.Context,
+	userId int,
+	form models.CreateFolderModel,
+) (*models.FolderModel, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	workspaceId := form.WorkspaceId
+	if form.ParentId != nil {
+		if err := lockFolderTree(ctx, tx); err != nil {
+			return nil, err
+		}
+
+		var parentWorkspaceId *int
+		query := `SELECT workspace_id FROM folders WHERE id = $1`
+		if err := tx.QueryRow(ctx, query, *form.ParentId).Scan(&parentWorkspaceId); err != nil {
+			return nil, err
+		}
+		if workspaceId != nil && !utils.SameId(workspaceId, parentWorkspaceId) {
+			return nil, ErrFolderSpaceMismatch
+		}
+		workspaceId = parentWorkspaceId
+
+		if err := checkFolderPlacement(ctx, tx, 0, *form.ParentId); err != nil {
+			return nil, err
+		}
+	}
+
+	folder, err := insertFolder(ctx, tx, userId, form.Name, form.ParentId, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+
+// insertFolder creates the folder without any checks; callers validate the
+// placement first.
+func insertFolder(
+	ctx context.Context,
+	tx pgx.Tx,
+	userId int,
+	name string,
+	parentId *int,
+	workspaceId *int,
+) (*models.FolderModel, error) {
+	var folderId int
+	query := `
+		INSERT INTO folders (name, parent_id, owner_id, workspace_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	if err := tx.QueryRow(ctx, query, name, parentId, userId, workspaceId).Scan(&folderId); err != nil {
+		return nil, err
+	}
+
+	var folder models.FolderModel
+	if err := scanFolder(tx.QueryRow(ctx, folderSelect+` WHERE f.id = $2`, userId, folderId), &folder); err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+
+// GetFolder returns the folder with its breadcrumbs, or pgx.ErrNoRows when
+// the user cannot open it.
+func (r *FolderRepository) GetFolder(ctx context.Context, folderId int, userId int) (*models.FolderModel, error) {
+	var folder models.FolderModel
+
+	query := folderSelect + ` WHERE f.id = $2 AND folder_role(f.id, $1) IS NOT NULL`
+	if err := scanFolder(r.DB.QueryRow(ctx, query, userId, folderId), &folder); err != nil {
+		return nil, err
+	}
+
+	path, err := getFolderPath(ctx, r.DB, folderId, userId)
+	if err != nil {
+		return nil, err
+	}
+	folder.Path = path
+	return &folder, nil
+}
+
+
+// GetRootFolders lists the top-level folders of a workspace, or of the
+// personal space of userId when workspaceId is nil. The personal root also
+// contains folders shared with the user directly.
+func (r *FolderRepository) GetRootFolders(
+	ctx context.Context,
+	userId int,
+	workspaceId *int,
+	limit int,
+	offset int,
+) ([]*models.FolderModel, error) {
+	query := folderSelect + `
+		WHERE CASE
+			WHEN $2::int IS NULL THEN
+				(f.parent_id IS NULL AND f.workspace_id IS NULL AND f.owner_id = $1)
+				OR EXISTS(SELECT 1 FROM folder_members fm WHERE fm.folder_id = f.id AND fm.user_id = $1)
+			ELSE f.parent_id IS NULL AND f.workspace_id = $2
+		END
+		ORDER BY lower(f.name), f.id
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.DB.Query(ctx, query, userId, workspaceId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := make([]*models.FolderModel, 0)
+	for rows.Next() {
+		var folder models.FolderModel
+		if err := scanFolder(rows, &folder); err != nil {
+			return nil, err
+		}
+		folders = append(folders, &folder)
+	}
+	return folders, rows.Err()
+}
+
+
+// GetChildren lists subfolders first, then documents, each alphabetically.
+// Everything inside a folder inherits its permissions, so the caller only
+// needs access to the folder itself.
+func (r *FolderRepository) GetChildren(
+	ctx context.Context,
+	folderId int,
+	limit int,
+	offset int,
+) ([]*models.FolderItemModel, error) {
+	query := `
+		SELECT kind, id, name, updated_at
+		FROM (
+			SELECT $4::text AS kind, id, name, updated_at, 0 AS ord FROM folders WHERE parent_id = $1
+			UNION ALL
+			SELECT $5::text, id, title, updated_at, 1 FROM documents WHERE folder_id = $1 AND deleted_at IS NULL
+		) AS items
+		ORDER BY ord, lower(name), id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.DB.Query(
+		ctx, query, folderId, limit, offset, utils.FolderItemFolder, utils.FolderItemDocument,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*models.FolderItemModel, 0)
+	for rows.Next() {
+		var item models.FolderItemModel
+		if err := rows.Scan(&item.Type, &item.Id, &item.Name, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+
+func (r *FolderRepository) RenameFolder(ctx context.Context, folderId int, userId int, name string) (*models.FolderModel, error) {
+	var folder models.FolderModel
+
+	query := `
+		UPDATE folders f
+		SET name = $3, updated_at = now()
+		WHERE f.id = $2 AND folder_role(f.id, $1) = 'owner'
+		RETURNING f.id, f.name, f.parent_id, f.workspace_id, 'owner', f.created_at, f.updated_at
+	`
+	if err := scanFolder(r.DB.QueryRow(ctx, query, userId, folderId, name), &folder); err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+
+// MoveFolder moves a folder with everything in it under parentId, or to the
+// root of its space when parentId is nil. Folders never change workspace by
+// moving; the caller must own the folder.
+func (r *FolderRepository) MoveFolder(ctx context.Context, folderId int, parentId *int, userId int) (*models.FolderModel, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockFolderTree(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	var previous, workspaceId *int
+	query := `SELECT parent_id, workspace_id FROM folders WHERE id = $1 AND folder_role(id, $2) = 'owner' FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, folderId, userId).Scan(&previous, &workspaceId); err != nil {
+		return nil, err
+	}
+
+	if parentId != nil {
+		var parentWorkspaceId *int
+		query = `SELECT workspace_id FROM folders WHERE id = $1`
+		if err := tx.QueryRow(ctx, query, *parentId).Scan(&parentWorkspaceId); err != nil {
+			return nil, err
+		}
+		if !utils.SameId(workspaceId, parentWorkspaceId) {
+			return nil, ErrFolderSpaceMismatch
+		}
+		if err := checkFolderPlacement(ctx, tx, folderId, *parentId); err != nil {
+			return nil, err
+		}
+	}
+
+	var folder models.FolderModel
+	query = `
+		UPDATE folders f
+		SET parent_id = $3, updated_at = now()
+		WHERE f.id = $2
+		RETURNING f.id, f.name, f.parent_id, f.workspace_id, folder_role(f.id, $1), f.created_at, f.updated_at
+	`
+	if err := scanFolder(tx.QueryRow(ctx, query, userId, folderId, parentId), &folder); err != nil {
+		return nil, err
+	}
+
+	// Moving changes which grants are inherited, so it is audited like sharing.
+	err = writeFolderAudit(
+		ctx, tx, utils.AuditFolderMove, folderId, userId,
+		map[string]*int{"parent_id": previous}, map[string]*int{"parent_id": parentId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+
+// DeleteFolder removes a folder and hands its subfolders and documents to its
+// parent, so nothing inside is lost.
+func (r *FolderRepository) DeleteFolder(ctx context.Context, folderId int, userId int) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockFolderTree(ctx, tx); err != nil {
+		return err
+	}
+
+	var parentId *int
+	query := `SELECT parent_id FROM folders WHERE id = $1 AND folder_role(id, $2) = 'owner' FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, folderId, userId).Scan(&parentId); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE folders SET parent_id = $2 WHERE parent_id = $1`, folderId, parentId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE documents SET folder_id = $2 WHERE folder_id = $1`, folderId, parentId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM folders WHERE id = $1`, folderId); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+
+// ShareFolder grants memberId role on the folder and, through inheritance,
+// on everything inside it.
+func (r *FolderRepository) ShareFolder(ctx context.Context, folderId int, memberId int, role string, actorId int) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous *string
+	query := `SELECT role FROM folder_members WHERE folder_id = $1 AND user_id = $2 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, folderId, memberId).Scan(&previous); err != nil && err != pgx.ErrNoRows {
+		return err
+	}
+
+	query = `
+		INSERT INTO folder_members (folder_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (folder_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`
+	if _, err := tx.Exec(ctx, query, folderId, memberId, role); err != nil {
+		return err
+	}
+
+	var before any
+	if previous != nil {
+		before = map[string]any{"user_id": memberId, "role": *previous}
+	}
+	after := map[string]any{"user_id": memberId, "role": role}
+	if err := writeFolderAudit(ctx, tx, utils.AuditFolderShare, folderId, actorId, before, after); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+
+func (r *FolderRepository) UnshareFolder(ctx context.Context, folderId int, memberId int, actorId int) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous string
+	query := `DELETE FROM folder_members WHERE folder_id = $1 AND user_id = $2 RETURNING role`
+	if err := tx.QueryRow(ctx, query, folderId, memberId).Scan(&previous); err != nil {
+		return err
+	}
+
+	before := map[string]any{"user_id": memberId, "role": previous}
+	if err := writeFolderAudit(ctx, tx, utils.AuditFolderUnshare, folderId, actorId, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
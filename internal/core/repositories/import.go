@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"golang/internal/infrastructure/database/models"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+
+// ImportDocuments creates the documents of an import in one transaction,
+// recreating their folder structure under folderId, or at the root of the
+// workspace or personal space when folderId is nil. workspaceId must already
+// be the workspace of folderId. Either everything is imported or nothing.
+func (r *DocumentRepository) ImportDocuments(
+	ctx context.Context,
+	userId int,
+	folderId *int,
+	workspaceId *int,
+	files []models.ImportFileModel,
+) (*models.ImportResultModel, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	levels := 0
+	for _, file := range files {
+		levels = max(levels, len(file.Folders))
+	}
+	if levels > 0 {
+		if err := lockFolderTree(ctx, tx); err != nil {
+			return nil, err
+		}
+
+		depth := 0
+		if folderId != nil {
+			if depth, err = folderDepth(ctx, tx, *folderId); err != nil {
+				return nil, err
+			}
+		}
+		if depth+levels > MaxFolderDepth {
+			return nil, ErrFolderTooDeep
+		}
+	}
+
+	result := &models.ImportResultModel{
+		Folders:   make([]*models.FolderModel, 0),
+		Documents: make([]*models.BaseDocumentModel, 0, len(files)),
+	}
+	created := make(map[string]int)
+	for _, file := range files {
+		parentId := folderId
+		for i, name := range file.Folders {
+			key := strings.Join(file.Folders[:i+1], "/")
+			if id, ok := created[key]; ok {
+				parentId = &id
+				continue
+			}
+
+			folder, err := insertFolder(ctx, tx, userId, name, parentId, workspaceId)
+			if err != nil {
+				return nil, err
+			}
+			created[key] = folder.Id
+			result.Folders = append(result.Folders, folder)
+			parentId = &folder.Id
+		}
+
+		document, err := createDocument(ctx, tx, models.CreateDocumentModel{
+			Title:       file.Title,
+			Content:     file.Content,
+			WorkspaceId: workspaceId,
+			FolderId:    parentId,
+		}, userId)
+		if err != nil {
+			return nil, err
+		}
+		result.Documents = append(result.Documents, document)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+)
+
+
+// GetBacklinks returns the documents the user can open whose content links
+// to documentId, by id or by a wiki link to its title from the same
+// workspace or personal space. The index itself is kept by a trigger on
+// documents.content.
+func (r *DocumentRepository) GetBacklinks(
+	ctx context.Context,
+	documentId int,
+	userId int,
+	limit int,
+	offset int,
+) ([]*models.BacklinkModel, error) {
+	query := `
+		WITH target AS (
+			SELECT id, lower(title) AS title, workspace_id, owner_id
+			FROM documents
+			WHERE id = $1
+		), sources AS (
+			SELECT l.source_id
+			FROM document_links l, target t
+			WHERE l.target_id = t.id
+			UNION
+			SELECT l.source_id
+			FROM document_links l
+			JOIN target t ON lower(l.target_title) = t.title
+			JOIN documents s ON s.id = l.source_id
+			WHERE s.workspace_id IS NOT DISTINCT FROM t.workspace_id
+				AND (t.workspace_id IS NOT NULL OR s.owner_id = t.owner_id)
+		)
+		SELECT d.id, d.title, d.workspace_id, d.folder_id, d.updated_at
+		FROM sources
+		JOIN documents d ON d.id = sources.source_id
+		WHERE d.id <> $1 AND document_role(d.id, $2) IS NOT NULL
+		ORDER BY d.updated_at DESC, d.id
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.DB.Query(ctx, query, documentId, userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	backlinks := make([]*models.BacklinkModel, 0)
+	for rows.Next() {
+		var backlink models.BacklinkModel
+		err := rows.Scan(&backlink.Id, &backlink.Title, &backlink.WorkspaceId, &backlink.FolderId, &backlink.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		backlinks = append(backlinks, &backlink)
+	}
+	return backlinks, rows.Err()
+}
+
+
+// GetDocumentLinks returns the links in the content of documentId, links to
+// ids first and wiki links after, each resolved for the user. A wiki link
+// resolves to a document with that title in the same space, preferring one
+// the user can open. Documents the user cannot open are not named.
+func (r *DocumentRepository) GetDocumentLinks(ctx context.Context, documentId int, userId int) ([]*models.DocumentLinkModel, error) {
+	query := `
+		SELECT l.target_id, l.target_title, t.id, t.title, t.role
+		FROM documents s
+		JOIN document_links l ON l.source_id = s.id
+		LEFT JOIN LATERAL (
+			SELECT d.id, d.title, document_role(d.id, $2) AS role
+			FROM documents d
+			WHERE d.deleted_at IS NULL AND d.id <> s.id AND (
+				d.id = l.target_id
+				OR (lower(d.title) = lower(l.target_title)
+					AND d.workspace_id IS NOT DISTINCT FROM s.workspace_id
+					AND (d.workspace_id IS NOT NULL OR d.owner_id = s.owner_id))
+			)
+			ORDER BY document_role(d.id, $2) IS NULL, d.id
+			LIMIT 1
+		) t ON true
+		WHERE s.id = $1
+		ORDER BY l.id
+	`
+	rows, err := r.DB.Query(ctx, query, documentId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make([]*models.DocumentLinkModel, 0)
+	for rows.Next() {
+		var link models.DocumentLinkModel
+		var role *string
+		err := rows.Scan(&link.TargetId, &link.TargetTitle, &link.DocumentId, &link.Title, &role)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case link.DocumentId == nil:
+			link.Status = utils.LinkMissing
+		case role == nil:
+			link.Status = utils.LinkInaccessible
+			link.DocumentId, link.Title = nil, nil
+		default:
+			link.Status = utils.LinkOk
+		}
+		links = append(links, &link)
+	}
+	return links, rows.Err()
+}
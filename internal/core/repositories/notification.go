@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/infrastructure/database/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// NotificationChannel is the Postgres NOTIFY channel announcing new or
+// updated notifications; the payload is {"id": ..., "user_id": ...}.
+const NotificationChannel = "notifications"
+
+
+// writeNotifications fans a notification out to recipients (the actor is
+// skipped) inside the caller's transaction. An unread notification with the
+// same group key is bumped instead of duplicated. pg_notify is delivered on
+// commit, so listeners never see rolled back notifications.
+func writeNotifications(ctx context.Context, db execer, recipients []int, notification models.NewNotificationModel) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(notification.Data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH n AS (
+			INSERT INTO notifications (user_id, type, group_key, document_id, actor_id, data)
+			SELECT DISTINCT r.user_id, $2, $3, $4, $5, $6
+			FROM unnest($1::int[]) AS r(user_id)
+			WHERE r.user_id <> $5
+			ON CONFLICT (user_id, group_key) WHERE read_at IS NULL DO UPDATE
+			SET count = notifications.count + 1,
+				actor_id = EXCLUDED.actor_id,
+				data = EXCLUDED.data,
+				updated_at = now()
+			RETURNING id, user_id
+		)
+		SELECT pg_notify('` + NotificationChannel + `', json_build_object('id', n.id, 'user_id', n.user_id)::text)
+		FROM n
+	`
+	_, err = db.Exec(
+		ctx, query, recipients, notification.Type, notification.GroupKey,
+		notification.DocumentId, notification.ActorId, data,
+	)
+	return err
+}
+
+
+type NotificationRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+// NotifyEmail notifies the account registered with email, if there is one.
+func (r *NotificationRepository) NotifyEmail(ctx context.Context, email string, notification models.NewNotificationModel) error {
+	var recipients []int
+
+	query := `SELECT COALESCE(array_agg(id), '{}') FROM users WHERE lower(email) = lower($1)`
+	if err := r.DB.QueryRow(ctx, query, email).Scan(&recipients); err != nil {
+		return err
+	}
+	return writeNotifications(ctx, r.DB, recipients, notification)
+}
+
+
+const notificationSelect = `
+	SELECT
+		n.id, n.type, n.document_id, n.count, n.data, n.read_at, n.created_at, n.updated_at,
+		u.id, u.username, u.email
+	FROM notifications n
+	LEFT JOIN users u ON u.id = n.actor_id
+`
+
+
+func scanNotification(row pgx.Row, notification *models.NotificationModel) error {
+	var actorId *int
+	var actorName, actorEmail *string
+
+	err := row.Scan(
+		&notification.Id, &notification.Type, &notification.DocumentId, &notification.Count,
+		&notification.Data, &notification.ReadAt, &notification.CreatedAt, &notification.UpdatedAt,
+		&actorId, &actorName, &actorEmail,
+	)
+	if err != nil {
+		return err
+	}
+	if actorId != nil {
+		notification.Actor = &models.BaseUserModel{Id: *actorId, Username: *actorName, Email: *actorEmail}
+	}
+	return nil
+}
+
+
+func (r *NotificationRepository) GetNotification(ctx context.Context, userId int, notificationId int64) (*models.NotificationModel, error) {
+	var notification models.NotificationModel
+
+	query := notificationSelect + ` WHERE n.id = $1 AND n.user_id = $2`
+	if err := scanNotification(r.DB.QueryRow(ctx, query, notificationId, userId), &notification); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+
+func (r *NotificationRepository) GetUserNotifications(
+	ctx context.Context,
+	userId int,
+	unreadOnly bool,
+	limit int,
+	offset int,
+) ([]*models.NotificationModel, error) {
+	query := notificationSelect + `
+		WHERE n.user_id = $1 AND (NOT $2 OR n.read_at IS NULL)
+		ORDER BY n.updated_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.DB.Query(ctx, query, userId, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := make([]*models.NotificationModel, 0)
+	for rows.Next() {
+		var notification models.NotificationModel
+		if err := scanNotification(rows, &notification); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &notification)
+	}
+	return notifications, rows.Err()
+}
+
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userId int) (int, error) {
+	var count int
+	err := r.DB.QueryRow(ctx, `SELECT count(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userId).Scan(&count)
+	return count, err
+}
+
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userId int, notificationId int64) error {
+	query := `
+		UPDATE notifications SET read_at = COALESCE(read_at, now())
+		WHERE id = $1 AND user_id = $2
+	`
+	rows, err := r.DB.Exec(ctx, query, notificationId, userId)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userId int) (int, error) {
+	rows, err := r.DB.Exec(ctx, `UPDATE notifications SET read_at = now() WHERE user_id = $1 AND read_at IS NULL`, userId)
+	if err != nil {
+		return 0, err
+	}
+	return int(rows.RowsAffected()), nil
+}
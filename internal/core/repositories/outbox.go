@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/infrastructure/database/models"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// execer is satisfied by both the pool and a transaction, so outbox rows can
+// be written inside whatever transaction changes the aggregate.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+
+func writeEvent(
+	ctx context.Context,
+	db execer,
+	eventType string,
+	aggregateType string,
+	aggregateId int,
+	actorId *int,
+	documentId *int,
+	payload any,
+) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO outbox_events (event_type, aggregate_type, aggregate_id, actor_id, document_id, payload)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = db.Exec(ctx, query, eventType, aggregateType, strconv.Itoa(aggregateId), actorId, documentId, body)
+	return err
+}
+
+
+type OutboxRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+// ClaimPending locks up to limit due events for the duration of tx. Other
+// relays skip locked rows, so several instances can run side by side.
+func (r *OutboxRepository) ClaimPending(ctx context.Context, tx pgx.Tx, limit int) ([]*models.DomainEventModel, error) {
+	query := `
+		SELECT
+			id, event_id::text, event_type, aggregate_type, aggregate_id,
+			actor_id, document_id, payload, attempts, created_at
+		FROM outbox_events
+		WHERE published_at IS NULL AND next_attempt_at <= now()
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.DomainEventModel
+	for rows.Next() {
+		var event models.DomainEventModel
+		err := rows.Scan(
+			&event.Id, &event.EventId, &event.Type, &event.AggregateType, &event.AggregateId,
+			&event.ActorId, &event.DocumentId, &event.Payload, &event.Attempts, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, tx pgx.Tx, eventId int64) error {
+	_, err := tx.Exec(ctx, `UPDATE outbox_events SET published_at = now() WHERE id = $1`, eventId)
+	return err
+}
+
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, tx pgx.Tx, eventId int64, publishErr error, retryIn time.Duration) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = now() + $3 * interval '1 second'
+		WHERE id = $1
+	`
+	_, err := tx.Exec(ctx, query, eventId, publishErr.Error(), int(retryIn.Seconds()))
+	return err
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/core/content"
+	"golang/internal/infrastructure/database/models"
+	"log"
+	"time"
+)
+
+
+// documentStats computes the statistics of markdown and the outline as it
+// is stored.
+func documentStats(markdown string) (*models.DocumentStatsModel, []byte, error) {
+	stats := content.MarkdownStats(markdown)
+	outline, err := json.Marshal(stats.Outline)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	model := &models.DocumentStatsModel{
+		WordCount:      stats.Words,
+		CharacterCount: stats.Characters,
+		ReadingTime:    content.ReadingMinutes(stats.Words),
+		Outline:        make([]models.OutlineEntryModel, 0, len(stats.Outline)),
+	}
+	for _, heading := range stats.Outline {
+		model.Outline = append(model.Outline, models.OutlineEntryModel{Level: heading.Level, Text: heading.Text})
+	}
+	return model, outline, nil
+}
+
+
+// writeDocumentStats stores the statistics of the new content of a document
+// and who wrote it. Every content write calls it in its transaction.
+func writeDocumentStats(
+	ctx context.Context,
+	db execer,
+	documentId int,
+	markdown string,
+	editorId int,
+) (*models.DocumentStatsModel, error) {
+	stats, outline, err := documentStats(markdown)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE documents
+		SET word_count = $2, character_count = $3, outline = $4, last_editor_id = $5
+		WHERE id = $1
+	`
+	_, err = db.Exec(ctx, query, documentId, stats.WordCount, stats.CharacterCount, outline, editorId)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+
+// deriveDocumentStats computes the statistics of content written before they
+// were kept and stores them, unless the document changed since revision.
+// Failing to store them only costs another computation on the next read.
+func (r *DocumentRepository) deriveDocumentStats(
+	ctx context.Context,
+	documentId int,
+	markdown string,
+	revision time.Time,
+) (*models.DocumentStatsModel, error) {
+	stats, outline, err := documentStats(markdown)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE documents SET word_count = $2, character_count = $3, outline = $4
+		WHERE id = $1 AND word_count IS NULL AND updated_at = $5
+	`
+	if _, err := r.DB.Exec(ctx, query, documentId, stats.WordCount, stats.CharacterCount, outline, revision); err != nil {
+		log.Printf("failed to store stats of document %d: %v", documentId, err)
+	}
+	return stats, nil
+}
+
+
+// scanDocumentStats builds the statistics read with a document; nil when
+// they were never computed.
+func scanDocumentStats(wordCount *int, characterCount *int, outline []byte) (*models.DocumentStatsModel, error) {
+	if wordCount == nil || characterCount == nil {
+		return nil, nil
+	}
+
+	stats := &models.DocumentStatsModel{
+		WordCount:      *wordCount,
+		CharacterCount: *characterCount,
+		ReadingTime:    content.ReadingMinutes(*wordCount),
+		Outline:        make([]models.OutlineEntryModel, 0),
+	}
+	if outline != nil {
+		if err := json.Unmarshal(outline, &stats.Outline); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
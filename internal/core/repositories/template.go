@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+type TemplateRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+const templateColumns = `
+	t.id, t.name, t.description, t.title, t.content, t.workspace_id, t.created_by, t.created_at, t.updated_at
+`
+
+
+// readableTemplate matches the templates user $2 may use: their own and
+// those of their workspaces.
+const readableTemplate = `
+	(t.owner_id = $2 OR EXISTS(
+		SELECT 1 FROM workspace_members wm
+		WHERE wm.workspace_id = t.workspace_id AND wm.user_id = $2
+	))
+`
+
+
+// manageableTemplate matches the templates the user in parameter user may
+// change: their own, workspace templates they wrote while still an editor,
+// and any template of a workspace they own or administer.
+func manageableTemplate(user string) string {
+	return fmt.Sprintf(`
+		(t.owner_id = %[1]s OR EXISTS(
+			SELECT 1 FROM workspace_members wm
+			WHERE wm.workspace_id = t.workspace_id AND wm.user_id = %[1]s
+				AND (wm.role IN ('owner', 'admin') OR (wm.role = 'editor' AND t.created_by = %[1]s))
+		))
+	`, user)
+}
+
+
+func scanTemplate(row interface{ Scan(...any) error }) (*models.TemplateModel, error) {
+	var template models.TemplateModel
+	var id int
+	err := row.Scan(
+		&id, &template.Name, &template.Description, &template.Title, &template.Content,
+		&template.WorkspaceId, &template.CreatedBy, &template.CreatedAt, &template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Id = &id
+	template.Scope = utils.TemplateScopePersonal
+	if template.WorkspaceId != nil {
+		template.Scope = utils.TemplateScopeWorkspace
+	}
+	return &template, nil
+}
+
+
+// GetTemplates returns the personal templates of the user and the templates
+// of their workspaces, or of workspaceId only when it is set.
+func (r *TemplateRepository) GetTemplates(ctx context.Context, userId int, workspaceId *int) ([]*models.TemplateModel, error) {
+	query := `
+		SELECT ` + templateColumns + `
+		FROM document_templates t
+		WHERE ` + readableTemplate + `
+			AND ($1::int IS NULL OR t.owner_id IS NOT NULL OR t.workspace_id = $1)
+		ORDER BY t.workspace_id NULLS FIRST, lower(t.name), t.id
+	`
+	rows, err := r.DB.Query(ctx, query, workspaceId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := make([]*models.TemplateModel, 0)
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+
+func (r *TemplateRepository) GetTemplate(ctx context.Context, templateId int, userId int) (*models.TemplateModel, error) {
+	query := `
+		SELECT ` + templateColumns + `
+		FROM document_templates t
+		WHERE t.id = $1 AND ` + readableTemplate
+	return scanTemplate(r.DB.QueryRow(ctx, query, templateId, userId))
+}
+
+
+// CreateTemplate stores a template in the personal library of the user, or
+// in the workspace of the form. Workspace access is checked by the caller.
+func (r *TemplateRepository) CreateTemplate(
+	ctx context.Context,
+	userId int,
+	form models.CreateTemplateModel,
+) (*models.TemplateModel, error) {
+	var ownerId *int
+	if form.WorkspaceId == nil {
+		ownerId = &userId
+	}
+
+	query := `
+		INSERT INTO document_templates AS t (name, description, title, content, owner_id, workspace_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + templateColumns
+	row := r.DB.QueryRow(
+		ctx, query, form.Name, form.Description, form.Title, form.Content, ownerId, form.WorkspaceId, userId,
+	)
+	return scanTemplate(row)
+}
+
+
+func (r *TemplateRepository) UpdateTemplate(
+	ctx context.Context,
+	templateId int,
+	userId int,
+	form models.UpdateTemplateModel,
+) (*models.TemplateModel, error) {
+	clauses, args := utils.GetSetParams(form)
+	if clauses != "" {
+		clauses += ", "
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE document_templates AS t
+		SET %supdated_at = now()
+		WHERE t.id = $%d AND %s
+		RETURNING %s
+	`, clauses, len(args)+1, manageableTemplate(fmt.Sprintf("$%d", len(args)+2)), templateColumns)
+	args = append(args, templateId, userId)
+
+	return scanTemplate(r.DB.QueryRow(ctx, query, args...))
+}
+
+
+// DeleteTemplate removes a template the user may manage and reports
+// whether there was one.
+func (r *TemplateRepository) DeleteTemplate(ctx context.Context, templateId int, userId int) (bool, error) {
+	query := `DELETE FROM document_templates AS t WHERE t.id = $1 AND ` + manageableTemplate("$2")
+	result, err := r.DB.Exec(ctx, query, templateId, userId)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
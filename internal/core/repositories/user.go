@@ -3,7 +3,10 @@ package repositories
 import (
 	"context"
 	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"strconv"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -13,11 +16,19 @@ type UserRepository struct {
 }
 
 
+// GetUserByEmail matches the address case-insensitively, preferring an exact
+// match for accounts that only differ in case.
 func (repo *UserRepository) GetUserByEmail(ctx context.Context, value string) (*models.UserModel, error) {
 	var user models.UserModel
 
-	err := repo.DB.QueryRow(ctx, "SELECT id, username, email, password FROM users WHERE email = $1", value).Scan(
-		&user.Id, &user.Username, &user.Email, &user.Password,
+	query := `
+		SELECT id, username, email, password, locale FROM users
+		WHERE lower(email) = lower($1)
+		ORDER BY email = $1 DESC, id
+		LIMIT 1
+	`
+	err := repo.DB.QueryRow(ctx, query, value).Scan(
+		&user.Id, &user.Username, &user.Email, &user.Password, &user.Locale,
 	)
 
 	if err != nil {
@@ -42,8 +53,14 @@ func (repo *UserRepository) GetUserById(ctx context.Context, userId int) (*model
 
 func (repo *UserRepository) CreateUser(ctx context.Context, userForm models.RegisterUserModel) (*models.BaseUserModel, error) {
 	var user models.BaseUserModel
+
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 	
-	err := repo.DB.QueryRow(
+	err = tx.QueryRow(
 		ctx,
 		"INSERT INTO users (username, email, password) VALUES ($1, $2, $3) RETURNING id, username, email",
 		userForm.Username, userForm.Email, userForm.Password,
@@ -52,6 +69,15 @@ func (repo *UserRepository) CreateUser(ctx context.Context, userForm models.Regi
 	if err != nil {
 		return nil, err
 	}
+
+	payload := models.UserEventPayload{Id: user.Id, Username: user.Username, Email: user.Email}
+	if err := writeEvent(ctx, tx, utils.EventUserRegistered, "user", user.Id, &user.Id, nil, payload); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
@@ -72,10 +98,138 @@ func (repo *UserRepository) UpdateUser(ctx context.Context, userId int, userForm
 }
 
 
+func (repo *UserRepository) GetDigestSettings(ctx context.Context, userId int) (*models.DigestSettingsModel, error) {
+	var settings models.DigestSettingsModel
+
+	err := repo.DB.QueryRow(
+		ctx,
+		"SELECT digest_frequency, last_digest_at FROM users WHERE id = $1",
+		userId,
+	).Scan(&settings.Frequency, &settings.LastSentAt)
+
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+
+func (repo *UserRepository) UpdateDigestSettings(ctx context.Context, userId int, frequency string) (*models.DigestSettingsModel, error) {
+	var settings models.DigestSettingsModel
+
+	err := repo.DB.QueryRow(
+		ctx,
+		"UPDATE users SET digest_frequency = $1 WHERE id = $2 RETURNING digest_frequency, last_digest_at",
+		frequency, userId,
+	).Scan(&settings.Frequency, &settings.LastSentAt)
+
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+
+// GetLocaleByEmail returns the email locale of the account registered with
+// email, or pgx.ErrNoRows for addresses without an account.
+func (repo *UserRepository) GetLocaleByEmail(ctx context.Context, email string) (string, error) {
+	var locale string
+	err := repo.DB.QueryRow(ctx, "SELECT locale FROM users WHERE lower(email) = lower($1)", email).Scan(&locale)
+	return locale, err
+}
+
+
+func (repo *UserRepository) UpdateLocale(ctx context.Context, userId int, locale string) (*models.LocaleModel, error) {
+	var settings models.LocaleModel
+
+	err := repo.DB.QueryRow(
+		ctx,
+		"UPDATE users SET locale = $1 WHERE id = $2 RETURNING locale",
+		locale, userId,
+	).Scan(&settings.Locale)
+
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+
+func (repo *UserRepository) GetPasswordById(ctx context.Context, userId int) (string, error) {
+	var password string
+	err := repo.DB.QueryRow(ctx, "SELECT password FROM users WHERE id = $1", userId).Scan(&password)
+	return password, err
+}
+
+
+// UpdatePassword stores a new password hash and records auth.password.change
+// in the same transaction. Hashes never end up in the audit log.
+func (repo *UserRepository) UpdatePassword(ctx context.Context, userId int, hashedPassword string) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Exec(ctx, "UPDATE users SET password = $1 WHERE id = $2", hashedPassword, userId)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	entry := models.AuditEntryModel{
+		ActorId:    &userId,
+		Action:     utils.AuditPasswordChange,
+		TargetType: "user",
+		TargetId:   strconv.Itoa(userId),
+	}
+	if err := writeAudit(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+
+func (repo *UserRepository) IsAdmin(ctx context.Context, userId int) (bool, error) {
+	var isAdmin bool
+	err := repo.DB.QueryRow(ctx, "SELECT is_admin FROM users WHERE id = $1", userId).Scan(&isAdmin)
+	return isAdmin, err
+}
+
+
 func (repo *UserRepository) DeleteUser(ctx context.Context, userId int) error {
 	_, err := repo.DB.Exec(ctx, "DELETE FROM users WHERE id = $1", userId)
 	if err != nil {
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func (repo *UserRepository) GetUserByIdentity(ctx context.Context, provider string, subject string) (*models.BaseUserModel, error) {
+	var user models.BaseUserModel
+
+	query := `
+		SELECT u.id, u.username, u.email
+		FROM user_identities AS ui
+		JOIN users AS u ON u.id = ui.user_id
+		WHERE ui.provider = $1 AND ui.subject = $2
+	`
+	err := repo.DB.QueryRow(ctx, query, provider, subject).Scan(&user.Id, &user.Username, &user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+
+func (repo *UserRepository) CreateUserIdentity(ctx context.Context, identity models.UserIdentityModel) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, subject) DO NOTHING
+	`
+	_, err := repo.DB.Exec(ctx, query, identity.UserId, identity.Provider, identity.Subject, identity.Email)
+	return err
+}
@@ -0,0 +1,275 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+type WebhookRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+const webhookColumns = `id, document_id, url, events, is_active, created_at`
+
+
+func scanWebhook(row pgx.Row, webhook *models.WebhookModel) error {
+	return row.Scan(
+		&webhook.Id, &webhook.DocumentId, &webhook.Url,
+		&webhook.Events, &webhook.IsActive, &webhook.CreatedAt,
+	)
+}
+
+
+func (r *WebhookRepository) CreateWebhook(
+	ctx context.Context,
+	ownerId int,
+	form models.CreateWebhookModel,
+	secret string,
+) (*models.WebhookModel, error) {
+	var webhook models.WebhookModel
+
+	events := form.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	query := `
+		INSERT INTO webhooks (owner_id, document_id, url, events, secret)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + webhookColumns
+	if err := scanWebhook(r.DB.QueryRow(ctx, query, ownerId, form.DocumentId, form.Url, events, secret), &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+
+func (r *WebhookRepository) GetUserWebhooks(ctx context.Context, ownerId int) ([]*models.WebhookModel, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE owner_id = $1 ORDER BY created_at DESC`
+	rows, err := r.DB.Query(ctx, query, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]*models.WebhookModel, 0)
+	for rows.Next() {
+		var webhook models.WebhookModel
+		if err := scanWebhook(rows, &webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, &webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+
+func (r *WebhookRepository) GetWebhook(ctx context.Context, ownerId int, webhookId int) (*models.WebhookModel, error) {
+	var webhook models.WebhookModel
+
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND owner_id = $2`
+	if err := scanWebhook(r.DB.QueryRow(ctx, query, webhookId, ownerId), &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, ownerId int, webhookId int) error {
+	rows, err := r.DB.Exec(ctx, `DELETE FROM webhooks WHERE id = $1 AND owner_id = $2`, webhookId, ownerId)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+
+// EnqueueDeliveries creates a pending delivery of event for every active
+// webhook that subscribes to it: document webhooks by document id, user
+// webhooks for any document, in both cases only while the webhook's owner
+// can still access the document. Access ignores the trash so that
+// document.deleted still reaches them. Redelivered events are ignored thanks
+// to the (webhook_id, event_id) key.
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, event *models.DomainEventModel) (int, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT w.id, $1, $2, $3
+		FROM webhooks w
+		WHERE w.is_active
+			AND (cardinality(w.events) = 0 OR $2 = ANY(w.events))
+			AND (
+				(w.document_id = $4 AND document_role_ignoring_trash($4, w.owner_id) IS NOT NULL)
+				OR (w.document_id IS NULL AND (
+					w.owner_id = $5
+					OR document_role_ignoring_trash($4, w.owner_id) IS NOT NULL
+				))
+			)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING
+	`
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := r.DB.Exec(ctx, query, event.EventId, event.Type, body, event.DocumentId, event.ActorId)
+	if err != nil {
+		return 0, err
+	}
+	return int(rows.RowsAffected()), nil
+}
+
+
+func (r *WebhookRepository) CreateTestDelivery(ctx context.Context, webhookId int, eventId string, payload []byte) (int64, error) {
+	var deliveryId int64
+
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, next_attempt_at)
+		VALUES ($1, $2, $3, $4, 'infinity')
+		RETURNING id
+	`
+	err := r.DB.QueryRow(ctx, query, webhookId, eventId, utils.EventWebhookTest, payload).Scan(&deliveryId)
+	return deliveryId, err
+}
+
+
+const webhookTargetQuery = `
+	SELECT wd.id, w.id, w.url, w.secret, wd.event_id, wd.event_type, wd.payload, wd.attempts
+	FROM webhook_deliveries wd
+	JOIN webhooks w ON w.id = wd.webhook_id
+`
+
+
+func scanTargets(rows pgx.Rows) ([]*models.WebhookTargetModel, error) {
+	defer rows.Close()
+
+	var targets []*models.WebhookTargetModel
+	for rows.Next() {
+		var target models.WebhookTargetModel
+		err := rows.Scan(
+			&target.DeliveryId, &target.WebhookId, &target.Url, &target.Secret,
+			&target.EventId, &target.EventType, &target.Payload, &target.Attempts,
+		)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, &target)
+	}
+	return targets, rows.Err()
+}
+
+
+func (r *WebhookRepository) GetDeliveryTarget(ctx context.Context, deliveryId int64) (*models.WebhookTargetModel, error) {
+	rows, err := r.DB.Query(ctx, webhookTargetQuery+` WHERE wd.id = $1`, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := scanTargets(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return targets[0], nil
+}
+
+
+// ClaimDueDeliveries locks up to limit due deliveries for the duration of tx,
+// so several dispatchers can run side by side.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, tx pgx.Tx, limit int) ([]*models.WebhookTargetModel, error) {
+	query := webhookTargetQuery + `
+		WHERE wd.status = 'pending' AND wd.next_attempt_at <= now()
+		ORDER BY wd.id
+		LIMIT $1
+		FOR UPDATE OF wd SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanTargets(rows)
+}
+
+
+// RecordAttempt stores the outcome of an attempt. retryIn is only used when
+// status stays pending.
+func (r *WebhookRepository) RecordAttempt(
+	ctx context.Context,
+	db execer,
+	deliveryId int64,
+	status string,
+	result models.WebhookResultModel,
+	retryIn time.Duration,
+) error {
+	var lastError *string
+	if result.Error != nil {
+		message := result.Error.Error()
+		lastError = &message
+	}
+	var responseCode *int
+	if result.ResponseCode != 0 {
+		responseCode = &result.ResponseCode
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2,
+			attempts = attempts + 1,
+			response_code = $3,
+			last_error = $4,
+			duration_ms = $5,
+			next_attempt_at = now() + $6 * interval '1 second',
+			delivered_at = CASE WHEN $2 = 'delivered' THEN now() END
+		WHERE id = $1
+	`
+	_, err := db.Exec(
+		ctx, query, deliveryId, status, responseCode, lastError,
+		int(result.Duration.Milliseconds()), int(retryIn.Seconds()),
+	)
+	return err
+}
+
+
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, ownerId int, webhookId int, limit int) ([]*models.WebhookDeliveryModel, error) {
+	query := `
+		SELECT
+			wd.id, wd.event_id, wd.event_type, wd.payload, wd.status, wd.attempts,
+			wd.response_code, wd.last_error, wd.duration_ms,
+			wd.next_attempt_at, wd.delivered_at, wd.created_at
+		FROM webhook_deliveries wd
+		JOIN webhooks w ON w.id = wd.webhook_id
+		WHERE wd.webhook_id = $1 AND w.owner_id = $2
+		ORDER BY wd.id DESC
+		LIMIT $3
+	`
+	rows, err := r.DB.Query(ctx, query, webhookId, ownerId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDeliveryModel, 0)
+	for rows.Next() {
+		var delivery models.WebhookDeliveryModel
+		err := rows.Scan(
+			&delivery.Id, &delivery.EventId, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+			&delivery.ResponseCode, &delivery.LastError, &delivery.DurationMs,
+			&delivery.NextAttempt, &delivery.DeliveredAt, &delivery.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// ErrLastWorkspaceOwner is returned when a change would leave a workspace
+// without an owner.
+var ErrLastWorkspaceOwner = errors.New("workspace must keep at least one owner")
+
+
+type WorkspaceRepository struct {
+	DB *pgxpool.Pool
+}
+
+
+func writeWorkspaceAudit(
+	ctx context.Context,
+	db execer,
+	action string,
+	workspaceId int,
+	actorId int,
+	before any,
+	after any,
+) error {
+	beforeJSON, afterJSON, err := auditValues(before, after)
+	if err != nil {
+		return err
+	}
+	return writeAudit(ctx, db, models.AuditEntryModel{
+		ActorId:    &actorId,
+		Action:     action,
+		TargetType: "workspace",
+		TargetId:   strconv.Itoa(workspaceId),
+		Before:     beforeJSON,
+		After:      afterJSON,
+	})
+}
+
+
+// CreateWorkspace creates a workspace with userId as its first owner.
+func (r *WorkspaceRepository) CreateWorkspace(ctx context.Context, userId int, name string) (*models.WorkspaceModel, error) {
+	workspace := models.WorkspaceModel{Role: utils.RoleOwner}
+
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO workspaces (name, created_by)
+		VALUES ($1, $2)
+		RETURNING id, name, created_at
+	`
+	if err := tx.QueryRow(ctx, query, name, userId).Scan(&workspace.Id, &workspace.Name, &workspace.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	query = `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(ctx, query, workspace.Id, userId, utils.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	after := map[string]string{"name": workspace.Name}
+	if err := writeWorkspaceAudit(ctx, tx, utils.AuditWorkspaceCreate, workspace.Id, userId, nil, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+
+const workspaceSelect = `
+	SELECT w.id, w.name, wm.role, w.created_at
+	FROM workspaces w
+	JOIN workspace_members wm ON wm.workspace_id = w.id
+`
+
+
+// GetWorkspace returns the workspace with the role of userId in it, or
+// pgx.ErrNoRows when they are not a member.
+func (r *WorkspaceRepository) GetWorkspace(ctx context.Context, workspaceId int, userId int) (*models.WorkspaceModel, error) {
+	var workspace models.WorkspaceModel
+
+	query := workspaceSelect + ` WHERE w.id = $1 AND wm.user_id = $2`
+	err := r.DB.QueryRow(ctx, query, workspaceId, userId).Scan(
+		&workspace.Id, &workspace.Name, &workspace.Role, &workspace.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+
+func (r *WorkspaceRepository) GetUserWorkspaces(ctx context.Context, userId int) ([]*models.WorkspaceModel, error) {
+	query := workspaceSelect + ` WHERE wm.user_id = $1 ORDER BY w.name, w.id`
+	rows, err := r.DB.Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := make([]*models.WorkspaceModel, 0)
+	for rows.Next() {
+		var workspace models.WorkspaceModel
+		if err := rows.Scan(&workspace.Id, &workspace.Name, &workspace.Role, &workspace.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, &workspace)
+	}
+	return workspaces, rows.Err()
+}
+
+
+func (r *WorkspaceRepository) GetMembers(ctx context.Context, workspaceId int) ([]*models.WorkspaceMemberModel, error) {
+	query := `
+		SELECT u.id, u.username, u.email, wm.role, wm.created_at
+		FROM workspace_members wm
+		JOIN users u ON u.id = wm.user_id
+		WHERE wm.workspace_id = $1
+		ORDER BY wm.created_at, u.id
+	`
+	rows, err := r.DB.Query(ctx, query, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]*models.WorkspaceMemberModel, 0)
+	for rows.Next() {
+		var member models.WorkspaceMemberModel
+		err := rows.Scan(
+			&member.User.Id, &member.User.Username, &member.User.Email, &member.Role, &member.JoinedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+	return members, rows.Err()
+}
+
+
+// AddMember adds userId with role after they redeemed an invite from
+// inviterId. Existing members keep their current role.
+func (r *WorkspaceRepository) AddMember(ctx context.Context, workspaceId int, userId int, role string, inviterId int) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO workspace_members (workspace_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+	rows, err := tx.Exec(ctx, query, workspaceId, userId, role)
+	if err != nil {
+		return err
+	}
+	if rows.RowsAffected() == 0 {
+		return tx.Commit(ctx)
+	}
+
+	after := map[string]any{"user_id": userId, "role": role, "invited_by": inviterId}
+	if err := writeWorkspaceAudit(ctx, tx, utils.AuditWorkspaceInviteAccept, workspaceId, userId, nil, after); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+
+// lockMember locks the membership of userId together with every owner row of
+// the workspace, so concurrent demotions cannot remove the last owner.
+func lockMember(ctx context.Context, tx pgx.Tx, workspaceId int, userId int) (string, int, error) {
+	query := `
+		SELECT user_id, role
+		FROM workspace_members
+		WHERE workspace_id = $1 AND (user_id = $2 OR role = $3)
+		FOR UPDATE
+	`
+	rows, err := tx.Query(ctx, query, workspaceId, userId, utils.RoleOwner)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+
+	role, owners := "", 0
+	for rows.Next() {
+		var memberId int
+		var memberRole string
+		if err := rows.Scan(&memberId, &memberRole); err != nil {
+			return "", 0, err
+		}
+		if memberId == userId {
+			role = memberRole
+		}
+		if memberRole == utils.RoleOwner {
+			owners++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", 0, err
+	}
+	if role == "" {
+		return "", 0, pgx.ErrNoRows
+	}
+	return role, owners, nil
+}
+
+
+func (r *WorkspaceRepository) UpdateMemberRole(ctx context.Context, workspaceId int, userId int, role string, actorId int) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	previous, owners, err := lockMember(ctx, tx, workspaceId, userId)
+	if err != nil {
+		return err
+	}
+	if previous == utils.RoleOwner && role != utils.RoleOwner && owners <= 1 {
+		return ErrLastWorkspaceOwner
+	}
+
+	query := `UPDATE workspace_members SET role = $3 WHERE workspace_id = $1 AND user_id = $2`
+	if _, err := tx.Exec(ctx, query, workspaceId, userId, role); err != nil {
+		return err
+	}
+
+	err = writeWorkspaceAudit(
+		ctx, tx, utils.AuditWorkspaceMemberRole, workspaceId, actorId,
+		map[string]any{"user_id": userId, "role": previous}, map[string]any{"user_id": userId, "role": role},
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceId int, userId int, actorId int) error {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	previous, owners, err := lockMember(ctx, tx, workspaceId, userId)
+	if err != nil {
+		return err
+	}
+	if previous == utils.RoleOwner && owners <= 1 {
+		return ErrLastWorkspaceOwner
+	}
+
+	query := `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+	if _, err := tx.Exec(ctx, query, workspaceId, userId); err != nil {
+		return err
+	}
+
+	before := map[string]any{"user_id": userId, "role": previous}
+	if err := writeWorkspaceAudit(ctx, tx, utils.AuditWorkspaceMemberRemove, workspaceId, actorId, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+type ActivityService struct {
+	DB         *pgxpool.Pool
+	Repository *repositories.ActivityRepository
+}
+
+
+func (s *ActivityService) GetDocumentActivity(
+	ctx context.Context,
+	documentId int,
+	limit int,
+	offset int,
+) ([]*models.ActivityModel, *apierrors.APIError) {
+	feed, err := s.Repository.GetDocumentActivity(ctx, documentId, limit, offset)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "activity")
+	}
+	return feed, nil
+}
+
+
+// Listen calls push for every feed entry announced on the Postgres channel
+// until ctx is done, reconnecting after errors. Bumped edit bursts are pushed
+// again with the same id, so clients replace entries they already show.
+func (s *ActivityService) Listen(ctx context.Context, push func(activity *models.ActivityModel)) {
+	for {
+		if err := s.listen(ctx, push); err != nil {
+			log.Printf("Activity listener stopped: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+
+func (s *ActivityService) listen(ctx context.Context, push func(activity *models.ActivityModel)) error {
+	pooled, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// LISTEN state is tied to the connection, so it must not go back to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+repositories.ActivityChannel); err != nil {
+		return err
+	}
+
+	for {
+		message, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var target struct {
+			Id int64 `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(message.Payload), &target); err != nil {
+			continue
+		}
+
+		activity, err := s.Repository.GetActivity(ctx, target.Id)
+		if err != nil {
+			log.Printf("Failed to load activity %d: %v", target.Id, err)
+			continue
+		}
+		push(activity)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"golang/internal/core/authz"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"io"
+	"log"
+	"strings"
+)
+
+
+type ApiKeyService struct {
+	Repository *repositories.ApiKeyRepository
+}
+
+
+// maxPrefixAttempts bounds the retries after a prefix collision.
+const maxPrefixAttempts = 3
+
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+
+func (s *ApiKeyService) CreateApiKey(
+	ctx context.Context,
+	userId int,
+	form io.ReadCloser,
+) (*models.CreatedApiKeyModel, *apierrors.APIError) {
+	var apiKeyForm models.CreateApiKeyModel
+
+	if err := json.NewDecoder(form).Decode(&apiKeyForm); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(apiKeyForm); err != nil {
+		return nil, err
+	}
+
+	// dk_<prefix>_<secret>: the prefix is stored in clear for lookup and
+	// display, the whole key only as a SHA-256 hash. The prefix is hex so it
+	// never contains the "_" separator; prefixes are unique, so a collision
+	// just draws another one.
+	for attempt := 1; ; attempt++ {
+		prefix := utils.RandomHex(6)
+		key := utils.ApiKeyPrefix + "_" + prefix + "_" + utils.RandomToken(32)
+
+		apiKey, err := s.Repository.CreateApiKey(ctx, userId, apiKeyForm, prefix, hashApiKey(key))
+		if err != nil {
+			if apierrors.IsUniqueViolation(err) && attempt < maxPrefixAttempts {
+				continue
+			}
+			return nil, apierrors.CheckDBError(err, "api key")
+		}
+		return &models.CreatedApiKeyModel{ApiKeyModel: *apiKey, Key: key}, nil
+	}
+}
+
+
+func (s *ApiKeyService) GetUserApiKeys(ctx context.Context, userId int) ([]*models.ApiKeyModel, *apierrors.APIError) {
+	apiKeys, err := s.Repository.GetUserApiKeys(ctx, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "api key")
+	}
+	return apiKeys, nil
+}
+
+
+func (s *ApiKeyService) RevokeApiKey(ctx context.Context, userId int, apiKeyId int) *apierrors.APIError {
+	if err := s.Repository.RevokeApiKey(ctx, userId, apiKeyId); err != nil {
+		return apierrors.CheckDBError(err, "api key")
+	}
+	return nil
+}
+
+
+func (s *ApiKeyService) ValidateApiKey(ctx context.Context, key string) (*authz.Principal, *apierrors.APIError) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != utils.ApiKeyPrefix {
+		return nil, &apierrors.ErrInvalidApiKey
+	}
+
+	apiKey, err := s.Repository.GetApiKeyByPrefix(ctx, parts[1])
+	if err != nil {
+		return nil, &apierrors.ErrInvalidApiKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashApiKey(key))) != 1 || apiKey.RevokedAt != nil {
+		return nil, &apierrors.ErrInvalidApiKey
+	}
+
+	if err := s.Repository.TouchApiKey(ctx, apiKey.Id); err != nil {
+		log.Printf("Failed to record api key usage: %v", err)
+	}
+	return &authz.Principal{User: &apiKey.User, Kind: authz.PrincipalApiKey, Scopes: apiKey.Scopes}, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+)
+
+
+const maxAttachmentName = 255
+
+
+type AttachmentService struct {
+	Repository         *repositories.AttachmentRepository
+	DocumentRepository *repositories.DocumentRepository
+	Store              clients.BlobStore
+	// MaxSize caps a single upload in bytes; Types are the sniffed MIME
+	// types accepted.
+	MaxSize int64
+	Types   []string
+}
+
+
+// sniffContentType detects the type of body from its first bytes, without
+// parameters such as the charset.
+func sniffContentType(body []byte) string {
+	detected := http.DetectContentType(body)
+	if mediaType, _, err := mime.ParseMediaType(detected); err == nil {
+		return mediaType
+	}
+	return detected
+}
+
+
+// attachmentName keeps the base name of an uploaded file, without control
+// characters and at most maxAttachmentName runes long.
+func attachmentName(filename string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, path.Base(strings.ReplaceAll(filename, "\\", "/")))
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if runes := []rune(name); len(runes) > maxAttachmentName {
+		name = string(runes[:maxAttachmentName])
+	}
+	return name
+}
+
+
+// UploadAttachment stores a file for a document the user may edit. Its type
+// is sniffed from the content; the name and type the client sent are not
+// trusted.
+func (s *AttachmentService) UploadAttachment(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	filename string,
+	file io.Reader,
+) (*models.AttachmentModel, *apierrors.APIError) {
+	canEdit, err := s.DocumentRepository.CheckDocumentRole(ctx, userId, documentId, utils.RoleOwner, utils.RoleEditor)
+	if err != nil || !canEdit {
+		return nil, &apierrors.ErrDocumentAccessDenied
+	}
+
+	body, err := io.ReadAll(io.LimitReader(file, s.MaxSize+1))
+	if err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+	if int64(len(body)) > s.MaxSize {
+		return nil, &apierrors.ErrAttachmentTooLarge
+	}
+	if len(body) == 0 {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	contentType := sniffContentType(body)
+	if !slices.Contains(s.Types, contentType) {
+		return nil, &apierrors.ErrAttachmentType
+	}
+
+	key := fmt.Sprintf("documents/%d/%s", documentId, utils.RandomToken(18))
+	if err := s.Store.Put(ctx, key, body, contentType); err != nil {
+		log.Printf("failed to store attachment of document %d: %v", documentId, err)
+		return nil, &apierrors.ErrInternalServerError
+	}
+
+	attachment, err := s.Repository.CreateAttachment(ctx, userId, models.AttachmentModel{
+		DocumentId:  documentId,
+		Filename:    attachmentName(filename),
+		ContentType: contentType,
+		Size:        int64(len(body)),
+		StorageKey:  key,
+	})
+	if err != nil {
+		if deleteErr := s.Store.Delete(ctx, key); deleteErr != nil {
+			log.Printf("failed to remove unrecorded blob %s: %v", key, deleteErr)
+		}
+		if err == pgx.ErrNoRows {
+			return nil, &apierrors.ErrDocumentAccessDenied
+		}
+		return nil, apierrors.CheckDBError(err, "attachment")
+	}
+	return attachment, nil
+}
+
+
+func (s *AttachmentService) GetAttachments(
+	ctx context.Context,
+	userId int,
+	documentId int,
+) ([]*models.AttachmentModel, *apierrors.APIError) {
+	if hasAccess, err := s.DocumentRepository.CheckDocumentAccess(ctx, userId, documentId); err != nil || !hasAccess {
+		return nil, &apierrors.ErrDocumentAccessDenied
+	}
+
+	attachments, err := s.Repository.GetAttachments(ctx, documentId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "attachment")
+	}
+	return attachments, nil
+}
+
+
+// OpenAttachment returns an attachment of a document the user can open
+// together with its content, which the caller must close.
+func (s *AttachmentService) OpenAttachment(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	attachmentId int,
+) (*models.AttachmentModel, io.ReadCloser, *apierrors.APIError) {
+	if hasAccess, err := s.DocumentRepository.CheckDocumentAccess(ctx, userId, documentId); err != nil || !hasAccess {
+		return nil, nil, &apierrors.ErrDocumentAccessDenied
+	}
+
+	attachment, err := s.Repository.GetAttachment(ctx, documentId, attachmentId)
+	if err != nil {
+		return nil, nil, apierrors.CheckDBError(err, "attachment")
+	}
+
+	body, err := s.Store.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, clients.ErrBlobNotFound) {
+		return nil, nil, apierrors.ErrItemNotFound("attachment")
+	}
+	if err != nil {
+		log.Printf("failed to read attachment %d: %v", attachmentId, err)
+		return nil, nil, &apierrors.ErrInternalServerError
+	}
+	return attachment, body, nil
+}
+
+
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, userId int, documentId int, attachmentId int) *apierrors.APIError {
+	canEdit, err := s.DocumentRepository.CheckDocumentRole(ctx, userId, documentId, utils.RoleOwner, utils.RoleEditor)
+	if err != nil || !canEdit {
+		return &apierrors.ErrDocumentAccessDenied
+	}
+
+	deleted, err := s.Repository.DeleteAttachment(ctx, documentId, attachmentId, userId)
+	if err != nil {
+		return apierrors.CheckDBError(err, "attachment")
+	}
+	if !deleted {
+		return apierrors.ErrItemNotFound("attachment")
+	}
+	return nil
+}
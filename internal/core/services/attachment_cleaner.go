@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/config"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// AttachmentCleaner removes the blobs of deleted attachments from storage.
+// Deletions are queued in the transaction that removes the rows, so a blob
+// is only removed once nothing can refer to it any more. Failed deletions
+// stay queued and are retried on the next run.
+type AttachmentCleaner struct {
+	DB           *pgxpool.Pool
+	Repository   *repositories.AttachmentRepository
+	Store        clients.BlobStore
+	BatchSize    int
+	PollInterval time.Duration
+}
+
+
+func NewAttachmentCleaner(db *pgxpool.Pool, store clients.BlobStore, cfg *config.StorageConfig) *AttachmentCleaner {
+	return &AttachmentCleaner{
+		DB:           db,
+		Repository:   &repositories.AttachmentRepository{DB: db},
+		Store:        store,
+		BatchSize:    cfg.CleanupBatch,
+		PollInterval: cfg.CleanupInterval,
+	}
+}
+
+
+func (c *AttachmentCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		cleaned, err := c.cleanBatch(ctx)
+		if err != nil {
+			log.Printf("Attachment cleanup error: %v", err)
+		}
+		if cleaned >= c.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+
+func (c *AttachmentCleaner) cleanBatch(ctx context.Context) (int, error) {
+	tx, err := c.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	deletions, err := c.Repository.ClaimDeletions(ctx, tx, c.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	done := make([]int, 0, len(deletions))
+	for _, deletion := range deletions {
+		if err := c.Store.Delete(ctx, deletion.StorageKey); err != nil {
+			log.Printf("Failed to delete blob %s: %v", deletion.StorageKey, err)
+			continue
+		}
+		done = append(done, deletion.Id)
+	}
+	if len(done) == 0 {
+		return 0, nil
+	}
+
+	if err := c.Repository.CompleteDeletions(ctx, tx, done); err != nil {
+		return 0, err
+	}
+	return len(done), tx.Commit(ctx)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+)
+
+
+const maxAuditPage = 100
+
+
+type AuditService struct {
+	Repository         *repositories.AuditRepository
+	DocumentRepository *repositories.DocumentRepository
+	UserRepository     *repositories.UserRepository
+}
+
+
+// GetDocumentAudit returns the audit trail of a document to its owner.
+func (s *AuditService) GetDocumentAudit(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	action string,
+	limit int,
+	offset int,
+) ([]*models.AuditEntryModel, *apierrors.APIError) {
+	if isOwner, err := s.DocumentRepository.CheckIsOwner(ctx, documentId, userId); err != nil || !isOwner {
+		return nil, &apierrors.ErrDocumentAccessDenied
+	}
+
+	filter := models.AuditFilterModel{DocumentId: &documentId, Action: action}
+	entries, err := s.Repository.GetAuditEntries(ctx, filter, min(limit, maxAuditPage), max(offset, 0))
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "audit entry")
+	}
+	return entries, nil
+}
+
+
+func (s *AuditService) CheckAdmin(ctx context.Context, userId int) *apierrors.APIError {
+	isAdmin, err := s.UserRepository.IsAdmin(ctx, userId)
+	if err != nil {
+		return apierrors.CheckDBError(err, "user")
+	}
+	if !isAdmin {
+		return &apierrors.ErrAdminRequired
+	}
+	return nil
+}
+
+
+// ExportAudit streams every entry matching filter to fn in insertion order.
+// Callers check CheckAdmin first; once streaming has started errors can no
+// longer be turned into an HTTP status.
+func (s *AuditService) ExportAudit(
+	ctx context.Context,
+	filter models.AuditFilterModel,
+	fn func(*models.AuditEntryModel) error,
+) error {
+	return s.Repository.StreamAuditEntries(ctx, filter, fn)
+}
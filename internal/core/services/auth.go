@@ -3,13 +3,17 @@ package services
 import (
     "context"
     "encoding/json"
+    "golang/internal/core/authz"
     "golang/internal/core/repositories"
+    "golang/internal/infrastructure/clients"
     "golang/internal/infrastructure/config"
     "golang/internal/infrastructure/database/models"
     "golang/internal/infrastructure/errors"
+    "golang/internal/infrastructure/jwk"
     "golang/internal/utils"
     "io"
     "log"
+    "strings"
     "time"
 
     "github.com/golang-jwt/jwt/v5"
@@ -19,8 +23,29 @@ import (
 
 
 type AuthService struct {
-    Config     *config.JwtConfig
-    Repository *repositories.UserRepository
+    Config      *config.JwtConfig
+    Repository  *repositories.UserRepository
+    OidcClients map[string]*clients.OidcClient
+    OidcStates  *OidcStateStore
+    Guard       *LoginGuard
+    Audit       *repositories.AuditRepository
+}
+
+
+// audit records an authentication event. Failures are logged only: an
+// unavailable audit table must not lock everybody out.
+func (s *AuthService) audit(ctx context.Context, action string, actorId *int, targetId string, ip string, userAgent string) {
+    entry := models.AuditEntryModel{
+        ActorId:    actorId,
+        Action:     action,
+        Ip:         ip,
+        UserAgent:  userAgent,
+        TargetType: "account",
+        TargetId:   targetId,
+    }
+    if err := s.Audit.CreateAuditEntry(ctx, entry); err != nil {
+        log.Printf("Failed to write %s audit entry: %v", action, err)
+    }
 }
 
 
@@ -108,6 +133,11 @@ func (s *AuthService) createTokenPair(userId int) (*models.TokenPair, *apierrors
 
 
 func (s *AuthService) createToken(userId int, tokenType string) (string, *apierrors.APIError) {
+    return s.createScopedToken(userId, tokenType, authz.SessionScopes)
+}
+
+
+func (s *AuthService) createScopedToken(userId int, tokenType string, scopes []string) (string, *apierrors.APIError) {
     var expiresAt time.Duration
 
     switch tokenType {
@@ -117,12 +147,34 @@ func (s *AuthService) createToken(userId int, tokenType string) (string, *apierr
         expiresAt = s.Config.RefreshTokenTime
     }
 
-    token := jwt.NewWithClaims(s.Config.SigningMethod, jwt.MapClaims{
+    claims := jwt.MapClaims{
         "sub": userId,
+        "typ": tokenType,
         "exp": time.Now().Add(expiresAt).Unix(),
-    })
+        "scope": strings.Join(scopes, " "),
+    }
 
-    tokenString, err := token.SignedString([]byte(s.Config.Secret))
+    var token *jwt.Token
+    var signingKey any
+
+    if s.Config.IsSymmetric() {
+        token = jwt.NewWithClaims(s.Config.SigningMethod, claims)
+        signingKey = []byte(s.Config.Secret)
+    } else {
+        var active *jwk.SigningKey
+        if s.Config.Keys != nil {
+            active = s.Config.Keys.Active()
+        }
+        if active == nil {
+            log.Printf("[INTERNAL] No signing key configured for %s", s.Config.SigningMethod.Alg())
+            return "", &apierrors.ErrInternalServerError
+        }
+        token = jwt.NewWithClaims(active.Method, claims)
+        token.Header["kid"] = active.Kid
+        signingKey = active.Private
+    }
+
+    tokenString, err := token.SignedString(signingKey)
     if err != nil {
         log.Printf("[INTERNAL] Failed to sign JWT token: %v", err)
         return "", &apierrors.ErrInternalServerError
@@ -132,16 +184,30 @@ func (s *AuthService) createToken(userId int, tokenType string) (string, *apierr
 
 
 func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*models.BaseUserModel, *apierrors.APIError) {
+    principal, err := s.Authenticate(ctx, tokenString)
+    if err != nil {
+        return nil, err
+    }
+    return principal.User, nil
+}
+
+
+// Authenticate validates an access token and returns the user together with
+// the scopes from its "scope" claim. Tokens issued before scopes existed get
+// the default session scopes.
+func (s *AuthService) Authenticate(ctx context.Context, tokenString string) (*authz.Principal, *apierrors.APIError) {
+    return s.authenticate(ctx, tokenString, utils.AccessToken)
+}
+
+
+// authenticate validates a JWT whose "typ" claim is tokenType, so a refresh
+// token is not accepted as an access token or the other way round.
+func (s *AuthService) authenticate(ctx context.Context, tokenString string, tokenType string) (*authz.Principal, *apierrors.APIError) {
     if tokenString == "" {
         return nil, &apierrors.ErrInvalidToken
     }
 
-    token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-        if token.Method != s.Config.SigningMethod {
-            return nil, &apierrors.ErrInvalidToken
-        }
-        return []byte(s.Config.Secret), nil
-    })
+    token, err := jwt.Parse(tokenString, s.verificationKey)
 
     if err != nil {
         log.Printf("Failed to parse token: %v", err)
@@ -149,6 +215,10 @@ func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*m
     }
 
     if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+        if typ, _ := claims["typ"].(string); typ != tokenType {
+            return nil, &apierrors.ErrInvalidToken
+        }
+
         userID, ok := claims["sub"].(float64)
         if !ok {
             return nil, &apierrors.ErrInvalidToken
@@ -158,23 +228,58 @@ func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*m
         if err != nil {
             return nil, apierrors.CheckDBError(err, "user")
         }
-        return user, nil
+
+        scopes := authz.SessionScopes
+        if scope, ok := claims["scope"].(string); ok {
+            scopes = strings.Fields(scope)
+        }
+        return &authz.Principal{User: user, Kind: authz.PrincipalSession, Scopes: scopes}, nil
     }
 
     return nil, &apierrors.ErrInvalidToken
 }
 
 
+// verificationKey picks the key for a token being validated: the shared secret
+// for HMAC setups, otherwise the key ring entry named by the kid header, which
+// still resolves for rotated keys until they retire.
+func (s *AuthService) verificationKey(token *jwt.Token) (any, error) {
+    if s.Config.IsSymmetric() {
+        if token.Method != s.Config.SigningMethod {
+            return nil, &apierrors.ErrInvalidToken
+        }
+        return []byte(s.Config.Secret), nil
+    }
+
+    kid, _ := token.Header["kid"].(string)
+    key, ok := s.Config.Keys.Lookup(kid)
+    if !ok || token.Method.Alg() != key.Method.Alg() {
+        return nil, &apierrors.ErrInvalidToken
+    }
+    return key.Public(), nil
+}
+
+
+func (s *AuthService) JWKS() jwk.Set {
+    if s.Config.Keys == nil {
+        return jwk.Set{Keys: []jwk.Key{}}
+    }
+    return s.Config.Keys.PublicSet()
+}
+
+
 func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*models.AuthResponseModel, *apierrors.APIError) {
-    user, err := s.ValidateToken(ctx, refreshToken)
+    principal, err := s.authenticate(ctx, refreshToken, utils.RefreshToken)
     if err != nil {
         return nil, err
     }
+    user := principal.User
 
-    accessToken, err := s.createToken(user.Id, utils.AccessToken)
+    accessToken, err := s.createScopedToken(user.Id, utils.AccessToken, principal.Scopes)
     if err != nil {
         return nil, err
     }
+    s.audit(ctx, utils.AuditTokenRefresh, &user.Id, user.Email, "", "")
 
     return &models.AuthResponseModel{
         TokenPair: models.TokenPair{
@@ -186,7 +291,12 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 }
 
 
-func (s *AuthService) LoginUser(ctx context.Context, userForm io.ReadCloser) (*models.AuthResponseModel, *apierrors.APIError) {
+func (s *AuthService) LoginUser(
+    ctx context.Context,
+    userForm io.ReadCloser,
+    ip string,
+    userAgent string,
+) (*models.AuthResponseModel, *apierrors.APIError) {
     var userFormEncoded models.LoginUserModel
     err := json.NewDecoder(userForm).Decode(&userFormEncoded)
     if err != nil {
@@ -197,15 +307,30 @@ func (s *AuthService) LoginUser(ctx context.Context, userForm io.ReadCloser) (*m
         return nil, err
     }
 
-    user, err := s.Repository.GetUserByEmail(ctx, userFormEncoded.Email)
+    // Throttling, the audit log and the account lookup all use the same
+    // normalized address.
+    email := strings.ToLower(strings.TrimSpace(userFormEncoded.Email))
+    if lockErr := s.Guard.Check(ctx, email, ip); lockErr != nil {
+        return nil, lockErr
+    }
+
+    user, err := s.Repository.GetUserByEmail(ctx, email)
     if err != nil { 
+        if err == pgx.ErrNoRows {
+            s.Guard.RegisterFailure(ctx, email, ip, userAgent, nil)
+            s.audit(ctx, utils.AuditLoginFailure, nil, email, ip, userAgent)
+        }
         return nil, apierrors.CheckDBError(err, "user")
     }
 
     passErr := s.CheckPassword(userFormEncoded.Password, user.Password)
     if passErr != nil {
+        s.Guard.RegisterFailure(ctx, email, ip, userAgent, user)
+        s.audit(ctx, utils.AuditLoginFailure, &user.Id, email, ip, userAgent)
         return nil, passErr
     }
+    s.Guard.RegisterSuccess(ctx, email)
+    s.audit(ctx, utils.AuditLoginSuccess, &user.Id, email, ip, userAgent)
 
     tokenPair, tokenPairErr := s.createTokenPair(user.Id)
     if tokenPairErr != nil {
@@ -220,4 +345,38 @@ func (s *AuthService) LoginUser(ctx context.Context, userForm io.ReadCloser) (*m
             Username: user.Username,
         },
     }, nil
-}
\ No newline at end of file
+}
+
+
+// ChangePassword replaces the password after re-checking the current one.
+// Accounts created through an identity provider have no usable password and
+// cannot change it this way.
+func (s *AuthService) ChangePassword(ctx context.Context, userId int, passwordForm io.ReadCloser) *apierrors.APIError {
+    var form models.ChangePasswordModel
+    if err := json.NewDecoder(passwordForm).Decode(&form); err != nil {
+        return &apierrors.ErrInvalidRequestBody
+    }
+
+    if err := utils.ValidateForm(form); err != nil {
+        return err
+    }
+
+    current, err := s.Repository.GetPasswordById(ctx, userId)
+    if err != nil {
+        return apierrors.CheckDBError(err, "user")
+    }
+
+    if passErr := s.CheckPassword(form.CurrentPassword, current); passErr != nil {
+        return passErr
+    }
+
+    hashedPassword, hashErr := s.HashPassword(form.NewPassword)
+    if hashErr != nil {
+        return hashErr
+    }
+
+    if err := s.Repository.UpdatePassword(ctx, userId, hashedPassword); err != nil {
+        return apierrors.CheckDBError(err, "user")
+    }
+    return nil
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+
+type oidcLoginState struct {
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+
+// OidcStateStore keeps the state -> (nonce, PKCE verifier) pairs between the
+// redirect to the provider and the callback. Entries are single use.
+type OidcStateStore struct {
+	TTL    time.Duration
+	mutex  sync.Mutex
+	states map[string]oidcLoginState
+}
+
+
+func NewOidcStateStore(ttl time.Duration) *OidcStateStore {
+	return &OidcStateStore{TTL: ttl, states: make(map[string]oidcLoginState)}
+}
+
+
+func (store *OidcStateStore) Put(state string, value oidcLoginState) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	for key, existing := range store.states {
+		if now.After(existing.ExpiresAt) {
+			delete(store.states, key)
+		}
+	}
+	value.ExpiresAt = now.Add(store.TTL)
+	store.states[state] = value
+}
+
+
+func (store *OidcStateStore) Take(state string) (oidcLoginState, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	value, ok := store.states[state]
+	delete(store.states, state)
+	if !ok || time.Now().After(value.ExpiresAt) {
+		return oidcLoginState{}, false
+	}
+	return value, true
+}
+
+
+func (s *AuthService) StartOidcLogin(ctx context.Context, provider string) (string, *apierrors.APIError) {
+	client, ok := s.OidcClients[provider]
+	if !ok {
+		return "", &apierrors.ErrOidcProviderNotFound
+	}
+
+	state := utils.RandomToken(24)
+	nonce := utils.RandomToken(24)
+	verifier := utils.RandomToken(32)
+	challenge := sha256.Sum256([]byte(verifier))
+
+	redirectUrl, err := client.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		log.Printf("[INTERNAL] OIDC provider %s unavailable: %v", provider, err)
+		return "", &apierrors.ErrInternalServerError
+	}
+
+	s.OidcStates.Put(state, oidcLoginState{Provider: provider, Nonce: nonce, CodeVerifier: verifier})
+	return redirectUrl, nil
+}
+
+
+func (s *AuthService) FinishOidcLogin(
+	ctx context.Context,
+	provider string,
+	state string,
+	code string,
+) (*models.AuthResponseModel, *apierrors.APIError) {
+	client, ok := s.OidcClients[provider]
+	if !ok {
+		return nil, &apierrors.ErrOidcProviderNotFound
+	}
+
+	loginState, ok := s.OidcStates.Take(state)
+	if !ok || loginState.Provider != provider || code == "" {
+		return nil, &apierrors.ErrOidcLoginFailed
+	}
+
+	tokens, err := client.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC code exchange with %s failed: %v", provider, err)
+		return nil, &apierrors.ErrOidcLoginFailed
+	}
+
+	claims, err := client.VerifyIdToken(ctx, tokens.IdToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("OIDC id_token from %s rejected: %v", provider, err)
+		return nil, &apierrors.ErrOidcLoginFailed
+	}
+
+	user, apiErr := s.linkOidcIdentity(ctx, provider, claims.Subject, claims.Email, claims.EmailVerified, claims.Name)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	tokenPair, apiErr := s.createTokenPair(user.Id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	s.audit(ctx, utils.AuditLoginSuccess, &user.Id, strings.ToLower(user.Email), "", "")
+
+	return &models.AuthResponseModel{
+		TokenPair: *tokenPair,
+		User:      *user,
+	}, nil
+}
+
+
+// linkOidcIdentity resolves the local account for an external subject. Known
+// subjects log straight in; otherwise the account is matched by email, which
+// is only trusted when the provider marks it as verified.
+func (s *AuthService) linkOidcIdentity(
+	ctx context.Context,
+	provider string,
+	subject string,
+	email string,
+	emailVerified bool,
+	name string,
+) (*models.BaseUserModel, *apierrors.APIError) {
+	user, err := s.Repository.GetUserByIdentity(ctx, provider, subject)
+	if err == nil {
+		return user, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, apierrors.CheckDBError(err, "user")
+	}
+
+	if email == "" || !emailVerified {
+		return nil, &apierrors.ErrOidcEmailNotVerified
+	}
+
+	existing, err := s.Repository.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		user = &existing.BaseUserModel
+	case err == pgx.ErrNoRows:
+		hashedPassword, hashErr := s.HashPassword(utils.RandomToken(32))
+		if hashErr != nil {
+			return nil, hashErr
+		}
+
+		var form models.RegisterUserModel
+		form.Username = oidcUsername(name, email)
+		form.Email = email
+		form.Password = hashedPassword
+
+		user, err = s.Repository.CreateUser(ctx, form)
+		if err != nil {
+			return nil, apierrors.CheckDBError(err, "user")
+		}
+	default:
+		return nil, apierrors.CheckDBError(err, "user")
+	}
+
+	err = s.Repository.CreateUserIdentity(ctx, models.UserIdentityModel{
+		UserId:   user.Id,
+		Provider: provider,
+		Subject:  subject,
+		Email:    email,
+	})
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "user")
+	}
+	return user, nil
+}
+
+
+func oidcUsername(name string, email string) string {
+	username := []rune(strings.TrimSpace(name))
+	if len(username) < 3 {
+		local, _, _ := strings.Cut(email, "@")
+		username = []rune(local)
+	}
+	if len(username) > 20 {
+		username = username[:20]
+	}
+	for len(username) < 3 {
+		username = append(username, '_')
+	}
+	return string(username)
+}
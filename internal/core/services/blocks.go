@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang/internal/core/content"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"io"
+	"log"
+	"time"
+)
+
+
+func mapBlocksError(err error) *apierrors.APIError {
+	var blockErr *content.BlockError
+	switch {
+	case errors.As(err, &blockErr):
+		return apierrors.NewContentError(blockErr.Path, blockErr.Reason)
+	case errors.Is(err, content.ErrBlockNotFound):
+		return &apierrors.ErrBlockNotFound
+	case errors.Is(err, repositories.ErrDocumentChanged):
+		return &apierrors.ErrDocumentChanged
+	default:
+		return apierrors.CheckDBError(err, "document")
+	}
+}
+
+
+// GetBlocks returns the content of a document as a block tree. Content last
+// written as Markdown is converted and the result kept, so block ids stay the
+// same until the next Markdown write.
+func (s *DocumentService) GetBlocks(
+	ctx context.Context,
+	userId int,
+	documentId int,
+) (*models.DocumentBlocksModel, *apierrors.APIError) {
+	if err := s.CheckDocumentAccess(ctx, userId, documentId); err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.Repository.GetDocumentBlocks(ctx, documentId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	if blocks.Blocks != nil {
+		return blocks, nil
+	}
+
+	blocks.Blocks, err = json.Marshal(content.BlocksFromMarkdown(blocks.Content))
+	if err != nil {
+		return nil, &apierrors.ErrEncodingError
+	}
+	if err := s.Repository.StoreDerivedBlocks(ctx, documentId, blocks.Blocks, blocks.Revision); err != nil {
+		log.Printf("failed to store blocks of document %d: %v", documentId, err)
+	}
+	return blocks, nil
+}
+
+
+// ReplaceBlocks validates a whole block tree and stores it as the content
+// of the document.
+func (s *DocumentService) ReplaceBlocks(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	blocksForm io.ReadCloser,
+) (*models.DocumentBlocksModel, *apierrors.APIError) {
+	var form models.ReplaceBlocksModel
+	if err := json.NewDecoder(blocksForm).Decode(&form); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+	if err := utils.ValidateForm(form); err != nil {
+		return nil, err
+	}
+
+	doc, err := content.ParseDocument(form.Blocks)
+	if err != nil {
+		return nil, mapBlocksError(err)
+	}
+	return s.storeBlocks(ctx, userId, documentId, doc, form.Revision)
+}
+
+
+// PatchBlocks applies edit operations to the current block tree. Without a
+// revision the operations apply to whatever the document holds; they fail
+// when a block they address is gone.
+func (s *DocumentService) PatchBlocks(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	patchForm io.ReadCloser,
+) (*models.DocumentBlocksModel, *apierrors.APIError) {
+	var form models.PatchBlocksModel
+	if err := json.NewDecoder(patchForm).Decode(&form); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+	if err := utils.ValidateForm(form); err != nil {
+		return nil, err
+	}
+
+	operations := make([]content.Operation, 0, len(form.Operations))
+	for i, operation := range form.Operations {
+		var block *content.Block
+		if len(operation.Block) > 0 && string(operation.Block) != "null" {
+			parsed, err := content.ParseBlock(operation.Block)
+			if err != nil {
+				return nil, apierrors.NewContentError(fmt.Sprintf("operations[%d].block", i), err.Error())
+			}
+			block = parsed
+		}
+		operations = append(operations, content.Operation{
+			Op:       operation.Op,
+			Id:       operation.Id,
+			ParentId: operation.ParentId,
+			AfterId:  operation.AfterId,
+			Block:    block,
+		})
+	}
+
+	current, apiErr := s.GetBlocks(ctx, userId, documentId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if form.Revision != nil && !form.Revision.Equal(current.Revision) {
+		return nil, &apierrors.ErrDocumentChanged
+	}
+
+	doc, err := content.ParseDocument(current.Blocks)
+	if err != nil {
+		return nil, &apierrors.ErrInternalServerError
+	}
+	if err := doc.Apply(operations); err != nil {
+		return nil, mapBlocksError(err)
+	}
+	// The tree was read at current.Revision; storing against it keeps a
+	// concurrent write from being overwritten.
+	return s.storeBlocks(ctx, userId, documentId, doc, &current.Revision)
+}
+
+
+func (s *DocumentService) storeBlocks(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	doc *content.Block,
+	revision *time.Time,
+) (*models.DocumentBlocksModel, *apierrors.APIError) {
+	blocks, err := json.Marshal(doc)
+	if err != nil {
+		return nil, &apierrors.ErrEncodingError
+	}
+
+	stored, err := s.Repository.UpdateDocumentBlocks(ctx, userId, documentId, blocks, doc.Markdown(), revision)
+	if err != nil {
+		return nil, mapBlocksError(err)
+	}
+	return stored, nil
+}
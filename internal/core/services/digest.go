@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/database/models"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// DigestService periodically mails users a summary of activity on their
+// documents. Users are claimed in batches, so several API instances can run
+// it at once; a digest with nothing to report is skipped.
+type DigestService struct {
+	Repository   *repositories.DigestRepository
+	Mail         *clients.MailQueue
+	BatchSize    int
+	PollInterval time.Duration
+}
+
+
+func NewDigestService(db *pgxpool.Pool, mail *clients.MailQueue) *DigestService {
+	return &DigestService{
+		Repository:   &repositories.DigestRepository{DB: db},
+		Mail:         mail,
+		BatchSize:    50,
+		PollInterval: 10 * time.Minute,
+	}
+}
+
+
+func (s *DigestService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		claimed, err := s.SendDue(ctx)
+		if err != nil {
+			log.Printf("Digest error: %v", err)
+		}
+		if claimed >= s.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+
+// SendDue handles one batch of due users and returns how many were claimed.
+func (s *DigestService) SendDue(ctx context.Context) (int, error) {
+	recipients, err := s.Repository.ClaimDueRecipients(ctx, s.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, recipient := range recipients {
+		if err := s.send(ctx, recipient); err != nil {
+			log.Printf("Failed to send digest to user %d: %v", recipient.User.Id, err)
+		}
+	}
+	return len(recipients), nil
+}
+
+
+func (s *DigestService) send(ctx context.Context, recipient *models.DigestRecipientModel) error {
+	documents, err := s.Repository.GetDocumentActivity(ctx, recipient)
+	if err != nil {
+		return err
+	}
+	mentions, err := s.Repository.GetUnreadMentions(ctx, recipient.User.Id, 10)
+	if err != nil {
+		return err
+	}
+	if len(documents) == 0 && len(mentions) == 0 {
+		return nil
+	}
+
+	documentData := make([]map[string]any, 0, len(documents))
+	for _, document := range documents {
+		documentData = append(documentData, map[string]any{
+			"Title":      document.Title,
+			"Edits":      document.Edits,
+			"Editors":    strings.Join(document.Editors, ", "),
+			"Comments":   document.Comments,
+			"Commenters": strings.Join(document.Commenters, ", "),
+		})
+	}
+	mentionData := make([]map[string]any, 0, len(mentions))
+	for _, mention := range mentions {
+		mentionData = append(mentionData, map[string]any{
+			"DocumentTitle": mention.DocumentTitle,
+			"Author":        mention.Author,
+			"Excerpt":       mention.Excerpt,
+		})
+	}
+
+	return s.Mail.Enqueue(ctx, models.MailMessageModel{
+		IdempotencyKey: fmt.Sprintf("digest:%d:%s", recipient.User.Id, time.Now().UTC().Format("2006-01-02")),
+		Template:       "digest",
+		Locale:         recipient.Locale,
+		To:             recipient.User.Email,
+		Data: map[string]any{
+			"Username":  recipient.User.Username,
+			"Frequency": recipient.Frequency,
+			"Documents": documentData,
+			"Mentions":  mentionData,
+		},
+	})
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"golang/internal/core/repositories"
 	"golang/internal/infrastructure/clients"
@@ -10,25 +11,66 @@ import (
 	"golang/internal/infrastructure/errors"
 	"golang/internal/utils"
 	"io"
+	"log"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type DocumentService struct {
-	Repository  *repositories.DocumentRepository
-	RedisClient *clients.RedisClient
-	SMTPClient  *clients.SmtpClient
+	Repository    *repositories.DocumentRepository
+	Invites       clients.InviteStore
+	MailQueue     *clients.MailQueue
+	Notifications *repositories.NotificationRepository
+	Users         *repositories.UserRepository
+	Audit         *repositories.AuditRepository
+	Workspaces    *repositories.WorkspaceRepository
+	Folders       *repositories.FolderRepository
+	Templates     *repositories.TemplateRepository
+	// TrashRetention is how long trashed documents are kept before purge.
+	TrashRetention time.Duration
 }
 
 
 func (s *DocumentService) CheckDocumentAccess(ctx context.Context, userId int, documentId int) *apierrors.APIError {
-	_, err := s.Repository.CheckDocumentAccess(ctx, userId, documentId)
-	if err != nil {
+	hasAccess, err := s.Repository.CheckDocumentAccess(ctx, userId, documentId)
+	if err != nil || !hasAccess {
 		return &apierrors.ErrDocumentAccessDenied
 	}
 	return nil
 }
 
+// resolveTarget checks that the user may create documents in folderId or
+// workspaceId and returns the workspace the documents belong to. A document
+// created in a folder joins the space of that folder; writing to the folder
+// is enough, workspace membership is not required.
+func (s *DocumentService) resolveTarget(
+	ctx context.Context,
+	userId int,
+	folderId *int,
+	workspaceId *int,
+) (*int, *apierrors.APIError) {
+	if folderId != nil {
+		folder, err := s.Folders.GetFolder(ctx, *folderId, userId)
+		if err != nil || !slices.Contains(folderWriters, folder.Role) {
+			return nil, &apierrors.ErrFolderAccessDenied
+		}
+		if workspaceId != nil && !utils.SameId(workspaceId, folder.WorkspaceId) {
+			return nil, &apierrors.ErrFolderSpaceMismatch
+		}
+		return folder.WorkspaceId, nil
+	}
+
+	if workspaceId != nil {
+		workspace, err := s.Workspaces.GetWorkspace(ctx, *workspaceId, userId)
+		if err != nil || !slices.Contains(workspaceWriters, workspace.Role) {
+			return nil, &apierrors.ErrWorkspaceAccessDenied
+		}
+	}
+	return workspaceId, nil
+}
+
 func (s *DocumentService) CreateDocument(
 	ctx context.Context,
 	userId int,
@@ -44,6 +86,16 @@ func (s *DocumentService) CreateDocument(
 		return nil, &apierrors.ErrEncodingError
 	}
 
+	workspaceId, apiErr := s.resolveTarget(ctx, userId, documentFormEncoded.FolderId, documentFormEncoded.WorkspaceId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	documentFormEncoded.WorkspaceId = workspaceId
+
+	if apiErr := s.applyTemplate(ctx, userId, &documentFormEncoded); apiErr != nil {
+		return nil, apiErr
+	}
+
 	document, err := s.Repository.CreateDocument(ctx, documentFormEncoded, userId)
 	if err != nil {
 		return nil, apierrors.CheckDBError(err, "document")
@@ -56,9 +108,8 @@ func (s *DocumentService) GetDocumentById(
 	documentId int,
 	userId int,
 ) (*models.DocumentModel, *apierrors.APIError) {
-	_, err := s.Repository.CheckDocumentAccess(ctx, userId, documentId)
-	if err != nil {
-		return nil, &apierrors.ErrDocumentAccessDenied
+	if err := s.CheckDocumentAccess(ctx, userId, documentId); err != nil {
+		return nil, err
 	}
 
 	document, err := s.Repository.GetDocumentById(ctx, documentId, userId)
@@ -99,6 +150,39 @@ func (s *DocumentService) DeleteDocument(ctx context.Context, documentId int, us
 	return nil
 }
 
+func (s *DocumentService) GetTrash(
+	ctx context.Context,
+	userId int,
+	limit int,
+	offset int,
+) ([]*models.TrashedDocumentModel, *apierrors.APIError) {
+	documents, err := s.Repository.GetTrash(ctx, userId, limit, offset)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	for _, document := range documents {
+		document.PurgeAt = document.DeletedAt.Add(s.TrashRetention)
+	}
+	return documents, nil
+}
+
+func (s *DocumentService) RestoreDocument(ctx context.Context, documentId int, userId int) (*models.BaseDocumentModel, *apierrors.APIError) {
+	document, err := s.Repository.RestoreDocument(ctx, documentId, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	return document, nil
+}
+
+// PurgeDocument deletes a trashed document for good; documents outside the
+// trash have to be deleted first.
+func (s *DocumentService) PurgeDocument(ctx context.Context, documentId int, userId int) *apierrors.APIError {
+	if err := s.Repository.PurgeDocument(ctx, documentId, userId); err != nil {
+		return apierrors.CheckDBError(err, "document")
+	}
+	return nil
+}
+
 func (s *DocumentService) UpdateDocumentContent(
 	ctx context.Context,
 	userId int,
@@ -117,53 +201,243 @@ func (s *DocumentService) UpdateDocumentContent(
 	return document, nil
 }
 
+// SendInvite stores an invite code and queues the invite email; the mail
+// itself is delivered later by the mailer worker.
 func (s *DocumentService) SendInvite(
 	ctx context.Context,
 	userId int,
-	userEmail string,
 	documentId int,
+	inviteForm io.ReadCloser,
 ) *apierrors.APIError {
-	if _, err := s.Repository.CheckIsOwner(ctx, userId, documentId); err != nil {
+	var invite models.InviteMemberModel
+
+	if err := json.NewDecoder(inviteForm).Decode(&invite); err != nil {
+		return &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(invite); err != nil {
+		return err
+	}
+	userEmail := invite.Email
+
+	if isOwner, err := s.Repository.CheckIsOwner(ctx, documentId, userId); err != nil || !isOwner {
 		return &apierrors.ErrDocumentAccessDenied
 	}
 
-	key := fmt.Sprintf("document:%d:user:%d:invite", documentId, userId)
-	code := utils.RandSeq(6)
-	err := s.RedisClient.Set(ctx, key, code, time.Hour*24)
+	document, err := s.Repository.GetDocumentById(ctx, documentId, userId)
 	if err != nil {
-		return &apierrors.ErrInvalidRequestBody
+		return apierrors.CheckDBError(err, "document")
 	}
 
-	redisErr := s.RedisClient.Set(ctx, key, code, time.Hour*24)
-	if redisErr != nil {
-		return &apierrors.ErrInternalServerError
+	code := newInviteCode()
+	after, _ := json.Marshal(map[string]string{"email": userEmail})
+	return s.inviter().send(ctx, invitation{
+		Key:   fmt.Sprintf("document:%d:invite:%s", documentId, code),
+		Value: fmt.Sprintf("%d:%s", userId, userEmail),
+		TTL:   time.Hour * 24,
+		Email: userEmail,
+		Notification: models.NewNotificationModel{
+			Type:       utils.NotificationInvite,
+			GroupKey:   "invite:" + strconv.Itoa(documentId),
+			DocumentId: &documentId,
+			ActorId:    userId,
+			Data:       map[string]any{"title": document.Title, "code": code},
+		},
+		Mail: func(locale string) models.MailMessageModel {
+			return clients.InviteMail(userEmail, locale, code, document.Title, strconv.Itoa(documentId))
+		},
+		Audit: models.AuditEntryModel{
+			ActorId:    &userId,
+			Action:     utils.AuditInviteSend,
+			TargetType: "document",
+			TargetId:   strconv.Itoa(documentId),
+			DocumentId: &documentId,
+			After:      after,
+		},
+	})
+}
+
+func (s *DocumentService) inviter() inviter {
+	return inviter{
+		Store:         s.Invites,
+		Notifications: s.Notifications,
+		Users:         s.Users,
+		MailQueue:     s.MailQueue,
+		Audit:         s.Audit,
 	}
+}
 
-	document, err := s.Repository.GetDocumentById(ctx, documentId, userId)
+// AcceptInvite redeems an invite code. The code is bound to the invited email,
+// so it only works for the account it was sent to.
+func (s *DocumentService) AcceptInvite(
+	ctx context.Context,
+	user *models.BaseUserModel,
+	documentId int,
+	code string,
+) *apierrors.APIError {
+	key := fmt.Sprintf("document:%d:invite:%s", documentId, code)
+	value, err := s.Invites.Load(ctx, key)
 	if err != nil {
+		return &apierrors.ErrInvalidInvite
+	}
+
+	inviterId, email, ok := strings.Cut(value, ":")
+	inviterIdInt, convErr := strconv.Atoi(inviterId)
+	if !ok || convErr != nil || !strings.EqualFold(email, user.Email) {
+		return &apierrors.ErrInvalidInvite
+	}
+
+	if err := s.Repository.AddDocumentMember(ctx, documentId, user.Id, inviterIdInt, utils.AuditInviteAccept); err != nil {
 		return apierrors.CheckDBError(err, "document")
 	}
 
-	smtpErr := s.SMTPClient.SendInviteToDocument(
-		userEmail,
-		"Invite to Document",
-		code,
-		strconv.Itoa(documentId),
-		document.Title,
-	)
-	if smtpErr != nil {
-		return &apierrors.ErrInternalServerError
+	if err := s.Invites.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete redeemed invite: %v", err)
 	}
 	return nil
 }
 
+func (s *DocumentService) UpdateMemberRole(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	memberId int,
+	roleForm io.ReadCloser,
+) *apierrors.APIError {
+	var role models.UpdateMemberRoleModel
+
+	if err := json.NewDecoder(roleForm).Decode(&role); err != nil {
+		return &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(role); err != nil {
+		return err
+	}
+
+	if isOwner, err := s.Repository.CheckIsOwner(ctx, documentId, userId); err != nil || !isOwner || memberId == userId {
+		return &apierrors.ErrDocumentAccessDenied
+	}
+
+	if err := s.Repository.UpdateMemberRole(ctx, documentId, memberId, role.Role, userId); err != nil {
+		return apierrors.CheckDBError(err, "document member")
+	}
+	return nil
+}
+
+// DuplicateDocument copies a document the user can read; see
+// DocumentRepository.DuplicateDocument.
+func (s *DocumentService) DuplicateDocument(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	duplicateForm io.ReadCloser,
+) (*models.BaseDocumentModel, *apierrors.APIError) {
+	var duplicate models.DuplicateDocumentModel
+
+	// Every field is optional, so an empty body is fine.
+	if err := json.NewDecoder(duplicateForm).Decode(&duplicate); err != nil && !errors.Is(err, io.EOF) {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(duplicate); err != nil {
+		return nil, err
+	}
+
+	source, apiErr := s.GetDocumentById(ctx, documentId, userId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// Copying members shares the copy with them, which only the owner of the
+	// original may decide.
+	if duplicate.IncludeMembers {
+		if isOwner, err := s.Repository.CheckIsOwner(ctx, documentId, userId); err != nil || !isOwner {
+			return nil, &apierrors.ErrDocumentAccessDenied
+		}
+	}
+
+	folderId, workspaceId := duplicate.FolderId, duplicate.WorkspaceId
+	explicit := folderId != nil || workspaceId != nil
+	if !explicit {
+		folderId, workspaceId = source.FolderId, source.WorkspaceId
+	}
+	workspaceId, apiErr = s.resolveTarget(ctx, userId, folderId, workspaceId)
+	if apiErr != nil {
+		if explicit {
+			return nil, apiErr
+		}
+		folderId, workspaceId = nil, nil
+	}
+
+	title := source.Title + " (copy)"
+	if duplicate.Title != nil && strings.TrimSpace(*duplicate.Title) != "" {
+		title = strings.TrimSpace(*duplicate.Title)
+	}
+
+	form := models.CreateDocumentModel{
+		Title:       title,
+		IsPublic:    source.IsPublic,
+		WorkspaceId: workspaceId,
+		FolderId:    folderId,
+	}
+	document, err := s.Repository.DuplicateDocument(ctx, documentId, userId, form, duplicate.IncludeMembers)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	return document, nil
+}
+
+// TransferOwnership hands the document over to another user who already has
+// access to it; the caller must be an owner.
+func (s *DocumentService) TransferOwnership(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	transferForm io.ReadCloser,
+) (*models.BaseDocumentModel, *apierrors.APIError) {
+	var transfer models.TransferOwnershipModel
+
+	if err := json.NewDecoder(transferForm).Decode(&transfer); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(transfer); err != nil {
+		return nil, err
+	}
+
+	if isOwner, err := s.Repository.CheckIsOwner(ctx, documentId, userId); err != nil || !isOwner {
+		return nil, &apierrors.ErrDocumentAccessDenied
+	}
+
+	document, err := s.Repository.TransferOwnership(ctx, documentId, transfer.UserId, userId)
+	switch {
+	case errors.Is(err, repositories.ErrNotDocumentMember):
+		return nil, &apierrors.ErrNotDocumentMember
+	case errors.Is(err, repositories.ErrAlreadyOwner):
+		return nil, &apierrors.ErrAlreadyOwner
+	case err != nil:
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	return document, nil
+}
+
+// GetUserDocuments lists the documents of the user, or only those filed in
+// folderId when it is given.
 func (s *DocumentService) GetUserDocuments(
 	ctx context.Context,
 	userId int,
+	folderId *int,
 	limit int,
 	offset int,
 ) ([]*models.BaseDocumentModel, *apierrors.APIError) {
-	documents, err := s.Repository.GetUserDocuments(ctx, userId, limit, offset)
+	if folderId != nil {
+		hasAccess, err := s.Folders.CheckFolderRole(ctx, userId, *folderId)
+		if err != nil || !hasAccess {
+			return nil, &apierrors.ErrFolderAccessDenied
+		}
+	}
+
+	documents, err := s.Repository.GetUserDocuments(ctx, userId, folderId, limit, offset)
 	if err != nil {
 		return nil, apierrors.CheckDBError(err, "document")
 	}
@@ -175,9 +449,24 @@ func (s *DocumentService) AddDocumentSnapshot(
 	userId int,
 	documentId int,
 ) (*models.BaseSnapshotModel, *apierrors.APIError) {
-	snapshot, err := s.Repository.AddDocumentSnapshot(ctx, userId, documentId)
+	snapshot, err := s.Repository.AddDocumentSnapshot(ctx, documentId, userId)
 	if err != nil {
 		return nil, apierrors.CheckDBError(err, "document")
 	}
 	return snapshot, nil
 }
+
+
+// RestoreSnapshot is limited to owners and editors, like other content changes.
+func (s *DocumentService) RestoreSnapshot(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	snapshotId int,
+) (*models.DocumentModel, *apierrors.APIError) {
+	document, err := s.Repository.RestoreSnapshot(ctx, documentId, snapshotId, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "snapshot")
+	}
+	return document, nil
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"io"
+	"slices"
+	"strings"
+)
+
+
+// folderWriters may file documents and create subfolders in a folder.
+var folderWriters = []string{utils.RoleOwner, utils.RoleEditor}
+
+
+type FolderService struct {
+	Repository         *repositories.FolderRepository
+	DocumentRepository *repositories.DocumentRepository
+	Workspaces         *repositories.WorkspaceRepository
+	Users              *repositories.UserRepository
+}
+
+
+func (s *FolderService) checkFolderRole(ctx context.Context, userId int, folderId int, roles ...string) *apierrors.APIError {
+	hasRole, err := s.Repository.CheckFolderRole(ctx, userId, folderId, roles...)
+	if err != nil || !hasRole {
+		return &apierrors.ErrFolderAccessDenied
+	}
+	return nil
+}
+
+
+func (s *FolderService) checkWorkspaceRole(ctx context.Context, userId int, workspaceId int, roles ...string) *apierrors.APIError {
+	workspace, err := s.Workspaces.GetWorkspace(ctx, workspaceId, userId)
+	if err != nil || (len(roles) > 0 && !slices.Contains(roles, workspace.Role)) {
+		return &apierrors.ErrWorkspaceAccessDenied
+	}
+	return nil
+}
+
+
+func mapFolderError(err error, itemName string) *apierrors.APIError {
+	switch {
+	case errors.Is(err, repositories.ErrFolderCycle):
+		return &apierrors.ErrFolderCycle
+	case errors.Is(err, repositories.ErrFolderTooDeep):
+		return &apierrors.ErrFolderTooDeep
+	case errors.Is(err, repositories.ErrFolderSpaceMismatch):
+		return &apierrors.ErrFolderSpaceMismatch
+	default:
+		return apierrors.CheckDBError(err, itemName)
+	}
+}
+
+
+// CreateFolder creates a folder in the personal space of the user, at the
+// root of a workspace or inside a parent folder.
+func (s *FolderService) CreateFolder(
+	ctx context.Context,
+	userId int,
+	form io.ReadCloser,
+) (*models.FolderModel, *apierrors.APIError) {
+	var folderForm models.CreateFolderModel
+
+	if err := json.NewDecoder(form).Decode(&folderForm); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(folderForm); err != nil {
+		return nil, err
+	}
+	folderForm.Name = strings.TrimSpace(folderForm.Name)
+
+	if folderForm.ParentId != nil {
+		if err := s.checkFolderRole(ctx, userId, *folderForm.ParentId, folderWriters...); err != nil {
+			return nil, err
+		}
+	} else if folderForm.WorkspaceId != nil {
+		if err := s.checkWorkspaceRole(ctx, userId, *folderForm.WorkspaceId, workspaceWriters...); err != nil {
+			return nil, err
+		}
+	}
+
+	folder, err := s.Repository.CreateFolder(ctx, userId, folderForm)
+	if err != nil {
+		return nil, mapFolderError(err, "folder")
+	}
+	return folder, nil
+}
+
+
+func (s *FolderService) GetFolder(ctx context.Context, userId int, folderId int) (*models.FolderModel, *apierrors.APIError) {
+	folder, err := s.Repository.GetFolder(ctx, folderId, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "folder")
+	}
+	return folder, nil
+}
+
+
+// GetRootFolders lists the top-level folders of the personal space of the
+// user, or of workspaceId when it is given.
+func (s *FolderService) GetRootFolders(
+	ctx context.Context,
+	userId int,
+	workspaceId *int,
+	limit int,
+	offset int,
+) ([]*models.FolderModel, *apierrors.APIError) {
+	if workspaceId != nil {
+		if err := s.checkWorkspaceRole(ctx, userId, *workspaceId); err != nil {
+			return nil, err
+		}
+	}
+
+	folders, err := s.Repository.GetRootFolders(ctx, userId, workspaceId, limit, offset)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "folder")
+	}
+	return folders, nil
+}
+
+
+func (s *FolderService) GetChildren(
+	ctx context.Context,
+	userId int,
+	folderId int,
+	limit int,
+	offset int,
+) ([]*models.FolderItemModel, *apierrors.APIError) {
+	if err := s.checkFolderRole(ctx, userId, folderId); err != nil {
+		return nil, err
+	}
+
+	items, err := s.Repository.GetChildren(ctx, folderId, limit, offset)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "folder")
+	}
+	return items, nil
+}
+
+
+func (s *FolderService) RenameFolder(
+	ctx context.Context,
+	userId int,
+	folderId int,
+	form io.ReadCloser,
+) (*models.FolderModel, *apierrors.APIError) {
+	var rename models.RenameFolderModel
+
+	if err := json.NewDecoder(form).Decode(&rename); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(rename); err != nil {
+		return nil, err
+	}
+
+	folder, err := s.Repository.RenameFolder(ctx, folderId, userId, strings.TrimSpace(rename.Name))
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "folder")
+	}
+	return folder, nil
+}
+
+
+// MoveFolder requires ownership of the folder and write access to the new
+// parent.
+func (s *FolderService) MoveFolder(
+	ctx context.Context,
+	userId int,
+	folderId int,
+	form io.ReadCloser,
+) (*models.FolderModel, *apierrors.APIError) {
+	var move models.MoveFolderModel
+
+	if err := json.NewDecoder(form).Decode(&move); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if move.ParentId != nil {
+		if err := s.checkFolderRole(ctx, userId, *move.ParentId, folderWriters...); err != nil {
+			return nil, err
+		}
+	}
+
+	folder, err := s.Repository.MoveFolder(ctx, folderId, move.ParentId, userId)
+	if err != nil {
+		return nil, mapFolderError(err, "folder")
+	}
+	return folder, nil
+}
+
+
+func (s *FolderService) DeleteFolder(ctx context.Context, userId int, folderId int) *apierrors.APIError {
+	if err := s.Repository.DeleteFolder(ctx, folderId, userId); err != nil {
+		return apierrors.CheckDBError(err, "folder")
+	}
+	return nil
+}
+
+
+// ShareFolder gives an existing user a role on the folder and everything in
+// it. Only owners of the folder may share it.
+func (s *FolderService) ShareFolder(
+	ctx context.Context,
+	userId int,
+	folderId int,
+	form io.ReadCloser,
+) *apierrors.APIError {
+	var share models.ShareFolderModel
+
+	if err := json.NewDecoder(form).Decode(&share); err != nil {
+		return &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(share); err != nil {
+		return err
+	}
+
+	if err := s.checkFolderRole(ctx, userId, folderId, utils.RoleOwner); err != nil {
+		return err
+	}
+
+	member, err := s.Users.GetUserByEmail(ctx, share.Email)
+	if err != nil {
+		return apierrors.CheckDBError(err, "user")
+	}
+
+	if err := s.Repository.ShareFolder(ctx, folderId, member.Id, share.Role, userId); err != nil {
+		return apierrors.CheckDBError(err, "folder")
+	}
+	return nil
+}
+
+
+// UnshareFolder revokes a direct grant; members may also leave on their own.
+func (s *FolderService) UnshareFolder(ctx context.Context, userId int, folderId int, memberId int) *apierrors.APIError {
+	if memberId != userId {
+		if err := s.checkFolderRole(ctx, userId, folderId, utils.RoleOwner); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Repository.UnshareFolder(ctx, folderId, memberId, userId); err != nil {
+		return apierrors.CheckDBError(err, "folder member")
+	}
+	return nil
+}
+
+
+// MoveDocument files a document into a folder or takes it out of one. The
+// caller must own the document and be able to write to the folder.
+func (s *FolderService) MoveDocument(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	form io.ReadCloser,
+) (*models.BaseDocumentModel, *apierrors.APIError) {
+	var move models.MoveToFolderModel
+
+	if err := json.NewDecoder(form).Decode(&move); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if move.FolderId != nil {
+		if err := s.checkFolderRole(ctx, userId, *move.FolderId, folderWriters...); err != nil {
+			return nil, err
+		}
+	}
+
+	document, err := s.DocumentRepository.MoveDocumentToFolder(ctx, documentId, move.FolderId, userId)
+	if err != nil {
+		return nil, mapFolderError(err, "document")
+	}
+	return document, nil
+}
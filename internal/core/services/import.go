@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"golang/internal/core/content"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"io"
+)
+
+
+func mapImportError(err error) *apierrors.APIError {
+	var blockErr *content.BlockError
+	switch {
+	case errors.As(err, &blockErr):
+		return apierrors.NewContentError(blockErr.Path, blockErr.Reason)
+	case errors.Is(err, content.ErrTooLarge):
+		return &apierrors.ErrImportTooLarge
+	case errors.Is(err, content.ErrInvalidArchive), errors.Is(err, content.ErrNotUTF8), errors.Is(err, content.ErrInvalidHTML):
+		return &apierrors.ErrInvalidImport
+	default:
+		return mapFolderError(err, "document")
+	}
+}
+
+
+// ImportFile creates a document from a Markdown, HTML or block JSON file.
+// HTML is sanitized and stored as Markdown; block trees are validated.
+func (s *DocumentService) ImportFile(
+	ctx context.Context,
+	userId int,
+	folderId *int,
+	workspaceId *int,
+	fileName string,
+	file io.Reader,
+) (*models.ImportResultModel, *apierrors.APIError) {
+	source, err := io.ReadAll(io.LimitReader(file, content.MaxMarkdownSize+1))
+	if err != nil {
+		return nil, &apierrors.ErrInvalidImport
+	}
+	if len(source) > content.MaxMarkdownSize {
+		return nil, &apierrors.ErrImportTooLarge
+	}
+
+	imported, err := content.ReadFile(fileName, source)
+	if err != nil {
+		return nil, mapImportError(err)
+	}
+	return s.importFiles(ctx, userId, folderId, workspaceId, []content.MarkdownFile{imported})
+}
+
+
+// ImportArchive imports every importable file of a zip archive, recreating
+// its directories as folders under folderId. The import is all or nothing.
+func (s *DocumentService) ImportArchive(
+	ctx context.Context,
+	userId int,
+	folderId *int,
+	workspaceId *int,
+	archive io.ReaderAt,
+	size int64,
+) (*models.ImportResultModel, *apierrors.APIError) {
+	files, err := content.ReadArchive(archive, size)
+	if err != nil {
+		return nil, mapImportError(err)
+	}
+	if len(files) == 0 {
+		return nil, &apierrors.ErrInvalidImport
+	}
+	return s.importFiles(ctx, userId, folderId, workspaceId, files)
+}
+
+
+func (s *DocumentService) importFiles(
+	ctx context.Context,
+	userId int,
+	folderId *int,
+	workspaceId *int,
+	files []content.MarkdownFile,
+) (*models.ImportResultModel, *apierrors.APIError) {
+	workspaceId, apiErr := s.resolveTarget(ctx, userId, folderId, workspaceId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	imports := make([]models.ImportFileModel, 0, len(files))
+	for _, file := range files {
+		imports = append(imports, models.ImportFileModel{
+			Folders: file.Folders,
+			Title:   file.Title(),
+			Content: file.Content,
+		})
+	}
+
+	result, err := s.Repository.ImportDocuments(ctx, userId, folderId, workspaceId, imports)
+	if err != nil {
+		return nil, mapImportError(err)
+	}
+	return result, nil
+}
+
+
+// ExportDocument returns the document to export with the format it is to be
+// written in. The handler writes the headers before streaming the body.
+func (s *DocumentService) ExportDocument(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	formatName string,
+) (content.Document, content.Format, *apierrors.APIError) {
+	format, ok := content.LookupFormat(formatName)
+	if !ok {
+		return content.Document{}, content.Format{}, &apierrors.ErrInvalidQuery
+	}
+
+	document, apiErr := s.GetDocumentById(ctx, documentId, userId)
+	if apiErr != nil {
+		return content.Document{}, content.Format{}, apiErr
+	}
+	return content.Document{
+		Title:     document.Title,
+		Body:      document.Content,
+		Author:    document.Owner.Username,
+		CreatedAt: document.CreatedAt,
+		UpdatedAt: document.UpdatedAt,
+	}, format, nil
+}
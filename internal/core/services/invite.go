@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"log"
+	"time"
+)
+
+
+// inviteCodeBytes is the entropy of an invite code, which grants membership
+// to whoever holds it together with the invited account.
+const inviteCodeBytes = 16
+
+
+func newInviteCode() string {
+	return utils.RandomToken(inviteCodeBytes)
+}
+
+
+// invitation is everything sent out for one invite code. Mail is built once
+// the invitee's locale is known.
+type invitation struct {
+	Key          string
+	Value        string
+	TTL          time.Duration
+	Email        string
+	Notification models.NewNotificationModel
+	Mail         func(locale string) models.MailMessageModel
+	Audit        models.AuditEntryModel
+}
+
+
+// inviter stores invite codes and tells the invitee about them, for both
+// document and workspace invites.
+type inviter struct {
+	Store         clients.InviteStore
+	Notifications *repositories.NotificationRepository
+	Users         *repositories.UserRepository
+	MailQueue     *clients.MailQueue
+	Audit         *repositories.AuditRepository
+}
+
+
+// send stores the code, then notifies and mails the invitee. Only failing to
+// store or mail the code fails the invite; the rest is best effort.
+func (i inviter) send(ctx context.Context, invite invitation) *apierrors.APIError {
+	if err := i.Store.Save(ctx, invite.Key, invite.Value, invite.TTL); err != nil {
+		log.Printf("Failed to store %s invite: %v", invite.Audit.TargetType, err)
+		return &apierrors.ErrInternalServerError
+	}
+
+	if err := i.Notifications.NotifyEmail(ctx, invite.Email, invite.Notification); err != nil {
+		log.Printf("Failed to notify invited user: %v", err)
+	}
+
+	// Invitees without an account get the default locale.
+	locale, _ := i.Users.GetLocaleByEmail(ctx, invite.Email)
+	if err := i.MailQueue.Enqueue(ctx, invite.Mail(locale)); err != nil {
+		log.Printf("Failed to enqueue %s invite: %v", invite.Audit.TargetType, err)
+		return &apierrors.ErrInternalServerError
+	}
+
+	// The code itself is a credential and stays out of the audit log.
+	if err := i.Audit.CreateAuditEntry(ctx, invite.Audit); err != nil {
+		log.Printf("Failed to write %s invite audit entry: %v", invite.Audit.TargetType, err)
+	}
+	return nil
+}
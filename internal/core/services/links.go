@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+)
+
+
+// GetBacklinks lists the documents the user can open that link to
+// documentId, most recently updated first.
+func (s *DocumentService) GetBacklinks(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	limit int,
+	offset int,
+) ([]*models.BacklinkModel, *apierrors.APIError) {
+	if err := s.CheckDocumentAccess(ctx, userId, documentId); err != nil {
+		return nil, err
+	}
+
+	backlinks, err := s.Repository.GetBacklinks(ctx, documentId, userId, limit, offset)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	return backlinks, nil
+}
+
+
+// GetDocumentLinks lists the links in the content of documentId with the
+// status of their targets; with brokenOnly only the links whose target is
+// missing or inaccessible to the user.
+func (s *DocumentService) GetDocumentLinks(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	brokenOnly bool,
+) ([]*models.DocumentLinkModel, *apierrors.APIError) {
+	if err := s.CheckDocumentAccess(ctx, userId, documentId); err != nil {
+		return nil, err
+	}
+
+	links, err := s.Repository.GetDocumentLinks(ctx, documentId, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	if !brokenOnly {
+		return links, nil
+	}
+
+	broken := make([]*models.DocumentLinkModel, 0)
+	for _, link := range links {
+		if link.Status != utils.LinkOk {
+			broken = append(broken, link)
+		}
+	}
+	return broken, nil
+}
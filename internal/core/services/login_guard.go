@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/config"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+
+// LoginGuard throttles password logins per account and per client IP. After
+// too many failures inside the window the key is locked; every further lockout
+// within a day doubles the lock duration up to MaxLockout.
+type LoginGuard struct {
+	Config *config.LoginGuardConfig
+	Store  clients.AttemptStore
+	Audit  *repositories.AuditRepository
+	Mailer *clients.MailQueue
+}
+
+
+func accountKey(email string) string {
+	return "login:account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+
+func (g *LoginGuard) Check(ctx context.Context, email string, ip string) *apierrors.APIError {
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		remaining, err := g.Store.LockedFor(ctx, key+":lock")
+		if err != nil {
+			log.Printf("Failed to read login lock: %v", err)
+			continue
+		}
+		if remaining > 0 {
+			return apierrors.ErrLoginLocked(remaining)
+		}
+	}
+	return nil
+}
+
+
+func (g *LoginGuard) RegisterSuccess(ctx context.Context, email string) {
+	if err := g.Store.Reset(ctx, accountKey(email)+":failures"); err != nil {
+		log.Printf("Failed to reset login failures: %v", err)
+	}
+}
+
+
+// RegisterFailure counts a failed attempt. user is nil when the email does not
+// belong to an account; the attempt still counts so lookups cannot be used to
+// probe for accounts without being throttled.
+func (g *LoginGuard) RegisterFailure(
+	ctx context.Context,
+	email string,
+	ip string,
+	userAgent string,
+	user *models.UserModel,
+) {
+	if lockedUntil, locked := g.registerFailure(ctx, accountKey(email), g.Config.MaxAccountAttempts); locked {
+		g.onLockout(ctx, "account", strings.ToLower(strings.TrimSpace(email)), ip, userAgent, user, lockedUntil)
+	}
+	if lockedUntil, locked := g.registerFailure(ctx, ipKey(ip), g.Config.MaxIpAttempts); locked {
+		g.onLockout(ctx, "ip", ip, ip, userAgent, nil, lockedUntil)
+	}
+}
+
+
+func (g *LoginGuard) registerFailure(ctx context.Context, key string, maxAttempts int) (time.Time, bool) {
+	failures, err := g.Store.Increment(ctx, key+":failures", g.Config.AttemptWindow)
+	if err != nil {
+		log.Printf("Failed to count login failure: %v", err)
+		return time.Time{}, false
+	}
+	if failures < maxAttempts {
+		return time.Time{}, false
+	}
+
+	lockouts, err := g.Store.Increment(ctx, key+":lockouts", 24*time.Hour)
+	if err != nil {
+		lockouts = 1
+	}
+
+	duration := g.Config.BaseLockout << min(lockouts-1, 16)
+	if duration <= 0 || duration > g.Config.MaxLockout {
+		duration = g.Config.MaxLockout
+	}
+
+	g.Store.Lock(ctx, key+":lock", duration)
+	g.Store.Reset(ctx, key+":failures")
+	return time.Now().Add(duration), true
+}
+
+
+func (g *LoginGuard) onLockout(
+	ctx context.Context,
+	targetType string,
+	targetId string,
+	ip string,
+	userAgent string,
+	user *models.UserModel,
+	lockedUntil time.Time,
+) {
+	after, _ := json.Marshal(map[string]any{"locked_until": lockedUntil})
+	entry := models.AuditEntryModel{
+		Action:     utils.AuditLoginLockout,
+		Ip:         ip,
+		UserAgent:  userAgent,
+		TargetType: targetType,
+		TargetId:   targetId,
+		After:      after,
+	}
+	if user != nil {
+		entry.ActorId = &user.Id
+	}
+	if err := g.Audit.CreateAuditEntry(ctx, entry); err != nil {
+		log.Printf("Failed to write lockout audit entry: %v", err)
+	}
+
+	if user == nil || g.Mailer == nil {
+		return
+	}
+	mail := clients.AccountLockedMail(user.Email, user.Locale, user.Username, ip, lockedUntil)
+	if err := g.Mailer.Enqueue(ctx, mail); err != nil {
+		log.Printf("Failed to enqueue lockout notification: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/config"
+	"net/http"
+	"testing"
+	"time"
+)
+
+
+func newTestGuard() *LoginGuard {
+	return &LoginGuard{
+		Config: &config.LoginGuardConfig{
+			MaxAccountAttempts: 3,
+			MaxIpAttempts:      5,
+			AttemptWindow:      time.Minute,
+			BaseLockout:        time.Minute,
+			MaxLockout:         time.Hour,
+		},
+		Store: clients.NewMemoryAttemptStore(),
+	}
+}
+
+
+func TestLoginGuardLockoutDoubles(t *testing.T) {
+	tests := []struct {
+		lockout int
+		want    time.Duration
+	}{
+		{lockout: 1, want: time.Minute},
+		{lockout: 2, want: 2 * time.Minute},
+		{lockout: 3, want: 4 * time.Minute},
+		{lockout: 6, want: 32 * time.Minute},
+		{lockout: 7, want: time.Hour},
+		{lockout: 40, want: time.Hour},
+	}
+
+	ctx := context.Background()
+	guard := newTestGuard()
+	key := accountKey("user@example.com")
+	maxAttempts := guard.Config.MaxAccountAttempts
+
+	lockout := 0
+	for _, tt := range tests {
+		for lockout < tt.lockout {
+			lockout++
+			// The previous lock has run out.
+			guard.Store.Reset(ctx, key+":lock")
+
+			for attempt := 1; attempt <= maxAttempts; attempt++ {
+				lockedUntil, locked := guard.registerFailure(ctx, key, maxAttempts)
+				if locked != (attempt == maxAttempts) {
+					t.Fatalf("lockout %d, attempt %d: locked = %v", lockout, attempt, locked)
+				}
+				if locked && lockout == tt.lockout {
+					if got := time.Until(lockedUntil); got > tt.want || got < tt.want-time.Second {
+						t.Errorf("lockout %d lasts %v, want %v", lockout, got, tt.want)
+					}
+				}
+			}
+		}
+
+		remaining, err := guard.Store.LockedFor(ctx, key+":lock")
+		if err != nil {
+			t.Fatalf("LockedFor: %v", err)
+		}
+		if remaining > tt.want || remaining < tt.want-time.Second {
+			t.Errorf("lockout %d: lock remaining %v, want %v", tt.lockout, remaining, tt.want)
+		}
+	}
+}
+
+
+func TestLoginGuardFailuresResetAfterLockout(t *testing.T) {
+	ctx := context.Background()
+	guard := newTestGuard()
+	key := accountKey("user@example.com")
+
+	for range guard.Config.MaxAccountAttempts {
+		guard.registerFailure(ctx, key, guard.Config.MaxAccountAttempts)
+	}
+	if _, locked := guard.registerFailure(ctx, key, guard.Config.MaxAccountAttempts); locked {
+		t.Error("first failure after a lockout locked again")
+	}
+}
+
+
+func TestLoginGuardCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		lockedKey  string
+		email      string
+		ip         string
+		wantLocked bool
+	}{
+		{
+			name:  "nothing locked",
+			email: "user@example.com",
+			ip:    "203.0.113.1",
+		},
+		{
+			name:       "account locked",
+			lockedKey:  accountKey("user@example.com"),
+			email:      "user@example.com",
+			ip:         "203.0.113.1",
+			wantLocked: true,
+		},
+		{
+			name:       "account locked under another spelling",
+			lockedKey:  accountKey("user@example.com"),
+			email:      "  User@Example.COM ",
+			ip:         "203.0.113.1",
+			wantLocked: true,
+		},
+		{
+			name:       "ip locked",
+			lockedKey:  ipKey("203.0.113.1"),
+			email:      "other@example.com",
+			ip:         "203.0.113.1",
+			wantLocked: true,
+		},
+		{
+			name:      "other ip",
+			lockedKey: ipKey("203.0.113.1"),
+			email:     "user@example.com",
+			ip:        "203.0.113.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			guard := newTestGuard()
+			if tt.lockedKey != "" {
+				guard.Store.Lock(ctx, tt.lockedKey+":lock", time.Minute)
+			}
+
+			err := guard.Check(ctx, tt.email, tt.ip)
+			if locked := err != nil; locked != tt.wantLocked {
+				t.Fatalf("Check() = %v, want locked %v", err, tt.wantLocked)
+			}
+			if err != nil && err.Code != http.StatusTooManyRequests {
+				t.Errorf("Check() code = %d, want %d", err.Code, http.StatusTooManyRequests)
+			}
+		})
+	}
+}
+
+
+func TestLoginGuardSuccessClearsFailures(t *testing.T) {
+	ctx := context.Background()
+	guard := newTestGuard()
+	key := accountKey("user@example.com")
+
+	for range guard.Config.MaxAccountAttempts - 1 {
+		guard.registerFailure(ctx, key, guard.Config.MaxAccountAttempts)
+	}
+	guard.RegisterSuccess(ctx, " USER@example.com")
+
+	if _, locked := guard.registerFailure(ctx, key, guard.Config.MaxAccountAttempts); locked {
+		t.Error("failures before a successful login still count")
+	}
+}
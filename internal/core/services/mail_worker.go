@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/config"
+	"golang/internal/infrastructure/database/models"
+	"log"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+
+// MailWorker sends the messages queued by clients.MailQueue. A failed send is
+// republished to the retry queue with an exponential TTL; once MaxAttempts is
+// reached the message is rejected and ends up in the dead-letter queue.
+// Delivered idempotency keys are remembered for SentTTL so redeliveries are
+// acknowledged without sending the email twice.
+type MailWorker struct {
+	Config *config.MailWorkerConfig
+	Queue  *clients.MailQueue
+	Sent   clients.AttemptStore
+}
+
+
+func NewMailWorker(queue *clients.MailQueue, redisClient *clients.RedisClient) *MailWorker {
+	return &MailWorker{
+		Config: config.LoadMailWorkerConfig(),
+		Queue:  queue,
+		Sent:   clients.NewAttemptStore(redisClient),
+	}
+}
+
+
+func (worker *MailWorker) Run(ctx context.Context) error {
+	channel, err := worker.Queue.Rabbit.Connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer channel.Close()
+
+	if err := channel.Qos(worker.Config.Prefetch, 0, false); err != nil {
+		return err
+	}
+
+	deliveries, err := channel.ConsumeWithContext(ctx, clients.MailQueueName, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return amqp091.ErrClosed
+			}
+			worker.handle(ctx, delivery)
+		}
+	}
+}
+
+
+func (worker *MailWorker) handle(ctx context.Context, delivery amqp091.Delivery) {
+	var message models.MailMessageModel
+	if err := json.Unmarshal(delivery.Body, &message); err != nil {
+		log.Printf("Dropping malformed mail message %s: %v", delivery.MessageId, err)
+		delivery.Nack(false, false)
+		return
+	}
+
+	sentKey := "mail:sent:" + message.IdempotencyKey
+	if message.IdempotencyKey != "" {
+		if remaining, _ := worker.Sent.LockedFor(ctx, sentKey); remaining > 0 {
+			delivery.Ack(false)
+			return
+		}
+	}
+
+	sendErr := worker.Queue.Smtp.Send(message)
+	if sendErr == nil {
+		if message.IdempotencyKey != "" {
+			worker.Sent.Lock(ctx, sentKey, worker.Config.SentTTL)
+		}
+		delivery.Ack(false)
+		return
+	}
+
+	attempt := deliveryAttempt(delivery)
+	if attempt >= worker.Config.MaxAttempts {
+		log.Printf("Giving up on %s mail to %s after %d attempts: %v", message.Template, message.To, attempt, sendErr)
+		delivery.Nack(false, false)
+		return
+	}
+
+	retryIn := min(worker.Config.BaseBackoff<<(attempt-1), worker.Config.MaxBackoff)
+	log.Printf("Failed to send %s mail to %s (attempt %d), retrying in %s: %v", message.Template, message.To, attempt, retryIn, sendErr)
+
+	if err := worker.Queue.Retry(ctx, message, attempt+1, retryIn); err != nil {
+		// The retry was not stored; requeue the original rather than lose it.
+		log.Printf("Failed to schedule mail retry: %v", err)
+		delivery.Nack(false, true)
+		return
+	}
+	delivery.Ack(false)
+}
+
+
+func deliveryAttempt(delivery amqp091.Delivery) int {
+	switch value := delivery.Headers[clients.MailAttemptHeader].(type) {
+	case int32:
+		return max(int(value), 1)
+	case int64:
+		return max(int(value), 1)
+	case int:
+		return max(value, 1)
+	}
+	return 1
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+type NotificationService struct {
+	DB         *pgxpool.Pool
+	Repository *repositories.NotificationRepository
+}
+
+
+func (s *NotificationService) GetUserNotifications(
+	ctx context.Context,
+	userId int,
+	unreadOnly bool,
+	limit int,
+	offset int,
+) ([]*models.NotificationModel, *apierrors.APIError) {
+	notifications, err := s.Repository.GetUserNotifications(ctx, userId, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "notification")
+	}
+	return notifications, nil
+}
+
+
+func (s *NotificationService) CountUnread(ctx context.Context, userId int) (*models.NotificationCountModel, *apierrors.APIError) {
+	count, err := s.Repository.CountUnread(ctx, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "notification")
+	}
+	return &models.NotificationCountModel{Unread: count}, nil
+}
+
+
+func (s *NotificationService) MarkRead(ctx context.Context, userId int, notificationId int64) *apierrors.APIError {
+	if err := s.Repository.MarkRead(ctx, userId, notificationId); err != nil {
+		return apierrors.CheckDBError(err, "notification")
+	}
+	return nil
+}
+
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userId int) (*models.NotificationCountModel, *apierrors.APIError) {
+	if _, err := s.Repository.MarkAllRead(ctx, userId); err != nil {
+		return nil, apierrors.CheckDBError(err, "notification")
+	}
+	return &models.NotificationCountModel{Unread: 0}, nil
+}
+
+
+// Listen calls push for every notification announced on the Postgres channel
+// until ctx is done, reconnecting after errors. Every API instance listens,
+// so a user gets the push on whichever instance holds their socket.
+func (s *NotificationService) Listen(ctx context.Context, push func(userId int, notification *models.NotificationModel)) {
+	for {
+		if err := s.listen(ctx, push); err != nil {
+			log.Printf("Notification listener stopped: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+
+func (s *NotificationService) listen(ctx context.Context, push func(userId int, notification *models.NotificationModel)) error {
+	pooled, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// LISTEN state is tied to the connection, so it must not go back to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+repositories.NotificationChannel); err != nil {
+		return err
+	}
+
+	for {
+		message, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var target struct {
+			Id     int64 `json:"id"`
+			UserId int   `json:"user_id"`
+		}
+		if err := json.Unmarshal([]byte(message.Payload), &target); err != nil {
+			continue
+		}
+
+		notification, err := s.Repository.GetNotification(ctx, target.UserId, target.Id)
+		if err != nil {
+			log.Printf("Failed to load notification %d: %v", target.Id, err)
+			continue
+		}
+		push(target.UserId, notification)
+	}
+}
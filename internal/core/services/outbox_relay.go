@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+
+// OutboxRelay moves committed outbox rows to the events topic exchange. An
+// event is marked published only after the broker confirmed it; failures are
+// retried with exponential backoff, so delivery is at-least-once and
+// consumers deduplicate on the message id (the event UUID).
+type OutboxRelay struct {
+	DB           *pgxpool.Pool
+	Repository   *repositories.OutboxRepository
+	Rabbit       *clients.RabbitClient
+	Exchange     string
+	BatchSize    int
+	PollInterval time.Duration
+	MaxBackoff   time.Duration
+}
+
+
+func NewOutboxRelay(db *pgxpool.Pool, rabbit *clients.RabbitClient) *OutboxRelay {
+	return &OutboxRelay{
+		DB:           db,
+		Repository:   &repositories.OutboxRepository{DB: db},
+		Rabbit:       rabbit,
+		Exchange:     rabbit.Config.EventsExchange,
+		BatchSize:    100,
+		PollInterval: time.Second,
+		MaxBackoff:   10 * time.Minute,
+	}
+}
+
+
+func (relay *OutboxRelay) Run(ctx context.Context) {
+	if err := relay.Rabbit.DeclareTopicExchange(relay.Exchange); err != nil {
+		log.Printf("Failed to declare exchange %s: %v", relay.Exchange, err)
+	}
+
+	ticker := time.NewTicker(relay.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		published, err := relay.publishBatch(ctx)
+		if err != nil {
+			log.Printf("Outbox relay error: %v", err)
+		}
+
+		// A full batch means there is probably more waiting; keep draining.
+		if published >= relay.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+
+func (relay *OutboxRelay) publishBatch(ctx context.Context) (int, error) {
+	tx, err := relay.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	events, err := relay.Repository.ClaimPending(ctx, tx, relay.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return published, err
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		publishErr := relay.Rabbit.Publish(
+			publishCtx, relay.Exchange, event.Type, event.EventId,
+			amqp091.Table{"event_type": event.Type, "aggregate_id": event.AggregateId},
+			body,
+		)
+		cancel()
+
+		if publishErr != nil {
+			retryIn := min(time.Second<<min(event.Attempts, 20), relay.MaxBackoff)
+			log.Printf("Failed to publish event %s (attempt %d), retrying in %s: %v", event.EventId, event.Attempts+1, retryIn, publishErr)
+			if err := relay.Repository.MarkFailed(ctx, tx, event.Id, publishErr, retryIn); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err := relay.Repository.MarkPublished(ctx, tx, event.Id); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, tx.Commit(ctx)
+}
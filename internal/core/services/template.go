@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"golang/internal/core/content"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+
+// templateDate is how {{date}} is written.
+const templateDate = "2006-01-02"
+
+
+type TemplateService struct {
+	Repository         *repositories.TemplateRepository
+	DocumentRepository *repositories.DocumentRepository
+	Workspaces         *repositories.WorkspaceRepository
+}
+
+
+func builtinTemplateModel(template content.BuiltinTemplate) *models.TemplateModel {
+	return &models.TemplateModel{
+		Key:         template.Key,
+		Scope:       utils.TemplateScopeBuiltin,
+		Name:        template.Name,
+		Description: template.Description,
+		Title:       template.Title,
+		Content:     template.Content,
+	}
+}
+
+
+// checkTemplateSpace checks that the user may add templates to workspaceId;
+// anyone may add to their personal library.
+func (s *TemplateService) checkTemplateSpace(ctx context.Context, userId int, workspaceId *int) *apierrors.APIError {
+	if workspaceId == nil {
+		return nil
+	}
+	workspace, err := s.Workspaces.GetWorkspace(ctx, *workspaceId, userId)
+	if err != nil || !slices.Contains(workspaceWriters, workspace.Role) {
+		return &apierrors.ErrWorkspaceAccessDenied
+	}
+	return nil
+}
+
+
+// GetTemplates lists the built-in templates, the personal templates of the
+// user and the templates of their workspaces, or of workspaceId only.
+func (s *TemplateService) GetTemplates(
+	ctx context.Context,
+	userId int,
+	workspaceId *int,
+) ([]*models.TemplateModel, *apierrors.APIError) {
+	if workspaceId != nil {
+		if _, err := s.Workspaces.GetWorkspace(ctx, *workspaceId, userId); err != nil {
+			return nil, &apierrors.ErrWorkspaceAccessDenied
+		}
+	}
+
+	stored, err := s.Repository.GetTemplates(ctx, userId, workspaceId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "template")
+	}
+
+	templates := make([]*models.TemplateModel, 0, len(stored)+3)
+	for _, template := range content.BuiltinTemplates() {
+		templates = append(templates, builtinTemplateModel(template))
+	}
+	return append(templates, stored...), nil
+}
+
+
+func (s *TemplateService) GetTemplate(ctx context.Context, userId int, templateId int) (*models.TemplateModel, *apierrors.APIError) {
+	template, err := s.Repository.GetTemplate(ctx, templateId, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "template")
+	}
+	return template, nil
+}
+
+
+func (s *TemplateService) CreateTemplate(
+	ctx context.Context,
+	userId int,
+	form io.ReadCloser,
+) (*models.TemplateModel, *apierrors.APIError) {
+	var templateForm models.CreateTemplateModel
+
+	if err := json.NewDecoder(form).Decode(&templateForm); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+	templateForm.Name = strings.TrimSpace(templateForm.Name)
+	if err := utils.ValidateForm(templateForm); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkTemplateSpace(ctx, userId, templateForm.WorkspaceId); err != nil {
+		return nil, err
+	}
+
+	template, err := s.Repository.CreateTemplate(ctx, userId, templateForm)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "template")
+	}
+	return template, nil
+}
+
+
+// UpdateTemplate changes a template the user may manage; see
+// TemplateRepository.UpdateTemplate. Built-in templates cannot be changed.
+func (s *TemplateService) UpdateTemplate(
+	ctx context.Context,
+	userId int,
+	templateId int,
+	form io.ReadCloser,
+) (*models.TemplateModel, *apierrors.APIError) {
+	var templateForm models.UpdateTemplateModel
+
+	if err := json.NewDecoder(form).Decode(&templateForm); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+	if templateForm.Name != nil {
+		name := strings.TrimSpace(*templateForm.Name)
+		if name == "" {
+			return nil, &apierrors.ErrValidationError
+		}
+		templateForm.Name = &name
+	}
+	if err := utils.ValidateForm(templateForm); err != nil {
+		return nil, err
+	}
+
+	template, err := s.Repository.UpdateTemplate(ctx, templateId, userId, templateForm)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "template")
+	}
+	return template, nil
+}
+
+
+func (s *TemplateService) DeleteTemplate(ctx context.Context, userId int, templateId int) *apierrors.APIError {
+	deleted, err := s.Repository.DeleteTemplate(ctx, templateId, userId)
+	if err != nil {
+		return apierrors.CheckDBError(err, "template")
+	}
+	if !deleted {
+		return apierrors.ErrItemNotFound("template")
+	}
+	return nil
+}
+
+
+// SaveAsTemplate saves the title and content of a document the user can
+// read as a new template.
+func (s *TemplateService) SaveAsTemplate(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	form io.ReadCloser,
+) (*models.TemplateModel, *apierrors.APIError) {
+	var saveForm models.SaveAsTemplateModel
+
+	// Every field is optional, so an empty body is fine.
+	if err := json.NewDecoder(form).Decode(&saveForm); err != nil && !errors.Is(err, io.EOF) {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+	saveForm.Name = strings.TrimSpace(saveForm.Name)
+	if err := utils.ValidateForm(saveForm); err != nil {
+		return nil, err
+	}
+
+	if hasAccess, err := s.DocumentRepository.CheckDocumentAccess(ctx, userId, documentId); err != nil || !hasAccess {
+		return nil, &apierrors.ErrDocumentAccessDenied
+	}
+	document, err := s.DocumentRepository.GetDocumentById(ctx, documentId, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+
+	if err := s.checkTemplateSpace(ctx, userId, saveForm.WorkspaceId); err != nil {
+		return nil, err
+	}
+
+	name := saveForm.Name
+	if name == "" {
+		name = document.Title
+	}
+	template, err := s.Repository.CreateTemplate(ctx, userId, models.CreateTemplateModel{
+		Name:        name,
+		Description: saveForm.Description,
+		Title:       document.Title,
+		Content:     document.Content,
+		WorkspaceId: saveForm.WorkspaceId,
+	})
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "template")
+	}
+	return template, nil
+}
+
+
+// applyTemplate fills the title and content of a new document from the
+// template it names, substituting the placeholders. A title given in the
+// form wins over the title of the template.
+func (s *DocumentService) applyTemplate(
+	ctx context.Context,
+	userId int,
+	form *models.CreateDocumentModel,
+) *apierrors.APIError {
+	if form.TemplateId != nil && form.TemplateKey != "" {
+		return &apierrors.ErrValidationError
+	}
+
+	var title, body, name string
+	switch {
+	case form.TemplateKey != "":
+		template, ok := content.LookupBuiltinTemplate(form.TemplateKey)
+		if !ok {
+			return apierrors.ErrItemNotFound("template")
+		}
+		title, body, name = template.Title, template.Content, template.Name
+	case form.TemplateId != nil:
+		template, err := s.Templates.GetTemplate(ctx, *form.TemplateId, userId)
+		if err != nil {
+			return apierrors.CheckDBError(err, "template")
+		}
+		title, body, name = template.Title, template.Content, template.Name
+	default:
+		return nil
+	}
+
+	user, err := s.Users.GetUserById(ctx, userId)
+	if err != nil {
+		return apierrors.CheckDBError(err, "user")
+	}
+	placeholders := content.Placeholders{
+		Date:   time.Now().UTC().Format(templateDate),
+		Author: user.Username,
+		Title:  name,
+	}
+
+	if strings.TrimSpace(form.Title) == "" {
+		form.Title = strings.TrimSpace(placeholders.Expand(title))
+		if form.Title == "" {
+			form.Title = name
+		}
+		if runes := []rune(form.Title); len(runes) > content.MaxTitleLength {
+			form.Title = string(runes[:content.MaxTitleLength])
+		}
+	}
+	placeholders.Title = form.Title
+	form.Content = placeholders.Expand(body)
+	return nil
+}
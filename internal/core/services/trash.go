@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/config"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// TrashPurger permanently deletes documents that have been in the trash for
+// longer than the retention period.
+type TrashPurger struct {
+	Repository   *repositories.DocumentRepository
+	Retention    time.Duration
+	BatchSize    int
+	PollInterval time.Duration
+}
+
+
+func NewTrashPurger(db *pgxpool.Pool, cfg *config.TrashConfig) *TrashPurger {
+	return &TrashPurger{
+		Repository:   &repositories.DocumentRepository{DB: db},
+		Retention:    cfg.Retention,
+		BatchSize:    cfg.BatchSize,
+		PollInterval: cfg.PurgeInterval,
+	}
+}
+
+
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.Repository.PurgeExpired(ctx, time.Now().Add(-p.Retention), p.BatchSize)
+		if err != nil {
+			log.Printf("Trash purge error: %v", err)
+		}
+		if purged >= p.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
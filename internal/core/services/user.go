@@ -45,6 +45,53 @@ func (s *UserService) UpdateUser(ctx context.Context, userId int, userForm io.Re
 }
 
 
+func (s *UserService) GetDigestSettings(ctx context.Context, userId int) (*models.DigestSettingsModel, *apierrors.APIError) {
+	settings, err := s.Repository.GetDigestSettings(ctx, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "user")
+	}
+	return settings, nil
+}
+
+
+func (s *UserService) UpdateDigestSettings(ctx context.Context, userId int, settingsForm io.ReadCloser) (*models.DigestSettingsModel, *apierrors.APIError) {
+	var settingsFormEncoded models.DigestSettingsModel
+
+	if err := json.NewDecoder(settingsForm).Decode(&settingsFormEncoded); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(settingsFormEncoded); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.Repository.UpdateDigestSettings(ctx, userId, settingsFormEncoded.Frequency)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "user")
+	}
+	return settings, nil
+}
+
+
+func (s *UserService) UpdateLocale(ctx context.Context, userId int, localeForm io.ReadCloser) (*models.LocaleModel, *apierrors.APIError) {
+	var localeFormEncoded models.LocaleModel
+
+	if err := json.NewDecoder(localeForm).Decode(&localeFormEncoded); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(localeFormEncoded); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.Repository.UpdateLocale(ctx, userId, localeFormEncoded.Locale)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "user")
+	}
+	return settings, nil
+}
+
+
 func (s *UserService) DeleteUser(ctx context.Context, userId int) *apierrors.APIError {
 	err := s.Repository.DeleteUser(ctx, userId)
 	if err != nil {
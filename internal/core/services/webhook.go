@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"io"
+	"strconv"
+	"time"
+)
+
+
+type WebhookService struct {
+	Repository         *repositories.WebhookRepository
+	DocumentRepository *repositories.DocumentRepository
+	Client             *clients.WebhookClient
+}
+
+
+func (s *WebhookService) CreateWebhook(
+	ctx context.Context,
+	userId int,
+	form io.ReadCloser,
+) (*models.CreatedWebhookModel, *apierrors.APIError) {
+	var webhookForm models.CreateWebhookModel
+
+	if err := json.NewDecoder(form).Decode(&webhookForm); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(webhookForm); err != nil {
+		return nil, err
+	}
+	if err := s.Client.CheckUrl(webhookForm.Url); err != nil {
+		return nil, &apierrors.ErrWebhookUrl
+	}
+
+	if webhookForm.DocumentId != nil {
+		isOwner, err := s.DocumentRepository.CheckIsOwner(ctx, *webhookForm.DocumentId, userId)
+		if err != nil || !isOwner {
+			return nil, &apierrors.ErrDocumentAccessDenied
+		}
+	}
+
+	secret := "whsec_" + utils.RandomToken(32)
+	webhook, err := s.Repository.CreateWebhook(ctx, userId, webhookForm, secret)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "webhook")
+	}
+
+	return &models.CreatedWebhookModel{WebhookModel: *webhook, Secret: secret}, nil
+}
+
+
+func (s *WebhookService) GetUserWebhooks(ctx context.Context, userId int) ([]*models.WebhookModel, *apierrors.APIError) {
+	webhooks, err := s.Repository.GetUserWebhooks(ctx, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "webhook")
+	}
+	return webhooks, nil
+}
+
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userId int, webhookId int) *apierrors.APIError {
+	if err := s.Repository.DeleteWebhook(ctx, userId, webhookId); err != nil {
+		return apierrors.CheckDBError(err, "webhook")
+	}
+	return nil
+}
+
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, userId int, webhookId int) ([]*models.WebhookDeliveryModel, *apierrors.APIError) {
+	if _, err := s.Repository.GetWebhook(ctx, userId, webhookId); err != nil {
+		return nil, apierrors.CheckDBError(err, "webhook")
+	}
+
+	deliveries, err := s.Repository.GetDeliveries(ctx, userId, webhookId, 100)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "webhook delivery")
+	}
+	return deliveries, nil
+}
+
+
+// SendTestEvent delivers a webhook.test event synchronously, once, and
+// returns the logged delivery so the caller sees the receiver's status code.
+func (s *WebhookService) SendTestEvent(ctx context.Context, userId int, webhookId int) (*models.WebhookDeliveryModel, *apierrors.APIError) {
+	webhook, err := s.Repository.GetWebhook(ctx, userId, webhookId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "webhook")
+	}
+
+	payload, _ := json.Marshal(map[string]int{"webhook_id": webhook.Id})
+	event := models.DomainEventModel{
+		EventId:       "test_" + utils.RandomToken(16),
+		Type:          utils.EventWebhookTest,
+		AggregateType: "webhook",
+		AggregateId:   strconv.Itoa(webhook.Id),
+		ActorId:       &userId,
+		DocumentId:    webhook.DocumentId,
+		Payload:       payload,
+		CreatedAt:     time.Now().UTC(),
+	}
+	body, _ := json.Marshal(event)
+
+	deliveryId, err := s.Repository.CreateTestDelivery(ctx, webhook.Id, event.EventId, body)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "webhook delivery")
+	}
+
+	target, err := s.Repository.GetDeliveryTarget(ctx, deliveryId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "webhook delivery")
+	}
+
+	result := s.Client.Deliver(ctx, target)
+	status := utils.WebhookDelivered
+	if result.Error != nil {
+		status = utils.WebhookFailed
+	}
+	if err := s.Repository.RecordAttempt(ctx, s.Repository.DB, deliveryId, status, result, 0); err != nil {
+		return nil, apierrors.CheckDBError(err, "webhook delivery")
+	}
+
+	deliveries, err := s.Repository.GetDeliveries(ctx, userId, webhook.Id, 1)
+	if err != nil || len(deliveries) == 0 {
+		return nil, apierrors.CheckDBError(err, "webhook delivery")
+	}
+	return deliveries[0], nil
+}
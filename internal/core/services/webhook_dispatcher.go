@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/config"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+
+const webhookQueueName = "webhooks.events"
+
+
+// WebhookDispatcher fans domain events out to webhook subscriptions. Events
+// are consumed from the events exchange and turned into delivery rows; a
+// second loop sends due deliveries and retries failures with exponential
+// backoff until MaxAttempts, after which the delivery is marked failed.
+type WebhookDispatcher struct {
+	DB           *pgxpool.Pool
+	Repository   *repositories.WebhookRepository
+	Rabbit       *clients.RabbitClient
+	Client       *clients.WebhookClient
+	BatchSize    int
+	PollInterval time.Duration
+	MaxAttempts  int
+	MaxBackoff   time.Duration
+}
+
+
+func NewWebhookDispatcher(db *pgxpool.Pool, rabbit *clients.RabbitClient, cfg *config.WebhookConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		DB:           db,
+		Repository:   &repositories.WebhookRepository{DB: db},
+		Rabbit:       rabbit,
+		Client:       clients.NewWebhookClient(cfg.AllowPrivateTargets),
+		BatchSize:    20,
+		PollInterval: 2 * time.Second,
+		MaxAttempts:  8,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+
+func (dispatcher *WebhookDispatcher) Run(ctx context.Context) {
+	go dispatcher.deliverLoop(ctx)
+
+	for {
+		if err := dispatcher.consume(ctx); err != nil {
+			log.Printf("Webhook consumer stopped: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+
+func (dispatcher *WebhookDispatcher) consume(ctx context.Context) error {
+	exchange := dispatcher.Rabbit.Config.EventsExchange
+	if err := dispatcher.Rabbit.DeclareTopicExchange(exchange); err != nil {
+		return err
+	}
+
+	channel, err := dispatcher.Rabbit.Connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer channel.Close()
+
+	if _, err := channel.QueueDeclare(webhookQueueName, true, false, false, false, nil); err != nil {
+		return err
+	}
+	for _, pattern := range []string{"document.*", "comment.*", "member.*"} {
+		if err := channel.QueueBind(webhookQueueName, pattern, exchange, false, nil); err != nil {
+			return err
+		}
+	}
+	if err := channel.Qos(dispatcher.BatchSize, 0, false); err != nil {
+		return err
+	}
+
+	deliveries, err := channel.ConsumeWithContext(ctx, webhookQueueName, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return amqp091.ErrClosed
+			}
+			dispatcher.enqueue(ctx, delivery)
+		}
+	}
+}
+
+
+func (dispatcher *WebhookDispatcher) enqueue(ctx context.Context, delivery amqp091.Delivery) {
+	var event models.DomainEventModel
+	if err := json.Unmarshal(delivery.Body, &event); err != nil {
+		log.Printf("Dropping malformed event %s: %v", delivery.MessageId, err)
+		delivery.Nack(false, false)
+		return
+	}
+
+	if _, err := dispatcher.Repository.EnqueueDeliveries(ctx, &event); err != nil {
+		log.Printf("Failed to enqueue webhook deliveries for %s: %v", event.EventId, err)
+		delivery.Nack(false, true)
+		return
+	}
+	delivery.Ack(false)
+}
+
+
+func (dispatcher *WebhookDispatcher) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := dispatcher.deliverBatch(ctx)
+		if err != nil {
+			log.Printf("Webhook delivery error: %v", err)
+		}
+		if sent >= dispatcher.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+
+func (dispatcher *WebhookDispatcher) deliverBatch(ctx context.Context) (int, error) {
+	tx, err := dispatcher.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	targets, err := dispatcher.Repository.ClaimDueDeliveries(ctx, tx, dispatcher.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, target := range targets {
+		result := dispatcher.Client.Deliver(ctx, target)
+
+		status, retryIn := utils.WebhookDelivered, time.Duration(0)
+		if result.Error != nil {
+			attempt := target.Attempts + 1
+			if attempt >= dispatcher.MaxAttempts {
+				status = utils.WebhookFailed
+				log.Printf("Giving up on webhook delivery %d after %d attempts: %v", target.DeliveryId, attempt, result.Error)
+			} else {
+				status = utils.WebhookPending
+				retryIn = min(30*time.Second<<min(target.Attempts, 20), dispatcher.MaxBackoff)
+			}
+		}
+
+		if err := dispatcher.Repository.RecordAttempt(ctx, tx, target.DeliveryId, status, result, retryIn); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(targets), tx.Commit(ctx)
+}
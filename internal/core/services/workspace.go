@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang/internal/core/repositories"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+
+const workspaceInviteTTL = 7 * 24 * time.Hour
+
+
+// workspaceManagers may invite, promote and remove members.
+var workspaceManagers = []string{utils.RoleOwner, utils.RoleAdmin}
+
+// workspaceWriters may create documents in a workspace or move them into it.
+var workspaceWriters = []string{utils.RoleOwner, utils.RoleAdmin, utils.RoleEditor}
+
+
+type WorkspaceService struct {
+	Repository         *repositories.WorkspaceRepository
+	DocumentRepository *repositories.DocumentRepository
+	Users              *repositories.UserRepository
+	Notifications      *repositories.NotificationRepository
+	Audit              *repositories.AuditRepository
+	Invites            clients.InviteStore
+	MailQueue          *clients.MailQueue
+}
+
+
+func (s *WorkspaceService) CreateWorkspace(
+	ctx context.Context,
+	userId int,
+	form io.ReadCloser,
+) (*models.WorkspaceModel, *apierrors.APIError) {
+	var workspaceForm models.CreateWorkspaceModel
+
+	if err := json.NewDecoder(form).Decode(&workspaceForm); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(workspaceForm); err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.Repository.CreateWorkspace(ctx, userId, strings.TrimSpace(workspaceForm.Name))
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "workspace")
+	}
+	return workspace, nil
+}
+
+
+func (s *WorkspaceService) GetUserWorkspaces(ctx context.Context, userId int) ([]*models.WorkspaceModel, *apierrors.APIError) {
+	workspaces, err := s.Repository.GetUserWorkspaces(ctx, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "workspace")
+	}
+	return workspaces, nil
+}
+
+
+// GetWorkspace returns the workspace if userId is a member and, when roles
+// are given, holds one of them.
+func (s *WorkspaceService) GetWorkspace(
+	ctx context.Context,
+	userId int,
+	workspaceId int,
+	roles ...string,
+) (*models.WorkspaceModel, *apierrors.APIError) {
+	workspace, err := s.Repository.GetWorkspace(ctx, workspaceId, userId)
+	if err != nil {
+		return nil, &apierrors.ErrWorkspaceAccessDenied
+	}
+	if len(roles) > 0 && !slices.Contains(roles, workspace.Role) {
+		return nil, &apierrors.ErrWorkspaceAccessDenied
+	}
+	return workspace, nil
+}
+
+
+func (s *WorkspaceService) GetMembers(ctx context.Context, userId int, workspaceId int) ([]*models.WorkspaceMemberModel, *apierrors.APIError) {
+	if _, err := s.GetWorkspace(ctx, userId, workspaceId); err != nil {
+		return nil, err
+	}
+
+	members, err := s.Repository.GetMembers(ctx, workspaceId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "workspace member")
+	}
+	return members, nil
+}
+
+
+func (s *WorkspaceService) GetDocuments(
+	ctx context.Context,
+	userId int,
+	workspaceId int,
+	limit int,
+	offset int,
+) ([]*models.BaseDocumentModel, *apierrors.APIError) {
+	if _, err := s.GetWorkspace(ctx, userId, workspaceId); err != nil {
+		return nil, err
+	}
+
+	documents, err := s.DocumentRepository.GetWorkspaceDocuments(ctx, workspaceId, limit, offset)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	return documents, nil
+}
+
+
+// SendInvite stores an invite code bound to the invited email and role and
+// queues the invite email.
+func (s *WorkspaceService) SendInvite(
+	ctx context.Context,
+	user *models.BaseUserModel,
+	workspaceId int,
+	inviteForm io.ReadCloser,
+) *apierrors.APIError {
+	var invite models.InviteWorkspaceMemberModel
+
+	if err := json.NewDecoder(inviteForm).Decode(&invite); err != nil {
+		return &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(invite); err != nil {
+		return err
+	}
+
+	workspace, apiErr := s.GetWorkspace(ctx, user.Id, workspaceId, workspaceManagers...)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	code := newInviteCode()
+	after, _ := json.Marshal(map[string]string{"email": invite.Email, "role": invite.Role})
+	return s.inviter().send(ctx, invitation{
+		Key:   fmt.Sprintf("workspace:%d:invite:%s", workspaceId, code),
+		Value: fmt.Sprintf("%d:%s:%s", user.Id, invite.Role, invite.Email),
+		TTL:   workspaceInviteTTL,
+		Email: invite.Email,
+		Notification: models.NewNotificationModel{
+			Type:     utils.NotificationWorkspaceInvite,
+			GroupKey: "workspace_invite:" + strconv.Itoa(workspaceId),
+			ActorId:  user.Id,
+			Data: map[string]any{
+				"workspace_id": workspaceId,
+				"name":         workspace.Name,
+				"role":         invite.Role,
+				"code":         code,
+			},
+		},
+		Mail: func(locale string) models.MailMessageModel {
+			return clients.WorkspaceInviteMail(
+				invite.Email, locale, code, workspace.Name, strconv.Itoa(workspaceId), user.Username, invite.Role,
+			)
+		},
+		Audit: models.AuditEntryModel{
+			ActorId:    &user.Id,
+			Action:     utils.AuditWorkspaceInviteSend,
+			TargetType: "workspace",
+			TargetId:   strconv.Itoa(workspaceId),
+			After:      after,
+		},
+	})
+}
+
+
+func (s *WorkspaceService) inviter() inviter {
+	return inviter{
+		Store:         s.Invites,
+		Notifications: s.Notifications,
+		Users:         s.Users,
+		MailQueue:     s.MailQueue,
+		Audit:         s.Audit,
+	}
+}
+
+
+// AcceptInvite redeems an invite code for the account it was sent to.
+func (s *WorkspaceService) AcceptInvite(
+	ctx context.Context,
+	user *models.BaseUserModel,
+	workspaceId int,
+	code string,
+) (*models.WorkspaceModel, *apierrors.APIError) {
+	key := fmt.Sprintf("workspace:%d:invite:%s", workspaceId, code)
+	value, err := s.Invites.Load(ctx, key)
+	if err != nil {
+		return nil, &apierrors.ErrInvalidInvite
+	}
+
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[2], user.Email) {
+		return nil, &apierrors.ErrInvalidInvite
+	}
+	inviterId, convErr := strconv.Atoi(parts[0])
+	if convErr != nil {
+		return nil, &apierrors.ErrInvalidInvite
+	}
+
+	if err := s.Repository.AddMember(ctx, workspaceId, user.Id, parts[1], inviterId); err != nil {
+		return nil, apierrors.CheckDBError(err, "workspace")
+	}
+
+	if err := s.Invites.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete redeemed workspace invite: %v", err)
+	}
+	return s.GetWorkspace(ctx, user.Id, workspaceId)
+}
+
+
+// canManage reports whether actor may change or remove a member currently
+// holding role, or grant newRole. Admins manage everyone except owners.
+func canManage(actor string, role string, newRole string) bool {
+	if actor == utils.RoleOwner {
+		return true
+	}
+	return actor == utils.RoleAdmin && role != utils.RoleOwner && newRole != utils.RoleOwner
+}
+
+
+func (s *WorkspaceService) memberRole(ctx context.Context, workspaceId int, userId int) (string, *apierrors.APIError) {
+	workspace, err := s.Repository.GetWorkspace(ctx, workspaceId, userId)
+	if err != nil {
+		return "", apierrors.CheckDBError(err, "workspace member")
+	}
+	return workspace.Role, nil
+}
+
+
+func (s *WorkspaceService) UpdateMemberRole(
+	ctx context.Context,
+	userId int,
+	workspaceId int,
+	memberId int,
+	roleForm io.ReadCloser,
+) *apierrors.APIError {
+	var role models.UpdateWorkspaceRoleModel
+
+	if err := json.NewDecoder(roleForm).Decode(&role); err != nil {
+		return &apierrors.ErrInvalidRequestBody
+	}
+
+	if err := utils.ValidateForm(role); err != nil {
+		return err
+	}
+
+	actor, apiErr := s.GetWorkspace(ctx, userId, workspaceId, workspaceManagers...)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	current, apiErr := s.memberRole(ctx, workspaceId, memberId)
+	if apiErr != nil {
+		return apiErr
+	}
+	if !canManage(actor.Role, current, role.Role) {
+		return &apierrors.ErrWorkspaceAccessDenied
+	}
+
+	return s.mapMemberError(s.Repository.UpdateMemberRole(ctx, workspaceId, memberId, role.Role, userId))
+}
+
+
+// RemoveMember removes a member; every member may also remove themselves.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, userId int, workspaceId int, memberId int) *apierrors.APIError {
+	actor, apiErr := s.GetWorkspace(ctx, userId, workspaceId)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if memberId != userId {
+		current, apiErr := s.memberRole(ctx, workspaceId, memberId)
+		if apiErr != nil {
+			return apiErr
+		}
+		if !canManage(actor.Role, current, "") {
+			return &apierrors.ErrWorkspaceAccessDenied
+		}
+	}
+
+	return s.mapMemberError(s.Repository.RemoveMember(ctx, workspaceId, memberId, userId))
+}
+
+
+func (s *WorkspaceService) mapMemberError(err error) *apierrors.APIError {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repositories.ErrLastWorkspaceOwner):
+		return &apierrors.ErrLastWorkspaceOwner
+	default:
+		return apierrors.CheckDBError(err, "workspace member")
+	}
+}
+
+
+// MoveDocument moves a document between the personal space of the caller and
+// a workspace. The caller must own the document and be allowed to write to
+// the target workspace.
+func (s *WorkspaceService) MoveDocument(
+	ctx context.Context,
+	userId int,
+	documentId int,
+	moveForm io.ReadCloser,
+) (*models.BaseDocumentModel, *apierrors.APIError) {
+	var move models.MoveDocumentModel
+
+	if err := json.NewDecoder(moveForm).Decode(&move); err != nil {
+		return nil, &apierrors.ErrInvalidRequestBody
+	}
+
+	if move.WorkspaceId != nil {
+		if _, err := s.GetWorkspace(ctx, userId, *move.WorkspaceId, workspaceWriters...); err != nil {
+			return nil, err
+		}
+	}
+
+	document, err := s.DocumentRepository.MoveDocument(ctx, documentId, move.WorkspaceId, userId)
+	if err != nil {
+		return nil, apierrors.CheckDBError(err, "document")
+	}
+	return document, nil
+}
@@ -2,9 +2,12 @@ package deps
 
 import (
 	"context"
+	"golang/internal/core/audit"
+	"golang/internal/core/authz"
 	"golang/internal/core/services"
 	"golang/internal/infrastructure/database/models"
 	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
 	"net/http"
 	"strings"
 
@@ -15,35 +18,71 @@ type AuthenticatedHandlerFunc func(w http.ResponseWriter, r *http.Request, user
 type AuthenticatedSocketHandlerFunc func(s socketio.Conn) error
 
 type AuthDependency struct {
-	Service *services.AuthService
+	Service       *services.AuthService
+	ApiKeyService *services.ApiKeyService
 }
 
 
-func NewAuthDependency(authService *services.AuthService) *AuthDependency {
+type contextKey string
+
+const principalKey contextKey = "principal"
+
+
+func NewAuthDependency(authService *services.AuthService, apiKeyService *services.ApiKeyService) *AuthDependency {
 	return &AuthDependency{
-		Service: authService,
+		Service:       authService,
+		ApiKeyService: apiKeyService,
+	}
+}
+
+
+// PrincipalFromContext returns the principal stored by Protected.
+func PrincipalFromContext(ctx context.Context) (*authz.Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*authz.Principal)
+	return principal, ok
+}
+
+
+func (d *AuthDependency) authenticate(request *http.Request) (*authz.Principal, *apierrors.APIError) {
+	authHeader := request.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, &apierrors.ErrInvalidToken
+	}
+
+	if apiKey, ok := strings.CutPrefix(authHeader, "ApiKey "); ok {
+		return d.ApiKeyService.ValidateApiKey(request.Context(), strings.TrimSpace(apiKey))
 	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == "" {
+		return nil, &apierrors.ErrInvalidToken
+	}
+	return d.Service.Authenticate(request.Context(), tokenString)
 }
 
 func (d *AuthDependency) Protected(handler AuthenticatedHandlerFunc) http.HandlerFunc {
+	return d.Scoped(handler)
+}
+
+// Scoped authenticates the request with any supported credential and checks
+// the principal holds every listed scope, answering 403 with the first missing
+// one otherwise.
+func (d *AuthDependency) Scoped(handler AuthenticatedHandlerFunc, scopes ...string) http.HandlerFunc {
 	return func(response http.ResponseWriter, request *http.Request) {
-		authHeader := request.Header.Get("Authorization")
-		if authHeader == "" {
-			apierrors.WriteHTTPError(response, &apierrors.ErrInvalidToken)
-			return
-		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == "" {
-			apierrors.WriteHTTPError(response, &apierrors.ErrInvalidToken)
+		principal, err := d.authenticate(request)
+		if err != nil {
+			apierrors.WriteHTTPError(response, err)
 			return
 		}
 
-		user, err := d.Service.ValidateToken(request.Context(), tokenString)
-		if err != nil {
-			apierrors.WriteHTTPError(response, err)
+		if missing, ok := principal.Missing(scopes...); ok {
+			apierrors.WriteHTTPError(response, apierrors.ErrMissingScope(missing))
 			return
 		}
-		handler(response, request, user)
+
+		ctx := context.WithValue(request.Context(), principalKey, principal)
+		ctx = audit.WithRequest(ctx, utils.GetClientIP(request), request.UserAgent())
+		handler(response, request.WithContext(ctx), principal.User)
 	}
 }
 
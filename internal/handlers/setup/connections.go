@@ -0,0 +1,54 @@
+package setup
+
+import (
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/config"
+	"golang/internal/infrastructure/database/connections"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+
+// Connections are the shared clients handed to every handler. Redis, SMTP and
+// RabbitMQ are optional and stay nil when their *_HOST variable is not set;
+// Mail is nil only when neither SMTP nor RabbitMQ is configured. Blobs is
+// always set; it is the local filesystem unless STORAGE_BACKEND says s3.
+type Connections struct {
+	DB     *pgxpool.Pool
+	Redis  *clients.RedisClient
+	Smtp   *clients.SmtpClient
+	Rabbit *clients.RabbitClient
+	Mail   *clients.MailQueue
+	Blobs  clients.BlobStore
+	// Invites is always set as well: Redis when configured, else memory.
+	Invites clients.InviteStore
+}
+
+
+func NewConnections(db *pgxpool.Pool) *Connections {
+	conns := &Connections{DB: db, Blobs: clients.NewBlobStore(config.LoadStorageConfig())}
+
+	if config.RedisConfigured() {
+		conns.Redis = clients.NewRedisClient("", connections.NewRedisConnection())
+	}
+	conns.Invites = clients.NewInviteStore(conns.Redis)
+
+	if config.SmtpConfigured() {
+		conns.Smtp = clients.NewSmtpClient()
+	}
+	if config.RabbitConfigured() {
+		conns.Rabbit = clients.NewRabbitClient()
+	}
+	if conns.Smtp != nil || conns.Rabbit != nil {
+		rabbit := conns.Rabbit
+		// Captured in-process mail must be sent by this process, not the worker.
+		// Without SMTP settings there is nothing captured and mail only queues.
+		if conns.Smtp != nil {
+			if _, ok := conns.Smtp.Transport.(*clients.MemoryTransport); ok {
+				rabbit = nil
+			}
+		}
+		conns.Mail = clients.NewMailQueue(rabbit, conns.Smtp)
+	}
+	return conns
+}
@@ -1,27 +1,39 @@
 package setup
 
 import (
+	"context"
 	"fmt"
 	"golang/internal/core/repositories"
 	"golang/internal/core/services"
 	"golang/internal/handlers/v1"
+	"golang/internal/infrastructure/clients"
 	"golang/internal/infrastructure/config"
 	"golang/internal/infrastructure/database/models"
 	"golang/internal/infrastructure/types"
 
 	socketio "github.com/googollee/go-socket.io"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 
-func InitNewHandler[T types.HandlerInterface](emptyHandler T, db *pgxpool.Pool) (T, error) {
+func InitNewHandler[T types.HandlerInterface](emptyHandler T, conns *Connections) (T, error) {
 	switch h := any(emptyHandler).(type) {
 	case *handlers.UserHandler:
-		userRepository := &repositories.UserRepository{DB: db}
-		documentRepository := &repositories.DocumentRepository{DB: db}
+		userRepository := &repositories.UserRepository{DB: conns.DB}
+		documentRepository := &repositories.DocumentRepository{DB: conns.DB}
 
 		userService := &services.UserService{Repository: userRepository}
-		documentService := &services.DocumentService{Repository: documentRepository}
+		documentService := &services.DocumentService{
+			Repository: documentRepository,
+			Invites: conns.Invites,
+			MailQueue: conns.Mail,
+			Notifications: &repositories.NotificationRepository{DB: conns.DB},
+			Users: &repositories.UserRepository{DB: conns.DB},
+			Audit: &repositories.AuditRepository{DB: conns.DB},
+			Workspaces: &repositories.WorkspaceRepository{DB: conns.DB},
+			Folders: &repositories.FolderRepository{DB: conns.DB},
+			Templates: &repositories.TemplateRepository{DB: conns.DB},
+			TrashRetention: config.LoadTrashConfig().Retention,
+		}
 		
 		*h = handlers.UserHandler{UserService: userService, DocumentService: documentService}
 		return any(h).(T), nil
@@ -31,28 +43,153 @@ func InitNewHandler[T types.HandlerInterface](emptyHandler T, db *pgxpool.Pool)
 		if err != nil {
 			panic(err)
 		}
+		if cfg.Keys != nil {
+			cfg.Keys.StartRotation(context.Background(), cfg.KeyRotation)
+		}
 		
-		repository := &repositories.UserRepository{DB: db}
-		service := &services.AuthService{Repository: repository, Config: cfg}
+		oidcCfg := config.LoadOidcConfig()
+		oidcClients := make(map[string]*clients.OidcClient, len(oidcCfg.Providers))
+		for name, providerCfg := range oidcCfg.Providers {
+			oidcClients[name] = clients.NewOidcClient(providerCfg)
+		}
+
+		repository := &repositories.UserRepository{DB: conns.DB}
+		auditRepository := &repositories.AuditRepository{DB: conns.DB}
+		guard := &services.LoginGuard{
+			Config: config.LoadLoginGuardConfig(),
+			Store: clients.NewAttemptStore(conns.Redis),
+			Audit: auditRepository,
+			Mailer: conns.Mail,
+		}
+		service := &services.AuthService{
+			Repository: repository, 
+			Config: cfg,
+			OidcClients: oidcClients,
+			OidcStates: services.NewOidcStateStore(oidcCfg.StateTime),
+			Guard: guard,
+			Audit: auditRepository,
+		}
 		*h = handlers.AuthHandler{Service: service}
 		return any(h).(T), nil
 
 	case *handlers.DocumentHandler:
-		documentRepository := &repositories.DocumentRepository{DB: db}
-		commentRepository := &repositories.CommentRepository{DB: db}
+		documentRepository := &repositories.DocumentRepository{DB: conns.DB}
+		commentRepository := &repositories.CommentRepository{DB: conns.DB}
 
-		documentService := &services.DocumentService{Repository: documentRepository}
+		documentService := &services.DocumentService{
+			Repository: documentRepository,
+			Invites: conns.Invites,
+			MailQueue: conns.Mail,
+			Notifications: &repositories.NotificationRepository{DB: conns.DB},
+			Users: &repositories.UserRepository{DB: conns.DB},
+			Audit: &repositories.AuditRepository{DB: conns.DB},
+			Workspaces: &repositories.WorkspaceRepository{DB: conns.DB},
+			Folders: &repositories.FolderRepository{DB: conns.DB},
+			Templates: &repositories.TemplateRepository{DB: conns.DB},
+			TrashRetention: config.LoadTrashConfig().Retention,
+		}
 		commentService := &services.CommentService{Repository: commentRepository}
+		storageConfig := config.LoadStorageConfig()
+		attachmentService := &services.AttachmentService{
+			Repository: &repositories.AttachmentRepository{DB: conns.DB},
+			DocumentRepository: documentRepository,
+			Store: conns.Blobs,
+			MaxSize: storageConfig.MaxAttachmentSize,
+			Types: storageConfig.AttachmentTypes,
+		}
+		activityService := &services.ActivityService{
+			DB: conns.DB,
+			Repository: &repositories.ActivityRepository{DB: conns.DB},
+		}
 		
 		socket := socketio.NewServer(nil)
 		*h = handlers.DocumentHandler{
 			DocumentService: documentService, 
 			CommentService: commentService,
+			ActivityService: activityService,
+			AttachmentService: attachmentService,
 			Socket: socket, 
 			Connections: make(map[string]map[string]models.BaseUserModel),
 		}
 		return any(h).(T), nil
 		
+	case *handlers.ApiKeyHandler:
+		repository := &repositories.ApiKeyRepository{DB: conns.DB}
+		service := &services.ApiKeyService{Repository: repository}
+		*h = handlers.ApiKeyHandler{Service: service}
+		return any(h).(T), nil
+
+	case *handlers.NotificationHandler:
+		service := &services.NotificationService{
+			DB: conns.DB,
+			Repository: &repositories.NotificationRepository{DB: conns.DB},
+		}
+		*h = handlers.NotificationHandler{Service: service}
+		return any(h).(T), nil
+
+	case *handlers.AuditHandler:
+		service := &services.AuditService{
+			Repository: &repositories.AuditRepository{DB: conns.DB},
+			DocumentRepository: &repositories.DocumentRepository{DB: conns.DB},
+			UserRepository: &repositories.UserRepository{DB: conns.DB},
+		}
+		*h = handlers.AuditHandler{Service: service}
+		return any(h).(T), nil
+
+	case *handlers.WorkspaceHandler:
+		service := &services.WorkspaceService{
+			Repository: &repositories.WorkspaceRepository{DB: conns.DB},
+			DocumentRepository: &repositories.DocumentRepository{DB: conns.DB},
+			Users: &repositories.UserRepository{DB: conns.DB},
+			Notifications: &repositories.NotificationRepository{DB: conns.DB},
+			Audit: &repositories.AuditRepository{DB: conns.DB},
+			Invites: conns.Invites,
+			MailQueue: conns.Mail,
+		}
+		*h = handlers.WorkspaceHandler{Service: service}
+		return any(h).(T), nil
+
+	case *handlers.FolderHandler:
+		service := &services.FolderService{
+			Repository: &repositories.FolderRepository{DB: conns.DB},
+			DocumentRepository: &repositories.DocumentRepository{DB: conns.DB},
+			Workspaces: &repositories.WorkspaceRepository{DB: conns.DB},
+			Users: &repositories.UserRepository{DB: conns.DB},
+		}
+		*h = handlers.FolderHandler{Service: service}
+		return any(h).(T), nil
+
+	case *handlers.TemplateHandler:
+		service := &services.TemplateService{
+			Repository: &repositories.TemplateRepository{DB: conns.DB},
+			DocumentRepository: &repositories.DocumentRepository{DB: conns.DB},
+			Workspaces: &repositories.WorkspaceRepository{DB: conns.DB},
+		}
+		*h = handlers.TemplateHandler{Service: service}
+		return any(h).(T), nil
+
+	case *handlers.MailTemplateHandler:
+		*h = handlers.MailTemplateHandler{}
+		return any(h).(T), nil
+
+	case *handlers.DevHandler:
+		*h = handlers.DevHandler{}
+		if config.IsDevelopment() && conns.Smtp != nil {
+			if mailbox, ok := conns.Smtp.Mailbox(); ok {
+				h.Mailbox = mailbox
+			}
+		}
+		return any(h).(T), nil
+
+	case *handlers.WebhookHandler:
+		service := &services.WebhookService{
+			Repository: &repositories.WebhookRepository{DB: conns.DB},
+			DocumentRepository: &repositories.DocumentRepository{DB: conns.DB},
+			Client: clients.NewWebhookClient(config.LoadWebhookConfig().AllowPrivateTargets),
+		}
+		*h = handlers.WebhookHandler{Service: service}
+		return any(h).(T), nil
+
 	default:
 		return emptyHandler, fmt.Errorf("undefined handler type: %T", emptyHandler)
 	}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"golang/internal/core/services"
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+
+type ApiKeyHandler struct {
+	Service *services.ApiKeyService
+}
+
+
+func (handler *ApiKeyHandler) CreateApiKey(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	apiKey, err := handler.Service.CreateApiKey(request.Context(), user.Id, request.Body)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, apiKey)
+}
+
+
+func (handler *ApiKeyHandler) GetApiKeys(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	apiKeys, err := handler.Service.GetUserApiKeys(request.Context(), user.Id)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, apiKeys)
+}
+
+
+func (handler *ApiKeyHandler) RevokeApiKey(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	apiKeyId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.RevokeApiKey(request.Context(), user.Id, apiKeyId); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *ApiKeyHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	server.HandleFunc("POST " + baseUrl + "/api-keys", d.Scoped(handler.CreateApiKey, utils.ScopeAccount))
+	server.HandleFunc("GET " + baseUrl + "/api-keys", d.Scoped(handler.GetApiKeys, utils.ScopeAccount))
+	server.HandleFunc("DELETE " + baseUrl + "/api-keys/{id}", d.Scoped(handler.RevokeApiKey, utils.ScopeAccount))
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+
+// attachmentMemory is how much of an upload is buffered in memory; the rest
+// of the file spills to a temporary file.
+const attachmentMemory = 4 << 20
+
+
+// attachmentIds reads the document and attachment ids from the path.
+func attachmentIds(request *http.Request) (int, int, bool) {
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		return 0, 0, false
+	}
+	attachmentId, err := strconv.Atoi(request.PathValue("attachmentId"))
+	if err != nil {
+		return 0, 0, false
+	}
+	return documentId, attachmentId, true
+}
+
+
+// UploadAttachment takes a multipart upload with the file in the "file"
+// field.
+func (handler *DocumentHandler) UploadAttachment(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	request.Body = http.MaxBytesReader(response, request.Body, handler.AttachmentService.MaxSize+(1<<20))
+	if err := request.ParseMultipartForm(attachmentMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierrors.WriteHTTPError(response, &apierrors.ErrAttachmentTooLarge)
+			return
+		}
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+	defer request.MultipartForm.RemoveAll()
+
+	file, header, err := request.FormFile("file")
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+	defer file.Close()
+
+	attachment, apiErr := handler.AttachmentService.UploadAttachment(request.Context(), user.Id, documentId, header.Filename, file)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, attachment)
+}
+
+
+func (handler *DocumentHandler) GetAttachments(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	attachments, apiErr := handler.AttachmentService.GetAttachments(request.Context(), user.Id, documentId)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, attachments)
+}
+
+
+// DownloadAttachment streams the file with its sniffed type. Images are
+// shown inline so they can be embedded in documents; everything else is a
+// download. The sandbox policy keeps a served file from running scripts on
+// our origin.
+func (handler *DocumentHandler) DownloadAttachment(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	documentId, attachmentId, ok := attachmentIds(request)
+	if !ok {
+		response.Header().Set("Content-Type", "application/json")
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	attachment, body, apiErr := handler.AttachmentService.OpenAttachment(request.Context(), user.Id, documentId, attachmentId)
+	if apiErr != nil {
+		response.Header().Set("Content-Type", "application/json")
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+	defer body.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		disposition = "inline"
+	}
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}); header != "" {
+		disposition = header
+	}
+
+	response.Header().Set("Content-Type", attachment.ContentType)
+	response.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	response.Header().Set("Content-Disposition", disposition)
+	response.Header().Set("X-Content-Type-Options", "nosniff")
+	response.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	response.Header().Set("Cache-Control", "private, max-age=3600")
+	response.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(response, body); err != nil {
+		log.Printf("download of attachment %d failed: %v", attachmentId, err)
+	}
+}
+
+
+func (handler *DocumentHandler) DeleteAttachment(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, attachmentId, ok := attachmentIds(request)
+	if !ok {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if apiErr := handler.AttachmentService.DeleteAttachment(request.Context(), user.Id, documentId, attachmentId); apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
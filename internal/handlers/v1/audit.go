@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"golang/internal/core/services"
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+
+type AuditHandler struct {
+	Service *services.AuditService
+}
+
+
+func (handler *AuditHandler) GetDocumentAudit(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	limit, offset := utils.GetLimitAndOffset(request)
+	action := request.URL.Query().Get("action")
+
+	entries, apiErr := handler.Service.GetDocumentAudit(request.Context(), user.Id, documentId, action, limit, offset)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, entries)
+}
+
+
+// ExportAudit streams the audit log as JSON Lines, one entry per line.
+func (handler *AuditHandler) ExportAudit(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	if apiErr := handler.Service.CheckAdmin(request.Context(), user.Id); apiErr != nil {
+		response.Header().Set("Content-Type", "application/json")
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	filter, ok := parseAuditFilter(request)
+	if !ok {
+		response.Header().Set("Content-Type", "application/json")
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidQuery)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/x-ndjson")
+	response.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	response.WriteHeader(http.StatusOK)
+
+	flusher, _ := response.(http.Flusher)
+	encoder := json.NewEncoder(response)
+	written := 0
+
+	err := handler.Service.ExportAudit(request.Context(), filter, func(entry *models.AuditEntryModel) error {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+		written++
+		if flusher != nil && written%500 == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Audit export aborted after %d entries: %v", written, err)
+	}
+}
+
+
+// parseAuditFilter reads since, until (RFC 3339), action and document_id.
+func parseAuditFilter(request *http.Request) (models.AuditFilterModel, bool) {
+	query := request.URL.Query()
+	filter := models.AuditFilterModel{Action: query.Get("action")}
+
+	for name, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, false
+		}
+		*target = &parsed
+	}
+
+	if value := query.Get("document_id"); value != "" {
+		documentId, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, false
+		}
+		filter.DocumentId = &documentId
+	}
+	return filter, true
+}
+
+
+func (handler *AuditHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	server.HandleFunc("GET " + baseUrl + "/documents/{id}/audit", d.Scoped(handler.GetDocumentAudit, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl + "/admin/audit/export", d.Scoped(handler.ExportAudit, utils.ScopeAccount))
+}
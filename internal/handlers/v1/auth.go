@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"golang/internal/core/audit"
 	"golang/internal/core/services"
 	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
 	"golang/internal/infrastructure/errors"
 	"golang/internal/utils"
 	"net/http"
@@ -48,7 +51,7 @@ func (handler *AuthHandler) RefreshToken(response http.ResponseWriter, request *
 	response.Header().Set("Content-Type", "application/json")
 
 	refreshToken := request.URL.Query().Get("refresh_token")
-	user, err := handler.Service.RefreshToken(request.Context(), refreshToken)
+	user, err := handler.Service.RefreshToken(requestContext(request), refreshToken)
 
 	response.WriteHeader(http.StatusAccepted)
 
@@ -64,7 +67,9 @@ func (handler *AuthHandler) RefreshToken(response http.ResponseWriter, request *
 func (handler *AuthHandler) LoginUser(response http.ResponseWriter, request *http.Request) {
 	response.Header().Set("Content-Type", "application/json")
 
-	user, serviceErr := handler.Service.LoginUser(request.Context(), request.Body)
+	user, serviceErr := handler.Service.LoginUser(
+		request.Context(), request.Body, utils.GetClientIP(request), request.UserAgent(),
+	)
 	if serviceErr != nil {
 		apierrors.WriteHTTPError(response, serviceErr)
 		return
@@ -74,9 +79,71 @@ func (handler *AuthHandler) LoginUser(response http.ResponseWriter, request *htt
 }
 
 
+func (handler *AuthHandler) OidcLogin(response http.ResponseWriter, request *http.Request) {
+	redirectUrl, err := handler.Service.StartOidcLogin(request.Context(), request.PathValue("provider"))
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	http.Redirect(response, request, redirectUrl, http.StatusFound)
+}
+
+
+func (handler *AuthHandler) OidcCallback(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+
+	query := request.URL.Query()
+	if query.Get("error") != "" {
+		apierrors.WriteHTTPError(response, &apierrors.ErrOidcLoginFailed)
+		return
+	}
+
+	user, err := handler.Service.FinishOidcLogin(
+		requestContext(request), request.PathValue("provider"), query.Get("state"), query.Get("code"),
+	)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, user)
+}
+
+
+func (handler *AuthHandler) ChangePassword(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	if err := handler.Service.ChangePassword(request.Context(), user.Id, request.Body); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+// requestContext attaches the client address for audit entries on routes
+// that are not wrapped by the auth dependency.
+func requestContext(request *http.Request) context.Context {
+	return audit.WithRequest(request.Context(), utils.GetClientIP(request), request.UserAgent())
+}
+
+
+func (handler *AuthHandler) GetJWKS(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Cache-Control", "public, max-age=300")
+	utils.WriteJSONResponse(response, http.StatusOK, handler.Service.JWKS())
+}
+
+
 func (handler *AuthHandler) SetupRoutes(server *http.ServeMux, baseUrl string, protected *deps.AuthDependency) {
 	server.HandleFunc(baseUrl+"/auth/register", handler.RegisterUser)
 	server.HandleFunc(baseUrl+"/auth/login", handler.LoginUser)
 	server.HandleFunc(baseUrl+"/auth/refresh", handler.RefreshToken)
 	server.HandleFunc(baseUrl+"/auth/current", handler.GetCurrentUser)
+	server.HandleFunc("PUT "+baseUrl+"/auth/password", protected.Scoped(handler.ChangePassword, utils.ScopeAccount))
+	server.HandleFunc("GET "+baseUrl+"/auth/oidc/{provider}/login", handler.OidcLogin)
+	server.HandleFunc("GET "+baseUrl+"/auth/oidc/{provider}/callback", handler.OidcCallback)
+	server.HandleFunc("GET /.well-known/jwks.json", handler.GetJWKS)
 }
\ No newline at end of file
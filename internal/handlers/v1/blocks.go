@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"golang/internal/core/content"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+
+func (handler *DocumentHandler) GetBlocks(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	blocks, serviceErr := handler.DocumentService.GetBlocks(request.Context(), user.Id, documentId)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, blocks)
+}
+
+
+// ReplaceBlocks stores a whole block tree as the document content.
+func (handler *DocumentHandler) ReplaceBlocks(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	request.Body = http.MaxBytesReader(response, request.Body, content.MaxBlocksSize)
+	blocks, serviceErr := handler.DocumentService.ReplaceBlocks(request.Context(), user.Id, documentId, request.Body)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, blocks)
+}
+
+
+// PatchBlocks applies block edit operations to the document content.
+func (handler *DocumentHandler) PatchBlocks(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	request.Body = http.MaxBytesReader(response, request.Body, content.MaxBlocksSize)
+	blocks, serviceErr := handler.DocumentService.PatchBlocks(request.Context(), user.Id, documentId, request.Body)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, blocks)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/clients"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+
+// DevHandler serves development helpers. Its routes are only registered when
+// APP_ENV is development and a capturing mail transport is configured.
+type DevHandler struct {
+	Mailbox clients.Mailbox
+}
+
+
+func (handler *DevHandler) GetMailbox(response http.ResponseWriter, request *http.Request) {
+	messages, err := handler.Mailbox.Messages()
+	if err != nil {
+		log.Printf("Failed to read mailbox: %v", err)
+		apierrors.WriteHTTPError(response, &apierrors.ErrInternalServerError)
+		return
+	}
+
+	if to := strings.ToLower(request.URL.Query().Get("to")); to != "" {
+		messages = slices.DeleteFunc(messages, func(message *models.CapturedMailModel) bool {
+			return !slices.ContainsFunc(message.To, func(address string) bool {
+				return strings.Contains(strings.ToLower(address), to)
+			})
+		})
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, messages)
+}
+
+
+func (handler *DevHandler) ClearMailbox(response http.ResponseWriter, request *http.Request) {
+	if err := handler.Mailbox.Clear(); err != nil {
+		log.Printf("Failed to clear mailbox: %v", err)
+		apierrors.WriteHTTPError(response, &apierrors.ErrInternalServerError)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+// SetupRoutes mounts the helpers under /dev, outside the versioned API.
+func (handler *DevHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	if handler.Mailbox == nil {
+		return
+	}
+
+	log.Printf("Development mailbox available at /dev/mailbox")
+	server.HandleFunc("GET /dev/mailbox", handler.GetMailbox)
+	server.HandleFunc("DELETE /dev/mailbox", handler.ClearMailbox)
+}
@@ -17,6 +17,8 @@ import (
 type DocumentHandler struct {
 	DocumentService 	*services.DocumentService
 	CommentService  	*services.CommentService
+	ActivityService 	*services.ActivityService
+	AttachmentService 	*services.AttachmentService
 	Socket      		*socketio.Server
 	Connections 		map[string]map[string]models.BaseUserModel
 	Mutex 				sync.RWMutex
@@ -71,6 +73,56 @@ func (handler *DocumentHandler) DeleteDocument(response http.ResponseWriter, req
 	response.WriteHeader(http.StatusOK)
 }
 
+func (handler *DocumentHandler) GetTrash(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	limit, offset := utils.GetLimitAndOffset(request)
+	documents, err := handler.DocumentService.GetTrash(request.Context(), user.Id, limit, offset)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, documents)
+}
+
+
+func (handler *DocumentHandler) RestoreDocument(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	document, serviceErr := handler.DocumentService.RestoreDocument(request.Context(), documentId, user.Id)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, document)
+}
+
+
+func (handler *DocumentHandler) PurgeDocument(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.DocumentService.PurgeDocument(request.Context(), documentId, user.Id); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
 func (handler *DocumentHandler) AddDocumentSnapshot(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
 	response.Header().Set("Content-Type", "application/json")
 
@@ -90,22 +142,113 @@ func (handler *DocumentHandler) AddDocumentSnapshot(response http.ResponseWriter
 }
 
 
+func (handler *DocumentHandler) RestoreSnapshot(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	snapshotId, err := strconv.Atoi(request.PathValue("snapshotId"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	document, serviceErr := handler.DocumentService.RestoreSnapshot(request.Context(), user.Id, documentId, snapshotId)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, document)
+}
+
+
+func (handler *DocumentHandler) GetActivity(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.DocumentService.CheckDocumentAccess(request.Context(), user.Id, documentId); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	limit, offset := utils.GetLimitAndOffset(request)
+	feed, serviceErr := handler.ActivityService.GetDocumentActivity(request.Context(), documentId, limit, offset)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, feed)
+}
+
+
 func (handler *DocumentHandler) SendInvite(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
 	response.Header().Set("Content-Type", "application/json")
 
 	documentId, err := strconv.Atoi(request.PathValue("id"))
 	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.DocumentService.SendInvite(request.Context(), user.Id, documentId, request.Body); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusAccepted, map[string]string{"detail": "Invite queued"})
+}
+
+
+func (handler *DocumentHandler) AcceptInvite(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.DocumentService.AcceptInvite(request.Context(), user, documentId, request.PathValue("code")); err != nil {
 		apierrors.WriteHTTPError(response, err)
 		return
 	}
 
-	smtpErr := handler.DocumentService.SendInvite(request.Context(), user.Id, request.PathValue("email"), documentId)
-	if smtpErr != nil {
-		apierrors.WriteHTTPError(response, smtpErr)
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *DocumentHandler) UpdateMemberRole(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	memberId, err := strconv.Atoi(request.PathValue("userId"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.DocumentService.UpdateMemberRole(request.Context(), user.Id, documentId, memberId, request.Body); err != nil {
+		apierrors.WriteHTTPError(response, err)
 		return
 	}
 
-	utils.WriteJSONResponse(response, http.StatusOK, `{"detail": "Invite sent successfully"}`)
+	response.WriteHeader(http.StatusNoContent)
 }
 
 
@@ -244,17 +387,79 @@ func (handler *DocumentHandler) GetCommentsReplies(response http.ResponseWriter,
 }
 
 
+// DuplicateDocument copies a document, optionally with its members.
+func (handler *DocumentHandler) DuplicateDocument(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	document, serviceErr := handler.DocumentService.DuplicateDocument(request.Context(), user.Id, documentId, request.Body)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, document)
+}
+
+
+// TransferOwnership makes another user with access the owner of the document.
+func (handler *DocumentHandler) TransferOwnership(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	document, serviceErr := handler.DocumentService.TransferOwnership(request.Context(), user.Id, documentId, request.Body)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, document)
+}
+
+
 func (handler *DocumentHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
-	server.HandleFunc("POST " + baseUrl+ "/documents", d.Protected(handler.CreateDocument))
-	server.HandleFunc("PUT " + baseUrl+ "/documents", d.Protected(handler.UpdateDocument))
-	server.HandleFunc("DELETE " + baseUrl+ "/documents", d.Protected(handler.DeleteDocument))
-	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/invite", d.Protected(handler.SendInvite))
-	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/snapshot", d.Protected(handler.AddDocumentSnapshot))
-
-	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/comments", d.Protected(handler.AddComment))
-	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/comments", d.Protected(handler.GetComments))
-	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/comments/{commentId}", d.Protected(handler.GetCommentsReplies))
-	server.HandleFunc("PUT " + baseUrl+ "/documents/{documentId}/comments/{commentId}", d.Protected(handler.UpdateComment))
-	server.HandleFunc("DELETE " + baseUrl+ "/documents/{id}/comments/{commentId}", d.Protected(handler.DeleteComment))
-	server.Handle(baseUrl + "documents/ws/{id}", handler.Socket)
+	server.HandleFunc("POST " + baseUrl+ "/documents", d.Scoped(handler.CreateDocument, utils.ScopeDocumentsWrite))
+	server.HandleFunc("PUT " + baseUrl+ "/documents/{id}", d.Scoped(handler.UpdateDocument, utils.ScopeDocumentsWrite))
+	server.HandleFunc("DELETE " + baseUrl+ "/documents/{id}", d.Scoped(handler.DeleteDocument, utils.ScopeDocumentsWrite))
+	server.HandleFunc("GET " + baseUrl+ "/documents/trash", d.Scoped(handler.GetTrash, utils.ScopeDocumentsRead))
+	server.HandleFunc("POST " + baseUrl+ "/documents/import", d.Scoped(handler.ImportDocuments, utils.ScopeDocumentsWrite))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/export", d.Scoped(handler.ExportDocument, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/blocks", d.Scoped(handler.GetBlocks, utils.ScopeDocumentsRead))
+	server.HandleFunc("PUT " + baseUrl+ "/documents/{id}/blocks", d.Scoped(handler.ReplaceBlocks, utils.ScopeDocumentsWrite))
+	server.HandleFunc("PATCH " + baseUrl+ "/documents/{id}/blocks", d.Scoped(handler.PatchBlocks, utils.ScopeDocumentsWrite))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/backlinks", d.Scoped(handler.GetBacklinks, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/links", d.Scoped(handler.GetDocumentLinks, utils.ScopeDocumentsRead))
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/attachments", d.Scoped(handler.UploadAttachment, utils.ScopeDocumentsWrite))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/attachments", d.Scoped(handler.GetAttachments, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/attachments/{attachmentId}", d.Scoped(handler.DownloadAttachment, utils.ScopeDocumentsRead))
+	server.HandleFunc("DELETE " + baseUrl+ "/documents/{id}/attachments/{attachmentId}", d.Scoped(handler.DeleteAttachment, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/restore", d.Scoped(handler.RestoreDocument, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/duplicate", d.Scoped(handler.DuplicateDocument, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/transfer", d.Scoped(handler.TransferOwnership, utils.ScopeDocumentsWrite))
+	server.HandleFunc("DELETE " + baseUrl+ "/documents/trash/{id}", d.Scoped(handler.PurgeDocument, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/invite", d.Scoped(handler.SendInvite, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/invite/{code}", d.Scoped(handler.AcceptInvite, utils.ScopeAccount))
+	server.HandleFunc("PUT " + baseUrl+ "/documents/{id}/members/{userId}", d.Scoped(handler.UpdateMemberRole, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/snapshot", d.Scoped(handler.AddDocumentSnapshot, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/snapshot/{snapshotId}/restore", d.Scoped(handler.RestoreSnapshot, utils.ScopeDocumentsWrite))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/activity", d.Scoped(handler.GetActivity, utils.ScopeDocumentsRead))
+
+	server.HandleFunc("POST " + baseUrl+ "/documents/{id}/comments", d.Scoped(handler.AddComment, utils.ScopeComments))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/comments", d.Scoped(handler.GetComments, utils.ScopeComments))
+	server.HandleFunc("GET " + baseUrl+ "/documents/{id}/comments/{commentId}", d.Scoped(handler.GetCommentsReplies, utils.ScopeComments))
+	server.HandleFunc("PUT " + baseUrl+ "/documents/{documentId}/comments/{commentId}", d.Scoped(handler.UpdateComment, utils.ScopeComments))
+	server.HandleFunc("DELETE " + baseUrl+ "/documents/{id}/comments/{commentId}", d.Scoped(handler.DeleteComment, utils.ScopeComments))
+	// Socket.io only talks to the exact path (the transport travels in the
+	// query), and a subtree pattern would clash with /documents/{id}/... routes.
+	server.Handle(baseUrl + "/documents/ws/{$}", handler.Socket)
 }
\ No newline at end of file
@@ -27,8 +27,12 @@ func (handler *DocumentHandler) notifyUsers(documentId string) {
 }
 
 
+// HandleConnect puts the connection in its user's room, which receives the
+// user's notifications.
 func (handler *DocumentHandler) HandleConnect(s socketio.Conn) error {
-	s.SetContext("")
+	if user, ok := s.Context().(*models.BaseUserModel); ok {
+		s.Join("user_" + strconv.Itoa(user.Id))
+	}
 	return nil
 }
 
@@ -123,6 +127,15 @@ func (handler *DocumentHandler) RunWebsocket() {
 }
 
 
+// RunActivityPush forwards new feed entries to the "doc_<id>" room of the
+// document they belong to.
+func (handler *DocumentHandler) RunActivityPush() {
+	go handler.ActivityService.Listen(context.Background(), func(activity *models.ActivityModel) {
+		handler.Socket.BroadcastToRoom("/", "doc_" + strconv.Itoa(activity.DocumentId), "activity", activity)
+	})
+}
+
+
 func (handler *DocumentHandler) SetupSocket(server *socketio.Server, d *deps.AuthDependency) {
 	handler.Socket.OnConnect("/", d.ProtectConnect(handler.HandleConnect))
 	handler.Socket.OnEvent("/", "join", d.ProtectEvent(handler.HandleJoinDocument))
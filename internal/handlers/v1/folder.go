@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"golang/internal/core/services"
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+
+type FolderHandler struct {
+	Service *services.FolderService
+}
+
+
+// queryId reads an optional integer query parameter; ok is false when it is
+// present but malformed.
+func queryId(request *http.Request, name string) (*int, bool) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return nil, true
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, false
+	}
+	return &id, true
+}
+
+
+func (handler *FolderHandler) CreateFolder(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folder, err := handler.Service.CreateFolder(request.Context(), user.Id, request.Body)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, folder)
+}
+
+
+func (handler *FolderHandler) GetRootFolders(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, ok := queryId(request, "workspace_id")
+	if !ok {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidQuery)
+		return
+	}
+
+	limit, offset := utils.GetLimitAndOffset(request)
+	folders, err := handler.Service.GetRootFolders(request.Context(), user.Id, workspaceId, limit, offset)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, folders)
+}
+
+
+func (handler *FolderHandler) GetFolder(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folderId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	folder, apiErr := handler.Service.GetFolder(request.Context(), user.Id, folderId)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, folder)
+}
+
+
+func (handler *FolderHandler) GetChildren(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folderId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	limit, offset := utils.GetLimitAndOffset(request)
+	items, apiErr := handler.Service.GetChildren(request.Context(), user.Id, folderId, limit, offset)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, items)
+}
+
+
+func (handler *FolderHandler) RenameFolder(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folderId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	folder, apiErr := handler.Service.RenameFolder(request.Context(), user.Id, folderId, request.Body)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, folder)
+}
+
+
+func (handler *FolderHandler) MoveFolder(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folderId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	folder, apiErr := handler.Service.MoveFolder(request.Context(), user.Id, folderId, request.Body)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, folder)
+}
+
+
+func (handler *FolderHandler) DeleteFolder(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folderId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.DeleteFolder(request.Context(), user.Id, folderId); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *FolderHandler) ShareFolder(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folderId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.ShareFolder(request.Context(), user.Id, folderId, request.Body); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *FolderHandler) UnshareFolder(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folderId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	memberId, err := strconv.Atoi(request.PathValue("userId"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.UnshareFolder(request.Context(), user.Id, folderId, memberId); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *FolderHandler) MoveDocument(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	document, apiErr := handler.Service.MoveDocument(request.Context(), user.Id, documentId, request.Body)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, document)
+}
+
+
+func (handler *FolderHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	server.HandleFunc("POST " + baseUrl + "/folders", d.Scoped(handler.CreateFolder, utils.ScopeDocumentsWrite))
+	server.HandleFunc("GET " + baseUrl + "/folders", d.Scoped(handler.GetRootFolders, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl + "/folders/{id}", d.Scoped(handler.GetFolder, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl + "/folders/{id}/children", d.Scoped(handler.GetChildren, utils.ScopeDocumentsRead))
+	server.HandleFunc("PUT " + baseUrl + "/folders/{id}", d.Scoped(handler.RenameFolder, utils.ScopeDocumentsWrite))
+	server.HandleFunc("PUT " + baseUrl + "/folders/{id}/parent", d.Scoped(handler.MoveFolder, utils.ScopeDocumentsWrite))
+	server.HandleFunc("DELETE " + baseUrl + "/folders/{id}", d.Scoped(handler.DeleteFolder, utils.ScopeDocumentsWrite))
+	server.HandleFunc("PUT " + baseUrl + "/folders/{id}/members", d.Scoped(handler.ShareFolder, utils.ScopeDocumentsWrite))
+	server.HandleFunc("DELETE " + baseUrl + "/folders/{id}/members/{userId}", d.Scoped(handler.UnshareFolder, utils.ScopeDocumentsWrite))
+	server.HandleFunc("PUT " + baseUrl + "/documents/{id}/folder", d.Scoped(handler.MoveDocument, utils.ScopeDocumentsWrite))
+}
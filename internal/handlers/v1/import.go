@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"golang/internal/core/content"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+
+// importMemory is how much of an upload is buffered in memory; the rest of
+// the file spills to a temporary file.
+const importMemory = 8 << 20
+
+
+// ImportDocuments takes a multipart upload with a Markdown, HTML or block JSON file, or
+// a zip archive of them, in the "file" field and files the documents in
+// the folder_id folder or the workspace_id workspace.
+func (handler *DocumentHandler) ImportDocuments(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	folderId, ok := queryId(request, "folder_id")
+	if !ok {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidQuery)
+		return
+	}
+	workspaceId, ok := queryId(request, "workspace_id")
+	if !ok {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidQuery)
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	request.Body = http.MaxBytesReader(response, request.Body, content.MaxArchiveSize+(1<<20))
+	if err := request.ParseMultipartForm(importMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierrors.WriteHTTPError(response, &apierrors.ErrImportTooLarge)
+			return
+		}
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+	defer request.MultipartForm.RemoveAll()
+
+	file, header, err := request.FormFile("file")
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+	defer file.Close()
+
+	var result *models.ImportResultModel
+	var apiErr *apierrors.APIError
+	switch {
+	case strings.EqualFold(path.Ext(header.Filename), ".zip"):
+		result, apiErr = handler.DocumentService.ImportArchive(request.Context(), user.Id, folderId, workspaceId, file, header.Size)
+	case content.IsImportName(header.Filename):
+		result, apiErr = handler.DocumentService.ImportFile(request.Context(), user.Id, folderId, workspaceId, header.Filename, file)
+	default:
+		apiErr = &apierrors.ErrInvalidImport
+	}
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, result)
+}
+
+
+// ExportDocument streams the document as a download in the format given by
+// the format query parameter, Markdown by default.
+func (handler *DocumentHandler) ExportDocument(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		response.Header().Set("Content-Type", "application/json")
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	formatName := request.URL.Query().Get("format")
+	if formatName == "" {
+		formatName = content.FormatMarkdown
+	}
+
+	document, format, apiErr := handler.DocumentService.ExportDocument(request.Context(), user.Id, documentId, formatName)
+	if apiErr != nil {
+		response.Header().Set("Content-Type", "application/json")
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	response.Header().Set("Content-Type", format.ContentType)
+	response.Header().Set("Content-Disposition", format.Disposition(document.Title))
+	response.WriteHeader(http.StatusOK)
+	if err := format.Write(response, document); err != nil {
+		log.Printf("export of document %d failed: %v", documentId, err)
+	}
+}
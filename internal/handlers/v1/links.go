@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+
+func (handler *DocumentHandler) GetBacklinks(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	limit, offset := utils.GetLimitAndOffset(request)
+	backlinks, serviceErr := handler.DocumentService.GetBacklinks(request.Context(), user.Id, documentId, limit, offset)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, backlinks)
+}
+
+
+// GetDocumentLinks reports the outgoing links of a document; ?broken=true
+// keeps only those whose target is missing or inaccessible.
+func (handler *DocumentHandler) GetDocumentLinks(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	brokenOnly := request.URL.Query().Get("broken") == "true"
+	links, serviceErr := handler.DocumentService.GetDocumentLinks(request.Context(), user.Id, documentId, brokenOnly)
+	if serviceErr != nil {
+		apierrors.WriteHTTPError(response, serviceErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, links)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"golang/templates"
+	"log"
+	"net/http"
+	"slices"
+)
+
+
+// MailTemplateHandler lets designers preview transactional emails rendered
+// with sample data.
+type MailTemplateHandler struct{}
+
+
+func (handler *MailTemplateHandler) GetMailTemplates(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	utils.WriteJSONResponse(response, http.StatusOK, map[string][]string{
+		"templates": templates.Names(),
+		"locales":   templates.Locales,
+	})
+}
+
+
+func (handler *MailTemplateHandler) PreviewMailTemplate(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	name := request.PathValue("name")
+	if !slices.Contains(templates.Names(), name) {
+		apierrors.WriteHTTPError(response, apierrors.ErrItemNotFound("mail template"))
+		return
+	}
+
+	email, err := templates.Render(name, request.URL.Query().Get("locale"), templates.Sample(name))
+	if err != nil {
+		log.Printf("Failed to render mail template %s: %v", name, err)
+		apierrors.WriteHTTPError(response, &apierrors.ErrInternalServerError)
+		return
+	}
+
+	response.Header().Set("X-Mail-Subject", email.Subject)
+	if request.URL.Query().Get("format") == "text" {
+		response.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		response.Write([]byte(email.Text))
+		return
+	}
+	response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	response.Write([]byte(email.HTML))
+}
+
+
+func (handler *MailTemplateHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	server.HandleFunc("GET " + baseUrl + "/mail-templates", d.Scoped(handler.GetMailTemplates, utils.ScopeAccount))
+	server.HandleFunc("GET " + baseUrl + "/mail-templates/{name}/preview", d.Scoped(handler.PreviewMailTemplate, utils.ScopeAccount))
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"golang/internal/core/services"
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/googollee/go-socket.io"
+)
+
+
+type NotificationHandler struct {
+	Service *services.NotificationService
+	Socket  *socketio.Server
+}
+
+
+func (handler *NotificationHandler) GetNotifications(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	limit, offset := utils.GetLimitAndOffset(request)
+	unreadOnly := request.URL.Query().Get("unread") == "true"
+
+	notifications, err := handler.Service.GetUserNotifications(request.Context(), user.Id, unreadOnly, limit, offset)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, notifications)
+}
+
+
+func (handler *NotificationHandler) GetUnreadCount(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	count, err := handler.Service.CountUnread(request.Context(), user.Id)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, count)
+}
+
+
+func (handler *NotificationHandler) MarkRead(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	notificationId, err := strconv.ParseInt(request.PathValue("id"), 10, 64)
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.MarkRead(request.Context(), user.Id, notificationId); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *NotificationHandler) MarkAllRead(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	count, err := handler.Service.MarkAllRead(request.Context(), user.Id)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, count)
+}
+
+
+// RunPush forwards new notifications to the "user_<id>" room of socket,
+// which every authenticated connection joins.
+func (handler *NotificationHandler) RunPush(socket *socketio.Server) {
+	handler.Socket = socket
+	go handler.Service.Listen(context.Background(), func(userId int, notification *models.NotificationModel) {
+		handler.Socket.BroadcastToRoom("/", "user_" + strconv.Itoa(userId), "notification", notification)
+	})
+}
+
+
+func (handler *NotificationHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	server.HandleFunc("GET " + baseUrl + "/notifications", d.Scoped(handler.GetNotifications, utils.ScopeAccount))
+	server.HandleFunc("GET " + baseUrl + "/notifications/unread-count", d.Scoped(handler.GetUnreadCount, utils.ScopeAccount))
+	server.HandleFunc("POST " + baseUrl + "/notifications/{id}/read", d.Scoped(handler.MarkRead, utils.ScopeAccount))
+	server.HandleFunc("POST " + baseUrl + "/notifications/read-all", d.Scoped(handler.MarkAllRead, utils.ScopeAccount))
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"golang/internal/core/services"
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+
+type TemplateHandler struct {
+	Service *services.TemplateService
+}
+
+
+func (handler *TemplateHandler) GetTemplates(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, ok := queryId(request, "workspace_id")
+	if !ok {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidQuery)
+		return
+	}
+
+	templates, err := handler.Service.GetTemplates(request.Context(), user.Id, workspaceId)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, templates)
+}
+
+
+func (handler *TemplateHandler) GetTemplate(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	templateId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	template, apiErr := handler.Service.GetTemplate(request.Context(), user.Id, templateId)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, template)
+}
+
+
+func (handler *TemplateHandler) CreateTemplate(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	template, err := handler.Service.CreateTemplate(request.Context(), user.Id, request.Body)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, template)
+}
+
+
+func (handler *TemplateHandler) UpdateTemplate(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	templateId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	template, apiErr := handler.Service.UpdateTemplate(request.Context(), user.Id, templateId, request.Body)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, template)
+}
+
+
+func (handler *TemplateHandler) DeleteTemplate(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	templateId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if apiErr := handler.Service.DeleteTemplate(request.Context(), user.Id, templateId); apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+// SaveAsTemplate saves a document as a personal or workspace template.
+func (handler *TemplateHandler) SaveAsTemplate(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	template, apiErr := handler.Service.SaveAsTemplate(request.Context(), user.Id, documentId, request.Body)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, template)
+}
+
+
+func (handler *TemplateHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	server.HandleFunc("GET " + baseUrl + "/templates", d.Scoped(handler.GetTemplates, utils.ScopeDocumentsRead))
+	server.HandleFunc("POST " + baseUrl + "/templates", d.Scoped(handler.CreateTemplate, utils.ScopeDocumentsWrite))
+	server.HandleFunc("GET " + baseUrl + "/templates/{id}", d.Scoped(handler.GetTemplate, utils.ScopeDocumentsRead))
+	server.HandleFunc("PUT " + baseUrl + "/templates/{id}", d.Scoped(handler.UpdateTemplate, utils.ScopeDocumentsWrite))
+	server.HandleFunc("DELETE " + baseUrl + "/templates/{id}", d.Scoped(handler.DeleteTemplate, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl + "/documents/{id}/template", d.Scoped(handler.SaveAsTemplate, utils.ScopeDocumentsWrite))
+}
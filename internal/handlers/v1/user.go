@@ -63,18 +63,57 @@ func (handler *UserHandler) DeleteUser(response http.ResponseWriter, request *ht
 }
 
 
-func (handler *UserHandler) GetUserDocuments(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+func (handler *UserHandler) GetDigestSettings(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
 	response.Header().Set("Content-Type", "application/json")
 
-	limit, offset := utils.GetLimitAndOffset(request)
+	settings, err := handler.UserService.GetDigestSettings(request.Context(), user.Id)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
 
-	userId, err := strconv.Atoi(request.PathValue("id"))
+	utils.WriteJSONResponse(response, http.StatusOK, settings)
+}
+
+
+func (handler *UserHandler) UpdateDigestSettings(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	settings, err := handler.UserService.UpdateDigestSettings(request.Context(), user.Id, request.Body)
 	if err != nil {
 		apierrors.WriteHTTPError(response, err)
 		return
 	}
 
-	documents, serviceErr := handler.DocumentService.GetUserDocuments(request.Context(), userId, limit, offset)
+	utils.WriteJSONResponse(response, http.StatusOK, settings)
+}
+
+
+func (handler *UserHandler) UpdateLocale(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	settings, err := handler.UserService.UpdateLocale(request.Context(), user.Id, request.Body)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, settings)
+}
+
+
+func (handler *UserHandler) GetUserDocuments(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	limit, offset := utils.GetLimitAndOffset(request)
+
+	folderId, ok := queryId(request, "folder_id")
+	if !ok {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidQuery)
+		return
+	}
+
+	documents, serviceErr := handler.DocumentService.GetUserDocuments(request.Context(), user.Id, folderId, limit, offset)
 	if serviceErr != nil {
 		apierrors.WriteHTTPError(response, serviceErr)
 		return
@@ -85,8 +124,11 @@ func (handler *UserHandler) GetUserDocuments(response http.ResponseWriter, reque
 
 
 func (handler *UserHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
-	server.HandleFunc("GET " + baseUrl+ "/user/{id}", d.Protected(handler.GetUserById))
-	server.HandleFunc("PUT " + baseUrl + "/user", d.Protected(handler.UpdateUser))
-	server.HandleFunc("DELETE " + baseUrl + "/user", d.Protected(handler.DeleteUser))
-	server.HandleFunc("GET " + baseUrl + "/user", d.Protected(handler.GetUserDocuments))
+	server.HandleFunc("GET " + baseUrl+ "/user/{id}", d.Scoped(handler.GetUserById, utils.ScopeDocumentsRead))
+	server.HandleFunc("PUT " + baseUrl + "/user", d.Scoped(handler.UpdateUser, utils.ScopeAccount))
+	server.HandleFunc("DELETE " + baseUrl + "/user", d.Scoped(handler.DeleteUser, utils.ScopeAccount))
+	server.HandleFunc("GET " + baseUrl + "/user/digest", d.Scoped(handler.GetDigestSettings, utils.ScopeAccount))
+	server.HandleFunc("PUT " + baseUrl + "/user/digest", d.Scoped(handler.UpdateDigestSettings, utils.ScopeAccount))
+	server.HandleFunc("PUT " + baseUrl + "/user/locale", d.Scoped(handler.UpdateLocale, utils.ScopeAccount))
+	server.HandleFunc("GET " + baseUrl + "/user", d.Scoped(handler.GetUserDocuments, utils.ScopeDocumentsRead))
 }
\ No newline at end of file
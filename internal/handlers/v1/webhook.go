@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"golang/internal/core/services"
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+
+type WebhookHandler struct {
+	Service *services.WebhookService
+}
+
+
+func (handler *WebhookHandler) CreateWebhook(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	webhook, err := handler.Service.CreateWebhook(request.Context(), user.Id, request.Body)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, webhook)
+}
+
+
+func (handler *WebhookHandler) GetWebhooks(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	webhooks, err := handler.Service.GetUserWebhooks(request.Context(), user.Id)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, webhooks)
+}
+
+
+func (handler *WebhookHandler) DeleteWebhook(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	webhookId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.DeleteWebhook(request.Context(), user.Id, webhookId); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *WebhookHandler) GetDeliveries(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	webhookId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	deliveries, apiErr := handler.Service.GetDeliveries(request.Context(), user.Id, webhookId)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, deliveries)
+}
+
+
+func (handler *WebhookHandler) SendTestEvent(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	webhookId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	delivery, apiErr := handler.Service.SendTestEvent(request.Context(), user.Id, webhookId)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, delivery)
+}
+
+
+func (handler *WebhookHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	server.HandleFunc("POST " + baseUrl + "/webhooks", d.Scoped(handler.CreateWebhook, utils.ScopeAccount))
+	server.HandleFunc("GET " + baseUrl + "/webhooks", d.Scoped(handler.GetWebhooks, utils.ScopeAccount))
+	server.HandleFunc("DELETE " + baseUrl + "/webhooks/{id}", d.Scoped(handler.DeleteWebhook, utils.ScopeAccount))
+	server.HandleFunc("GET " + baseUrl + "/webhooks/{id}/deliveries", d.Scoped(handler.GetDeliveries, utils.ScopeAccount))
+	server.HandleFunc("POST " + baseUrl + "/webhooks/{id}/test", d.Scoped(handler.SendTestEvent, utils.ScopeAccount))
+}
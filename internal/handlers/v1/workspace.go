@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"golang/internal/core/services"
+	"golang/internal/handlers/dependencies"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/infrastructure/errors"
+	"golang/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+
+type WorkspaceHandler struct {
+	Service *services.WorkspaceService
+}
+
+
+func (handler *WorkspaceHandler) CreateWorkspace(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspace, err := handler.Service.CreateWorkspace(request.Context(), user.Id, request.Body)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusCreated, workspace)
+}
+
+
+func (handler *WorkspaceHandler) GetWorkspaces(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaces, err := handler.Service.GetUserWorkspaces(request.Context(), user.Id)
+	if err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, workspaces)
+}
+
+
+func (handler *WorkspaceHandler) GetWorkspace(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	workspace, apiErr := handler.Service.GetWorkspace(request.Context(), user.Id, workspaceId)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, workspace)
+}
+
+
+func (handler *WorkspaceHandler) GetMembers(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	members, apiErr := handler.Service.GetMembers(request.Context(), user.Id, workspaceId)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, members)
+}
+
+
+func (handler *WorkspaceHandler) GetDocuments(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	limit, offset := utils.GetLimitAndOffset(request)
+	documents, apiErr := handler.Service.GetDocuments(request.Context(), user.Id, workspaceId, limit, offset)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, documents)
+}
+
+
+func (handler *WorkspaceHandler) SendInvite(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.SendInvite(request.Context(), user, workspaceId, request.Body); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusAccepted, map[string]string{"detail": "Invite queued"})
+}
+
+
+func (handler *WorkspaceHandler) AcceptInvite(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	workspace, apiErr := handler.Service.AcceptInvite(request.Context(), user, workspaceId, request.PathValue("code"))
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, workspace)
+}
+
+
+func (handler *WorkspaceHandler) UpdateMemberRole(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	memberId, err := strconv.Atoi(request.PathValue("userId"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.UpdateMemberRole(request.Context(), user.Id, workspaceId, memberId, request.Body); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *WorkspaceHandler) RemoveMember(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	workspaceId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	memberId, err := strconv.Atoi(request.PathValue("userId"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	if err := handler.Service.RemoveMember(request.Context(), user.Id, workspaceId, memberId); err != nil {
+		apierrors.WriteHTTPError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+
+func (handler *WorkspaceHandler) MoveDocument(response http.ResponseWriter, request *http.Request, user *models.BaseUserModel) {
+	response.Header().Set("Content-Type", "application/json")
+
+	documentId, err := strconv.Atoi(request.PathValue("id"))
+	if err != nil {
+		apierrors.WriteHTTPError(response, &apierrors.ErrInvalidRequestBody)
+		return
+	}
+
+	document, apiErr := handler.Service.MoveDocument(request.Context(), user.Id, documentId, request.Body)
+	if apiErr != nil {
+		apierrors.WriteHTTPError(response, apiErr)
+		return
+	}
+
+	utils.WriteJSONResponse(response, http.StatusOK, document)
+}
+
+
+func (handler *WorkspaceHandler) SetupRoutes(server *http.ServeMux, baseUrl string, d *deps.AuthDependency) {
+	server.HandleFunc("POST " + baseUrl + "/workspaces", d.Scoped(handler.CreateWorkspace, utils.ScopeDocumentsWrite))
+	server.HandleFunc("GET " + baseUrl + "/workspaces", d.Scoped(handler.GetWorkspaces, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl + "/workspaces/{id}", d.Scoped(handler.GetWorkspace, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl + "/workspaces/{id}/members", d.Scoped(handler.GetMembers, utils.ScopeDocumentsRead))
+	server.HandleFunc("GET " + baseUrl + "/workspaces/{id}/documents", d.Scoped(handler.GetDocuments, utils.ScopeDocumentsRead))
+	server.HandleFunc("POST " + baseUrl + "/workspaces/{id}/invite", d.Scoped(handler.SendInvite, utils.ScopeDocumentsWrite))
+	server.HandleFunc("POST " + baseUrl + "/workspaces/{id}/invite/{code}", d.Scoped(handler.AcceptInvite, utils.ScopeAccount))
+	server.HandleFunc("PUT " + baseUrl + "/workspaces/{id}/members/{userId}", d.Scoped(handler.UpdateMemberRole, utils.ScopeDocumentsWrite))
+	server.HandleFunc("DELETE " + baseUrl + "/workspaces/{id}/members/{userId}", d.Scoped(handler.RemoveMember, utils.ScopeDocumentsWrite))
+	server.HandleFunc("PUT " + baseUrl + "/documents/{id}/workspace", d.Scoped(handler.MoveDocument, utils.ScopeDocumentsWrite))
+}
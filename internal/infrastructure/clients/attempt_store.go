@@ -0,0 +1,184 @@
+package clients
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+
+// AttemptStore keeps expiring counters and locks for rate limiting.
+type AttemptStore interface {
+	Increment(ctx context.Context, key string, window time.Duration) (int, error)
+	Reset(ctx context.Context, key string) error
+	Lock(ctx context.Context, key string, duration time.Duration) error
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+}
+
+
+type RedisAttemptStore struct {
+	Client *RedisClient
+}
+
+
+func (store *RedisAttemptStore) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	count, err := store.Client.Increment(ctx, key, window)
+	return int(count), err
+}
+
+
+func (store *RedisAttemptStore) Reset(ctx context.Context, key string) error {
+	return store.Client.Delete(ctx, key)
+}
+
+
+func (store *RedisAttemptStore) Lock(ctx context.Context, key string, duration time.Duration) error {
+	return store.Client.Set(ctx, key, strconv.FormatInt(time.Now().Add(duration).Unix(), 10), duration)
+}
+
+
+func (store *RedisAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := store.Client.TTL(ctx, key)
+	if err == redis.Nil || ttl < 0 {
+		return 0, nil
+	}
+	return ttl, err
+}
+
+
+type memoryAttempt struct {
+	Count     int
+	ExpiresAt time.Time
+}
+
+
+type MemoryAttemptStore struct {
+	mutex   sync.Mutex
+	entries map[string]memoryAttempt
+}
+
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{entries: make(map[string]memoryAttempt)}
+}
+
+
+func (store *MemoryAttemptStore) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.evict(now)
+
+	entry, ok := store.entries[key]
+	if !ok {
+		entry.ExpiresAt = now.Add(window)
+	}
+	entry.Count++
+	store.entries[key] = entry
+	return entry.Count, nil
+}
+
+
+func (store *MemoryAttemptStore) Reset(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.entries, key)
+	return nil
+}
+
+
+func (store *MemoryAttemptStore) Lock(ctx context.Context, key string, duration time.Duration) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.entries[key] = memoryAttempt{Count: 1, ExpiresAt: time.Now().Add(duration)}
+	return nil
+}
+
+
+func (store *MemoryAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry, ok := store.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	remaining := time.Until(entry.ExpiresAt)
+	if remaining <= 0 {
+		delete(store.entries, key)
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+
+func (store *MemoryAttemptStore) evict(now time.Time) {
+	for key, entry := range store.entries {
+		if now.After(entry.ExpiresAt) {
+			delete(store.entries, key)
+		}
+	}
+}
+
+
+// FallbackAttemptStore uses Primary (Redis) and switches to Fallback (memory)
+// for any call where Primary fails, so an outage degrades to per-instance
+// limits instead of disabling them.
+type FallbackAttemptStore struct {
+	Primary  AttemptStore
+	Fallback AttemptStore
+}
+
+
+func NewAttemptStore(redisClient *RedisClient) AttemptStore {
+	memory := NewMemoryAttemptStore()
+	if redisClient == nil {
+		return memory
+	}
+	return &FallbackAttemptStore{Primary: &RedisAttemptStore{Client: redisClient}, Fallback: memory}
+}
+
+
+func (store *FallbackAttemptStore) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	count, err := store.Primary.Increment(ctx, key, window)
+	if err != nil {
+		log.Printf("Attempt store unavailable, using in-memory fallback: %v", err)
+		return store.Fallback.Increment(ctx, key, window)
+	}
+	return count, nil
+}
+
+
+func (store *FallbackAttemptStore) Reset(ctx context.Context, key string) error {
+	store.Fallback.Reset(ctx, key)
+	return store.Primary.Reset(ctx, key)
+}
+
+
+func (store *FallbackAttemptStore) Lock(ctx context.Context, key string, duration time.Duration) error {
+	store.Fallback.Lock(ctx, key, duration)
+	if err := store.Primary.Lock(ctx, key, duration); err != nil {
+		log.Printf("Attempt store unavailable, lock kept in memory only: %v", err)
+	}
+	return nil
+}
+
+
+func (store *FallbackAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	remaining, err := store.Primary.LockedFor(ctx, key)
+	if err != nil {
+		log.Printf("Attempt store unavailable, using in-memory fallback: %v", err)
+		return store.Fallback.LockedFor(ctx, key)
+	}
+	if remaining == 0 {
+		return store.Fallback.LockedFor(ctx, key)
+	}
+	return remaining, nil
+}
@@ -0,0 +1,173 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+
+// failingAttemptStore stands in for an unreachable Redis.
+type failingAttemptStore struct{}
+
+
+var errUnavailable = errors.New("unavailable")
+
+
+func (failingAttemptStore) Increment(context.Context, string, time.Duration) (int, error) {
+	return 0, errUnavailable
+}
+
+
+func (failingAttemptStore) Reset(context.Context, string) error {
+	return errUnavailable
+}
+
+
+func (failingAttemptStore) Lock(context.Context, string, time.Duration) error {
+	return errUnavailable
+}
+
+
+func (failingAttemptStore) LockedFor(context.Context, string) (time.Duration, error) {
+	return 0, errUnavailable
+}
+
+
+func TestMemoryAttemptStoreIncrement(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		wait   time.Duration
+		want   []int
+	}{
+		{name: "counts within the window", window: time.Minute, want: []int{1, 2, 3}},
+		{name: "restarts after the window", window: 10 * time.Millisecond, wait: 20 * time.Millisecond, want: []int{1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryAttemptStore()
+
+			for i, want := range tt.want {
+				if i > 0 {
+					time.Sleep(tt.wait)
+				}
+				got, err := store.Increment(ctx, "key", tt.window)
+				if err != nil {
+					t.Fatalf("Increment: %v", err)
+				}
+				if got != want {
+					t.Errorf("Increment #%d = %d, want %d", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+
+func TestMemoryAttemptStoreWindowStartsAtFirstAttempt(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAttemptStore()
+
+	store.Increment(ctx, "key", 100*time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+	// Further attempts do not extend the window.
+	store.Increment(ctx, "key", 100*time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+
+	if got, _ := store.Increment(ctx, "key", 100*time.Millisecond); got != 1 {
+		t.Errorf("Increment after the first window = %d, want 1", got)
+	}
+}
+
+
+func TestMemoryAttemptStoreReset(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAttemptStore()
+
+	store.Increment(ctx, "key", time.Minute)
+	store.Increment(ctx, "other", time.Minute)
+	store.Reset(ctx, "key")
+
+	if got, _ := store.Increment(ctx, "key", time.Minute); got != 1 {
+		t.Errorf("Increment after Reset = %d, want 1", got)
+	}
+	if got, _ := store.Increment(ctx, "other", time.Minute); got != 2 {
+		t.Errorf("Reset touched another key: Increment = %d, want 2", got)
+	}
+}
+
+
+func TestMemoryAttemptStoreLock(t *testing.T) {
+	tests := []struct {
+		name       string
+		duration   time.Duration
+		wait       time.Duration
+		wantLocked bool
+	}{
+		{name: "locked", duration: time.Minute, wantLocked: true},
+		{name: "expired", duration: 10 * time.Millisecond, wait: 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryAttemptStore()
+
+			store.Lock(ctx, "key", tt.duration)
+			time.Sleep(tt.wait)
+
+			remaining, err := store.LockedFor(ctx, "key")
+			if err != nil {
+				t.Fatalf("LockedFor: %v", err)
+			}
+			if locked := remaining > 0; locked != tt.wantLocked {
+				t.Fatalf("LockedFor = %v, want locked %v", remaining, tt.wantLocked)
+			}
+			if remaining > tt.duration {
+				t.Errorf("LockedFor = %v, longer than the lock %v", remaining, tt.duration)
+			}
+		})
+	}
+
+	if remaining, _ := NewMemoryAttemptStore().LockedFor(context.Background(), "missing"); remaining != 0 {
+		t.Errorf("LockedFor of an unknown key = %v, want 0", remaining)
+	}
+}
+
+
+func TestFallbackAttemptStore(t *testing.T) {
+	ctx := context.Background()
+	store := &FallbackAttemptStore{Primary: failingAttemptStore{}, Fallback: NewMemoryAttemptStore()}
+
+	for want := 1; want <= 3; want++ {
+		got, err := store.Increment(ctx, "key", time.Minute)
+		if err != nil {
+			t.Fatalf("Increment: %v", err)
+		}
+		if got != want {
+			t.Errorf("Increment = %d, want %d", got, want)
+		}
+	}
+
+	if err := store.Lock(ctx, "lock", time.Minute); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	remaining, err := store.LockedFor(ctx, "lock")
+	if err != nil {
+		t.Fatalf("LockedFor: %v", err)
+	}
+	if remaining <= 0 {
+		t.Error("lock taken during an outage is not enforced")
+	}
+}
+
+
+func TestNewAttemptStore(t *testing.T) {
+	if _, ok := NewAttemptStore(nil).(*MemoryAttemptStore); !ok {
+		t.Error("NewAttemptStore(nil) is not the in-memory store")
+	}
+}
@@ -0,0 +1,178 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"golang/internal/infrastructure/config"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3DateFormat    = "20060102T150405Z"
+	s3ResponseLimit = 1024
+)
+
+
+// S3BlobStore keeps blobs in a bucket of an S3-compatible service. Objects
+// are addressed path-style, {endpoint}/{bucket}/{key}, which MinIO and the
+// local stand-in in cmd/s3-stub understand.
+type S3BlobStore struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	HTTP      *http.Client
+}
+
+
+func NewS3BlobStore(cfg *config.StorageConfig) *S3BlobStore {
+	return &S3BlobStore{
+		Endpoint:  cfg.S3Endpoint,
+		Region:    cfg.S3Region,
+		Bucket:    cfg.S3Bucket,
+		AccessKey: cfg.S3AccessKey,
+		SecretKey: cfg.S3SecretKey,
+		HTTP:      &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+
+func hashHex(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+
+// SignS3Request signs request with AWS Signature Version 4 for the s3
+// service, setting the Host, X-Amz-Date and Authorization headers. The
+// X-Amz-Content-Sha256 header must already hold the hex SHA-256 of the body.
+// Receivers verify a request by signing a copy with the same date and
+// comparing the Authorization headers.
+func SignS3Request(request *http.Request, accessKey string, secretKey string, region string, date time.Time) {
+	host := request.Host
+	if host == "" {
+		host = request.URL.Host
+	}
+	amzDate := date.UTC().Format(s3DateFormat)
+	request.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": request.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		strings.ReplaceAll(request.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		headers["x-amz-content-sha256"],
+	}, "\n")
+
+	scope := amzDate[:8] + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), amzDate[:8])
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKey, scope, signedHeaders, signature,
+	))
+}
+
+
+func (store *S3BlobStore) do(ctx context.Context, method string, key string, body []byte, contentType string) (*http.Response, error) {
+	target := store.Endpoint + "/" + url.PathEscape(store.Bucket) + "/" + key
+	request, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	request.Header.Set("X-Amz-Content-Sha256", hashHex(body))
+	SignS3Request(request, store.AccessKey, store.SecretKey, store.Region, time.Now())
+
+	response, err := store.HTTP.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 300 && response.StatusCode != http.StatusNotFound {
+		defer response.Body.Close()
+		detail, _ := io.ReadAll(io.LimitReader(response.Body, s3ResponseLimit))
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", method, key, response.Status, bytes.TrimSpace(detail))
+	}
+	return response, nil
+}
+
+
+func (store *S3BlobStore) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	response, err := store.do(ctx, http.MethodPut, key, body, contentType)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("s3 bucket %q not found", store.Bucket)
+	}
+	return nil
+}
+
+
+func (store *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	response, err := store.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, ErrBlobNotFound
+	}
+	return response.Body, nil
+}
+
+
+func (store *S3BlobStore) Delete(ctx context.Context, key string) error {
+	response, err := store.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	return nil
+}
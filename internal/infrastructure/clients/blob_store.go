@@ -0,0 +1,104 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang/internal/infrastructure/config"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+
+var (
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrInvalidBlobKey = errors.New("invalid blob key")
+)
+
+
+// BlobStore keeps attachment files under keys chosen by the caller. Keys are
+// slash-separated relative paths. Deleting a missing blob is not an error,
+// so deletions can be retried.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+
+func NewBlobStore(cfg *config.StorageConfig) BlobStore {
+	switch cfg.Backend {
+	case config.StorageS3:
+		return NewS3BlobStore(cfg)
+	default:
+		return &LocalBlobStore{Dir: cfg.Dir}
+	}
+}
+
+
+// LocalBlobStore keeps blobs as files under Dir.
+type LocalBlobStore struct {
+	Dir string
+}
+
+
+func (store *LocalBlobStore) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("%w %q", ErrInvalidBlobKey, key)
+	}
+	return filepath.Join(store.Dir, filepath.FromSlash(key)), nil
+}
+
+
+// Put writes the blob to a temporary file first, so a reader never sees a
+// partly written one.
+func (store *LocalBlobStore) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+
+func (store *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+
+func (store *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
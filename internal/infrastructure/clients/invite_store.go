@@ -0,0 +1,112 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+
+// ErrInviteNotFound is returned for codes that were never stored, expired
+// or were already redeemed.
+var ErrInviteNotFound = errors.New("invite not found")
+
+
+// InviteStore keeps invite codes until they expire or are redeemed.
+type InviteStore interface {
+	Save(ctx context.Context, key string, value string, ttl time.Duration) error
+	Load(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+
+// NewInviteStore keeps invites in Redis when it is configured. Without it
+// they live in this process only: they are lost on restart and can only be
+// redeemed on the instance that sent them.
+func NewInviteStore(redisClient *RedisClient) InviteStore {
+	if redisClient == nil {
+		return NewMemoryInviteStore()
+	}
+	return &RedisInviteStore{Client: redisClient}
+}
+
+
+type RedisInviteStore struct {
+	Client *RedisClient
+}
+
+
+func (store *RedisInviteStore) Save(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return store.Client.Set(ctx, key, value, ttl)
+}
+
+
+func (store *RedisInviteStore) Load(ctx context.Context, key string) (string, error) {
+	value, err := store.Client.Get(ctx, key)
+	if err == redis.Nil {
+		return "", ErrInviteNotFound
+	}
+	return value, err
+}
+
+
+func (store *RedisInviteStore) Delete(ctx context.Context, key string) error {
+	return store.Client.Delete(ctx, key)
+}
+
+
+type memoryInvite struct {
+	Value     string
+	ExpiresAt time.Time
+}
+
+
+type MemoryInviteStore struct {
+	mutex   sync.Mutex
+	entries map[string]memoryInvite
+}
+
+
+func NewMemoryInviteStore() *MemoryInviteStore {
+	return &MemoryInviteStore{entries: make(map[string]memoryInvite)}
+}
+
+
+func (store *MemoryInviteStore) Save(ctx context.Context, key string, value string, ttl time.Duration) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	for existing, entry := range store.entries {
+		if now.After(entry.ExpiresAt) {
+			delete(store.entries, existing)
+		}
+	}
+	store.entries[key] = memoryInvite{Value: value, ExpiresAt: now.Add(ttl)}
+	return nil
+}
+
+
+func (store *MemoryInviteStore) Load(ctx context.Context, key string) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry, ok := store.entries[key]
+	if !ok || time.Now().After(entry.ExpiresAt) {
+		delete(store.entries, key)
+		return "", ErrInviteNotFound
+	}
+	return entry.Value, nil
+}
+
+
+func (store *MemoryInviteStore) Delete(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.entries, key)
+	return nil
+}
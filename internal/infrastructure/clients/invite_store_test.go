@@ -0,0 +1,67 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+
+func TestMemoryInviteStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wait    time.Duration
+		delete  bool
+		want    string
+		wantErr error
+	}{
+		{name: "stored", ttl: time.Minute, want: "1:user@example.com"},
+		{name: "expired", ttl: 10 * time.Millisecond, wait: 20 * time.Millisecond, wantErr: ErrInviteNotFound},
+		{name: "redeemed", ttl: time.Minute, delete: true, wantErr: ErrInviteNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryInviteStore()
+
+			if err := store.Save(ctx, "document:1:invite:code", "1:user@example.com", tt.ttl); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			time.Sleep(tt.wait)
+			if tt.delete {
+				if err := store.Delete(ctx, "document:1:invite:code"); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+			}
+
+			got, err := store.Load(ctx, "document:1:invite:code")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Load() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+
+func TestMemoryInviteStoreUnknownCode(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryInviteStore()
+	store.Save(ctx, "document:1:invite:code", "1:user@example.com", time.Minute)
+
+	if _, err := store.Load(ctx, "document:2:invite:code"); !errors.Is(err, ErrInviteNotFound) {
+		t.Errorf("Load() of another document's code: error = %v, want %v", err, ErrInviteNotFound)
+	}
+}
+
+
+func TestNewInviteStore(t *testing.T) {
+	if _, ok := NewInviteStore(nil).(*MemoryInviteStore); !ok {
+		t.Error("NewInviteStore(nil) is not the in-memory store")
+	}
+}
@@ -0,0 +1,145 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"golang/internal/infrastructure/database/models"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+
+const (
+	MailExchange           = "mail"
+	MailDeadLetterExchange = "mail.dead-letter"
+	MailQueueName          = "mail.outgoing"
+	MailRetryQueueName     = "mail.retry"
+	MailDeadQueueName      = "mail.dead"
+	mailRoutingKey         = "outgoing"
+	mailRetryRoutingKey    = "retry"
+	MailAttemptHeader      = "x-attempt"
+)
+
+
+// MailQueue hands outgoing mail to the mailer worker through RabbitMQ:
+//
+//	mail --outgoing--> mail.outgoing --(rejected)--> mail.dead-letter --> mail.dead
+//	mail --retry--> mail.retry --(per message TTL)--> mail --outgoing--> ...
+//
+// Without RabbitMQ (local development) messages are sent in the background
+// through Smtp instead.
+type MailQueue struct {
+	Rabbit *RabbitClient
+	Smtp   *SmtpClient
+}
+
+
+func NewMailQueue(rabbit *RabbitClient, smtp *SmtpClient) *MailQueue {
+	queue := &MailQueue{Rabbit: rabbit, Smtp: smtp}
+	if rabbit != nil {
+		if err := queue.Declare(); err != nil {
+			log.Printf("Failed to declare mail topology: %v", err)
+		}
+	}
+	return queue
+}
+
+
+func (queue *MailQueue) Declare() error {
+	channel, err := queue.Rabbit.Connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer channel.Close()
+
+	for _, exchange := range []string{MailExchange, MailDeadLetterExchange} {
+		if err := channel.ExchangeDeclare(exchange, amqp091.ExchangeDirect, true, false, false, false, nil); err != nil {
+			return err
+		}
+	}
+
+	queues := []struct {
+		name     string
+		exchange string
+		key      string
+		args     amqp091.Table
+	}{
+		{MailQueueName, MailExchange, mailRoutingKey, amqp091.Table{
+			"x-dead-letter-exchange":    MailDeadLetterExchange,
+			"x-dead-letter-routing-key": mailRoutingKey,
+		}},
+		{MailRetryQueueName, MailExchange, mailRetryRoutingKey, amqp091.Table{
+			"x-dead-letter-exchange":    MailExchange,
+			"x-dead-letter-routing-key": mailRoutingKey,
+		}},
+		{MailDeadQueueName, MailDeadLetterExchange, mailRoutingKey, nil},
+	}
+
+	for _, q := range queues {
+		if _, err := channel.QueueDeclare(q.name, true, false, false, false, q.args); err != nil {
+			return err
+		}
+		if err := channel.QueueBind(q.name, q.key, q.exchange, false, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+
+func (queue *MailQueue) Enqueue(ctx context.Context, message models.MailMessageModel) error {
+	if queue == nil {
+		return errors.New("neither RabbitMQ nor SMTP is configured")
+	}
+	if queue.Rabbit == nil {
+		if queue.Smtp == nil {
+			return errors.New("neither RabbitMQ nor SMTP is configured")
+		}
+		go func() {
+			if err := queue.Smtp.Send(message); err != nil {
+				log.Printf("Failed to send %s mail: %v", message.Template, err)
+			}
+		}()
+		return nil
+	}
+
+	return queue.publish(ctx, mailRoutingKey, message, 1, 0)
+}
+
+
+// Retry schedules another delivery attempt after delay.
+func (queue *MailQueue) Retry(ctx context.Context, message models.MailMessageModel, attempt int, delay time.Duration) error {
+	return queue.publish(ctx, mailRetryRoutingKey, message, attempt, delay)
+}
+
+
+func (queue *MailQueue) publish(
+	ctx context.Context,
+	routingKey string,
+	message models.MailMessageModel,
+	attempt int,
+	delay time.Duration,
+) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	publishing := amqp091.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp091.Persistent,
+		MessageId:    message.IdempotencyKey,
+		Timestamp:    time.Now(),
+		Headers:      amqp091.Table{MailAttemptHeader: int32(attempt)},
+		Body:         body,
+	}
+	if delay > 0 {
+		publishing.Expiration = strconv.FormatInt(delay.Milliseconds(), 10)
+	}
+
+	return queue.Rabbit.PublishMessage(ctx, MailExchange, routingKey, publishing)
+}
@@ -0,0 +1,262 @@
+package clients
+
+import (
+	"bytes"
+	"fmt"
+	"golang/internal/infrastructure/config"
+	"golang/internal/infrastructure/database/models"
+	"golang/internal/utils"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+
+// MailTransport delivers a rendered message.
+type MailTransport interface {
+	Deliver(message *gomail.Message) error
+}
+
+
+// Mailbox is implemented by the development transports, which keep messages
+// instead of sending them.
+type Mailbox interface {
+	Messages() ([]*models.CapturedMailModel, error)
+	Clear() error
+}
+
+
+func NewMailTransport(cfg *config.SmtpConfig) MailTransport {
+	switch cfg.Transport {
+	case config.MailTransportMemory:
+		return NewMemoryTransport(200)
+	case config.MailTransportDir:
+		return &DirTransport{Dir: cfg.MailDir}
+	default:
+		return &SmtpTransport{Dialer: gomail.NewDialer(cfg.Host, cfg.Port, cfg.User, cfg.Password)}
+	}
+}
+
+
+type SmtpTransport struct {
+	Dialer *gomail.Dialer
+}
+
+
+func (transport *SmtpTransport) Deliver(message *gomail.Message) error {
+	return transport.Dialer.DialAndSend(message)
+}
+
+
+type capturedMessage struct {
+	id         string
+	raw        []byte
+	receivedAt time.Time
+}
+
+
+// MemoryTransport keeps the latest Limit messages in process memory.
+type MemoryTransport struct {
+	Limit    int
+	mutex    sync.Mutex
+	messages []capturedMessage
+	sequence int
+}
+
+
+func NewMemoryTransport(limit int) *MemoryTransport {
+	return &MemoryTransport{Limit: limit}
+}
+
+
+func (transport *MemoryTransport) Deliver(message *gomail.Message) error {
+	var raw bytes.Buffer
+	if _, err := message.WriteTo(&raw); err != nil {
+		return err
+	}
+
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	transport.sequence++
+	transport.messages = append(transport.messages, capturedMessage{
+		id:         fmt.Sprintf("%d", transport.sequence),
+		raw:        raw.Bytes(),
+		receivedAt: time.Now(),
+	})
+	if len(transport.messages) > transport.Limit {
+		transport.messages = transport.messages[len(transport.messages)-transport.Limit:]
+	}
+	return nil
+}
+
+
+func (transport *MemoryTransport) Messages() ([]*models.CapturedMailModel, error) {
+	transport.mutex.Lock()
+	captured := slices.Clone(transport.messages)
+	transport.mutex.Unlock()
+
+	messages := make([]*models.CapturedMailModel, 0, len(captured))
+	for i := len(captured) - 1; i >= 0; i-- {
+		message, err := parseCapturedMail(captured[i].id, captured[i].raw, captured[i].receivedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+
+func (transport *MemoryTransport) Clear() error {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	transport.messages = nil
+	return nil
+}
+
+
+// DirTransport writes every message to Dir as an .eml file, so it can be
+// opened in a mail client and shared between the API and the mailer worker.
+type DirTransport struct {
+	Dir string
+}
+
+
+func (transport *DirTransport) Deliver(message *gomail.Message) error {
+	if err := os.MkdirAll(transport.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), utils.RandSeq(6))
+	file, err := os.Create(filepath.Join(transport.Dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = message.WriteTo(file)
+	return err
+}
+
+
+func (transport *DirTransport) Messages() ([]*models.CapturedMailModel, error) {
+	paths, err := filepath.Glob(filepath.Join(transport.Dir, "*.eml"))
+	if err != nil {
+		return nil, err
+	}
+	// File names start with a nanosecond timestamp: newest first.
+	slices.Sort(paths)
+	slices.Reverse(paths)
+
+	messages := make([]*models.CapturedMailModel, 0, len(paths))
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		message, err := parseCapturedMail(strings.TrimSuffix(filepath.Base(path), ".eml"), raw, info.ModTime())
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+
+func (transport *DirTransport) Clear() error {
+	paths, err := filepath.Glob(filepath.Join(transport.Dir, "*.eml"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+
+func parseCapturedMail(id string, raw []byte, receivedAt time.Time) (*models.CapturedMailModel, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		subject = message.Header.Get("Subject")
+	}
+
+	captured := &models.CapturedMailModel{
+		Id:             id,
+		To:             message.Header["To"],
+		Subject:        subject,
+		IdempotencyKey: message.Header.Get("X-Idempotency-Key"),
+		ReceivedAt:     receivedAt,
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		var bodyReader io.Reader = message.Body
+		if strings.EqualFold(message.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+			bodyReader = quotedprintable.NewReader(bodyReader)
+		}
+		body, err := io.ReadAll(bodyReader)
+		if err != nil {
+			return nil, err
+		}
+		setCapturedBody(captured, mediaType, string(body))
+		return captured, nil
+	}
+
+	// NextPart transparently decodes quoted-printable parts.
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		setCapturedBody(captured, partType, string(body))
+	}
+	return captured, nil
+}
+
+
+func setCapturedBody(captured *models.CapturedMailModel, mediaType string, body string) {
+	switch mediaType {
+	case "text/html":
+		captured.HTML = body
+	case "text/plain":
+		captured.Text = body
+	}
+}
@@ -0,0 +1,230 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang/internal/infrastructure/config"
+	"golang/internal/infrastructure/jwk"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+
+type OidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+
+type OidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IdToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+
+type OidcIdTokenClaims struct {
+	jwt.RegisteredClaims
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+}
+
+
+type OidcClient struct {
+	Config    *config.OidcProviderConfig
+	HTTP      *http.Client
+	discovery *OidcDiscovery
+	keys      *jwk.Set
+	mutex     sync.Mutex
+}
+
+
+func NewOidcClient(cfg *config.OidcProviderConfig) *OidcClient {
+	return &OidcClient{
+		Config: cfg,
+		HTTP:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+
+func (client *OidcClient) Discover(ctx context.Context) (*OidcDiscovery, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.discovery != nil {
+		return client.discovery, nil
+	}
+
+	var discovery OidcDiscovery
+	if err := client.getJSON(ctx, client.Config.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to load discovery document: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != client.Config.Issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", discovery.Issuer, client.Config.Issuer)
+	}
+
+	client.discovery = &discovery
+	return client.discovery, nil
+}
+
+
+func (client *OidcClient) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	discovery, err := client.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.Config.ClientId},
+		"redirect_uri":          {client.Config.RedirectUrl},
+		"scope":                 {strings.Join(client.Config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+
+func (client *OidcClient) Exchange(ctx context.Context, code string, codeVerifier string) (*OidcTokenResponse, error) {
+	discovery, err := client.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {client.Config.RedirectUrl},
+		"client_id":     {client.Config.ClientId},
+		"code_verifier": {codeVerifier},
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if client.Config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(client.Config.ClientId), url.QueryEscape(client.Config.ClientSecret))
+	}
+
+	response, err := client.HTTP.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return nil, fmt.Errorf("token endpoint returned %d: %s", response.StatusCode, body)
+	}
+
+	var tokens OidcTokenResponse
+	if err := json.NewDecoder(response.Body).Decode(&tokens); err != nil {
+		return nil, err
+	}
+	if tokens.IdToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return &tokens, nil
+}
+
+
+// VerifyIdToken checks the signature against the provider JWKS (refetched once
+// when an unknown kid shows up), then issuer, audience, expiry and nonce.
+func (client *OidcClient) VerifyIdToken(ctx context.Context, rawIdToken string, nonce string) (*OidcIdTokenClaims, error) {
+	var claims OidcIdTokenClaims
+
+	_, err := jwt.ParseWithClaims(
+		rawIdToken,
+		&claims,
+		func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			return client.publicKey(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(client.Config.Issuer),
+		jwt.WithAudience(client.Config.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+	return &claims, nil
+}
+
+
+func (client *OidcClient) publicKey(ctx context.Context, kid string) (any, error) {
+	discovery, err := client.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if client.keys == nil || attempt == 1 {
+			var keys jwk.Set
+			if err := client.getJSON(ctx, discovery.JwksUri, &keys); err != nil {
+				return nil, fmt.Errorf("failed to load jwks: %w", err)
+			}
+			client.keys = &keys
+		}
+
+		if kid == "" && len(client.keys.Keys) == 1 {
+			return client.keys.Keys[0].PublicKey()
+		}
+		if key, ok := client.keys.Find(kid); ok {
+			return key.PublicKey()
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+
+func (client *OidcClient) getJSON(ctx context.Context, endpoint string, target any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := client.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", endpoint, response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
@@ -1,7 +1,11 @@
 package clients
 
 import (
+	"context"
+	"errors"
 	"golang/internal/infrastructure/config"
+	"sync"
+	"time"
 
 	"github.com/rabbitmq/amqp091-go"
 )
@@ -9,6 +13,9 @@ import (
 
 type RabbitClient struct {
 	Connection *amqp091.Connection
+	Config     *config.RabbitConfig
+	channel    *amqp091.Channel
+	mutex      sync.Mutex
 }
 
 
@@ -18,5 +25,82 @@ func NewRabbitClient() *RabbitClient {
 	if err != nil {
 		panic(err)
 	}
-	return &RabbitClient{Connection: conn}
+	return &RabbitClient{Connection: conn, Config: rabbitCfg}
+}
+
+
+// publishChannel lazily opens a channel in confirm mode and reopens it after
+// the broker closed it (e.g. on a publish to a missing exchange).
+func (client *RabbitClient) publishChannel() (*amqp091.Channel, error) {
+	if client.channel != nil && !client.channel.IsClosed() {
+		return client.channel, nil
+	}
+
+	channel, err := client.Connection.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		return nil, err
+	}
+	client.channel = channel
+	return channel, nil
+}
+
+
+func (client *RabbitClient) DeclareTopicExchange(name string) error {
+	channel, err := client.Connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer channel.Close()
+
+	return channel.ExchangeDeclare(name, amqp091.ExchangeTopic, true, false, false, false, nil)
+}
+
+
+// Publish sends a persistent JSON message and waits for the broker to confirm
+// it, so a nil error means the message is safely stored.
+func (client *RabbitClient) Publish(
+	ctx context.Context,
+	exchange string,
+	routingKey string,
+	messageId string,
+	headers amqp091.Table,
+	body []byte,
+) error {
+	return client.PublishMessage(ctx, exchange, routingKey, amqp091.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp091.Persistent,
+		MessageId:    messageId,
+		Timestamp:    time.Now(),
+		Headers:      headers,
+		Body:         body,
+	})
+}
+
+
+func (client *RabbitClient) PublishMessage(ctx context.Context, exchange string, routingKey string, message amqp091.Publishing) error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	channel, err := client.publishChannel()
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, message)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New("message was not confirmed by the broker")
+	}
+	return nil
 }
@@ -24,4 +24,35 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, ex
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	return r.client.Get(ctx, key).Result()
+}
+
+
+func (r *RedisClient) Delete(ctx context.Context, keys ...string) error {
+	return r.client.Del(ctx, keys...).Err()
+}
+
+
+func (r *RedisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.client.TTL(ctx, key).Result()
+}
+
+
+// Increment bumps a counter and starts its expiry window on the first hit.
+func (r *RedisClient) Increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+
+func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{}, exp time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, exp).Result()
+}
@@ -1,56 +1,115 @@
 package clients
 
 import (
-	"bytes"
+	"fmt"
 	"golang/internal/infrastructure/config"
-	"html/template"
-	"os"
+	"golang/internal/infrastructure/database/models"
+	"golang/templates"
+	"time"
 
 	"gopkg.in/gomail.v2"
 )
 
 
 type SmtpClient struct {
-	Dialer *gomail.Dialer
+	Transport MailTransport
+	From      string
 }
 
 
 func NewSmtpClient() *SmtpClient {
 	cfg := config.LoadSmtpConfig()
 	
-	return &SmtpClient{Dialer: gomail.NewDialer(cfg.Host, cfg.Port, cfg.User, cfg.Password)}
+	return &SmtpClient{Transport: NewMailTransport(cfg), From: cfg.From}
 }
 
 
-func (client *SmtpClient) SendInviteToDocument(to string, subject string, code string, documentTitle string, documentId string) error {
-	message := gomail.NewMessage()
-	message.SetHeader("To", to)
-	message.SetHeader("Subject", subject)
-	
-	htmlBytes, err := os.ReadFile("invite_member.html")
+// Mailbox returns the captured messages store when a development transport
+// is configured.
+func (client *SmtpClient) Mailbox() (Mailbox, bool) {
+	mailbox, ok := client.Transport.(Mailbox)
+	return mailbox, ok
+}
+
+
+// Send renders message in its locale and delivers it with plain text and
+// HTML alternatives.
+func (client *SmtpClient) Send(message models.MailMessageModel) error {
+	email, err := templates.Render(message.Template, message.Locale, message.Data)
 	if err != nil {
 		return err
 	}
 
-	template, err := template.New("invite").Parse(string(htmlBytes))
-	if err != nil {
-		return err
+	subject := message.Subject
+	if subject == "" {
+		subject = email.Subject
 	}
 
-	data := map[string]string{
-		"DocumentTitle": 	documentTitle,
-		"AccessCode":       code,
-		"DocumentId": 		documentId,
+	mail := gomail.NewMessage()
+	mail.SetHeader("From", client.From)
+	mail.SetHeader("To", message.To)
+	mail.SetHeader("Subject", subject)
+	if message.IdempotencyKey != "" {
+		mail.SetHeader("X-Idempotency-Key", message.IdempotencyKey)
 	}
+	mail.SetBody("text/plain", email.Text)
+	mail.AddAlternative("text/html", email.HTML)
 
-	var buf bytes.Buffer
-	if err := template.Execute(&buf, data); err != nil {
-		return err
+	return client.Transport.Deliver(mail)
+}
+
+
+func InviteMail(to string, locale string, code string, documentTitle string, documentId string) models.MailMessageModel {
+	return models.MailMessageModel{
+		IdempotencyKey: "invite:" + documentId + ":" + code,
+		Template:       "invite_member",
+		Locale:         locale,
+		To:             to,
+		Data: map[string]any{
+			"DocumentTitle": documentTitle,
+			"AccessCode":    code,
+			"DocumentId":    documentId,
+			"InviteUrl":     "https://www.fasttaski.ru/" + documentId + "/invite/" + code,
+		},
 	}
-	message.SetBody("text/html", buf.String())
+}
 
-	if err := client.Dialer.DialAndSend(message); err != nil {
-		return err
+
+func WorkspaceInviteMail(
+	to string,
+	locale string,
+	code string,
+	workspaceName string,
+	workspaceId string,
+	inviterName string,
+	role string,
+) models.MailMessageModel {
+	return models.MailMessageModel{
+		IdempotencyKey: "workspace-invite:" + workspaceId + ":" + code,
+		Template:       "workspace_invite",
+		Locale:         locale,
+		To:             to,
+		Data: map[string]any{
+			"WorkspaceName": workspaceName,
+			"WorkspaceId":   workspaceId,
+			"InviterName":   inviterName,
+			"Role":          role,
+			"InviteUrl":     "https://www.fasttaski.ru/workspaces/" + workspaceId + "/invite/" + code,
+		},
 	}
-	return nil
-}
\ No newline at end of file
+}
+
+
+func AccountLockedMail(to string, locale string, username string, ip string, lockedUntil time.Time) models.MailMessageModel {
+	return models.MailMessageModel{
+		IdempotencyKey: fmt.Sprintf("lockout:%s:%d", to, lockedUntil.Unix()),
+		Template:       "account_locked",
+		Locale:         locale,
+		To:             to,
+		Data: map[string]any{
+			"Username":    username,
+			"Ip":          ip,
+			"LockedUntil": lockedUntil.UTC().Format("02.01.2006 15:04 MST"),
+		},
+	}
+}
@@ -0,0 +1,184 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang/internal/infrastructure/database/models"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	// webhookDrainLimit is how much of a response is read, and discarded, so
+	// the connection can be reused.
+	webhookDrainLimit = 4096
+)
+
+
+// ErrWebhookTarget is returned for receivers on loopback, private, link-local
+// and other non-public addresses.
+var ErrWebhookTarget = errors.New("webhook receiver address is not public")
+
+
+// nonPublicPrefixes are the ranges besides those netip classifies (loopback,
+// private, link-local, multicast, unspecified) a webhook must not reach.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+
+// isPublicAddr reports whether addr is a globally routable unicast address.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+
+type WebhookClient struct {
+	HTTP *http.Client
+	// AllowPrivate turns off the public address check; see
+	// config.WebhookConfig.
+	AllowPrivate bool
+}
+
+
+// NewWebhookClient builds a client that follows no redirects and, unless
+// allowPrivate is set, only connects to public addresses. The address is
+// checked when dialing, after DNS resolution, so a name that resolves or
+// rebinds to an internal address is refused as well.
+func NewWebhookClient(allowPrivate bool) *WebhookClient {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !isPublicAddr(addrPort.Addr()) {
+				return ErrWebhookTarget
+			}
+			return nil
+		}
+	}
+
+	return &WebhookClient{
+		AllowPrivate: allowPrivate,
+		HTTP: &http.Client{
+			Timeout: 10 * time.Second,
+			// Redirects would let a receiver bounce a signed payload elsewhere.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+			Transport: &http.Transport{
+				// No proxy: the dialer must see the receiver's own address.
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+}
+
+
+// CheckUrl rejects receiver URLs that can never be delivered to: other
+// schemes than http and https, and hosts that are literal non-public
+// addresses or localhost. Names are only resolved when delivering.
+func (client *WebhookClient) CheckUrl(rawUrl string) error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("webhook url must be an absolute http or https url")
+	}
+	if client.AllowPrivate {
+		return nil
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookTarget
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublicAddr(addr) {
+		return ErrWebhookTarget
+	}
+	return nil
+}
+
+
+// SignWebhook returns the signature header value: "t=<unix>,v1=<hex>", where
+// v1 is HMAC-SHA256(secret, "<unix>.<body>"). Receivers should recompute it
+// and reject stale timestamps to prevent replays.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+
+// Deliver POSTs the payload once. Only 2xx responses count as success. The
+// response body is discarded: only the status code is recorded, so the
+// delivery log cannot be used to read what a receiver returns.
+func (client *WebhookClient) Deliver(ctx context.Context, target *models.WebhookTargetModel) models.WebhookResultModel {
+	start := time.Now()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, target.Url, bytes.NewReader(target.Payload))
+	if err != nil {
+		return models.WebhookResultModel{Error: err}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "docs-webhooks/1.0")
+	request.Header.Set(WebhookEventHeader, target.EventType)
+	request.Header.Set(WebhookDeliveryHeader, target.EventId)
+	request.Header.Set(WebhookSignatureHeader, SignWebhook(target.Secret, start, target.Payload))
+
+	response, err := client.HTTP.Do(request)
+	if err != nil {
+		if errors.Is(err, ErrWebhookTarget) {
+			err = ErrWebhookTarget
+		}
+		return models.WebhookResultModel{Error: err, Duration: time.Since(start)}
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, webhookDrainLimit))
+
+	result := models.WebhookResultModel{
+		ResponseCode: response.StatusCode,
+		Duration:     time.Since(start),
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		result.Error = fmt.Errorf("receiver responded with %s", response.Status)
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"golang/internal/infrastructure/database/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "8.8.8.8", want: true},
+		{addr: "2606:4700:4700::1111", want: true},
+		{addr: "::ffff:93.184.216.34", want: true},
+
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "0.0.0.0"},
+		{addr: "::"},
+		{addr: "10.1.2.3"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "fc00::1"},
+		{addr: "fd12:3456::1"},
+		{addr: "100.64.0.1"},
+		{addr: "192.0.0.8"},
+		{addr: "192.0.2.1"},
+		{addr: "198.18.0.1"},
+		{addr: "198.51.100.1"},
+		{addr: "203.0.113.1"},
+		{addr: "224.0.0.1"},
+		{addr: "ff02::1"},
+		{addr: "240.0.0.1"},
+		{addr: "255.255.255.255"},
+		{addr: "2001:db8::1"},
+		{addr: "64:ff9b::7f00:1"},
+		{addr: "2002:7f00:1::"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "::ffff:169.254.169.254"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	if isPublicAddr(netip.Addr{}) {
+		t.Error("isPublicAddr of the zero address = true")
+	}
+}
+
+
+func TestCheckUrl(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		wantErr      bool
+		wantTarget   bool
+	}{
+		{name: "https", url: "https://hooks.example.com/receive"},
+		{name: "http with port", url: "http://hooks.example.com:8080/receive"},
+		{name: "public ip", url: "https://93.184.216.34/receive"},
+		{name: "other scheme", url: "ftp://hooks.example.com/receive", wantErr: true},
+		{name: "file", url: "file:///etc/passwd", wantErr: true},
+		{name: "relative", url: "/receive", wantErr: true},
+		{name: "no host", url: "https:///receive", wantErr: true},
+		{name: "unparsable", url: "https://[::1", wantErr: true},
+		{name: "localhost", url: "http://localhost:8080/", wantErr: true, wantTarget: true},
+		{name: "localhost trailing dot", url: "http://LOCALHOST./", wantErr: true, wantTarget: true},
+		{name: "localhost subdomain", url: "http://api.localhost/", wantErr: true, wantTarget: true},
+		{name: "loopback", url: "http://127.0.0.1/", wantErr: true, wantTarget: true},
+		{name: "loopback ipv6", url: "http://[::1]:8080/", wantErr: true, wantTarget: true},
+		{name: "metadata", url: "http://169.254.169.254/latest/meta-data/", wantErr: true, wantTarget: true},
+		{name: "private", url: "https://10.0.0.5/receive", wantErr: true, wantTarget: true},
+		{name: "mapped loopback", url: "http://[::ffff:127.0.0.1]/", wantErr: true, wantTarget: true},
+		{name: "private allowed", url: "http://10.0.0.5/receive", allowPrivate: true},
+		{name: "localhost allowed", url: "http://localhost:8080/", allowPrivate: true},
+		{name: "scheme checked when private allowed", url: "gopher://10.0.0.5/", allowPrivate: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &WebhookClient{AllowPrivate: tt.allowPrivate}
+
+			err := client.CheckUrl(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckUrl(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			if errors.Is(err, ErrWebhookTarget) != tt.wantTarget {
+				t.Errorf("CheckUrl(%q) error = %v, want ErrWebhookTarget %v", tt.url, err, tt.wantTarget)
+			}
+		})
+	}
+}
+
+
+func TestDeliver(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/fail":
+			http.Error(w, "nope", http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		allowPrivate bool
+		wantCode     int
+		wantErr      error
+		wantAnyErr   bool
+	}{
+		{name: "loopback refused", path: "/ok", wantErr: ErrWebhookTarget},
+		{name: "delivered", path: "/ok", allowPrivate: true, wantCode: http.StatusNoContent},
+		{name: "redirect not followed", path: "/redirect", allowPrivate: true, wantCode: http.StatusFound, wantAnyErr: true},
+		{name: "receiver error", path: "/fail", allowPrivate: true, wantCode: http.StatusInternalServerError, wantAnyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received, body = nil, nil
+			target := &models.WebhookTargetModel{
+				Url:       server.URL + tt.path,
+				Secret:    "secret",
+				EventId:   "evt-1",
+				EventType: "document.updated",
+				Payload:   []byte(`{"type":"document.updated"}`),
+			}
+
+			result := NewWebhookClient(tt.allowPrivate).Deliver(context.Background(), target)
+			if tt.wantErr != nil && !errors.Is(result.Error, tt.wantErr) {
+				t.Fatalf("Deliver() error = %v, want %v", result.Error, tt.wantErr)
+			}
+			if tt.wantErr == nil && (result.Error != nil) != tt.wantAnyErr {
+				t.Fatalf("Deliver() error = %v, want error %v", result.Error, tt.wantAnyErr)
+			}
+			if result.ResponseCode != tt.wantCode {
+				t.Errorf("ResponseCode = %d, want %d", result.ResponseCode, tt.wantCode)
+			}
+			if tt.wantCode == 0 {
+				if received != nil {
+					t.Error("refused receiver got the request")
+				}
+				return
+			}
+
+			if received.URL.Path != tt.path {
+				t.Errorf("receiver got %s, want %s", received.URL.Path, tt.path)
+			}
+			if string(body) != string(target.Payload) {
+				t.Errorf("receiver got body %q, want %q", body, target.Payload)
+			}
+			if got := received.Header.Get(WebhookEventHeader); got != target.EventType {
+				t.Errorf("%s = %q, want %q", WebhookEventHeader, got, target.EventType)
+			}
+			if got := received.Header.Get(WebhookDeliveryHeader); got != target.EventId {
+				t.Errorf("%s = %q, want %q", WebhookDeliveryHeader, got, target.EventId)
+			}
+			if got := received.Header.Get(WebhookSignatureHeader); !strings.HasPrefix(got, "t=") || !strings.Contains(got, ",v1=") {
+				t.Errorf("%s = %q, want t=<unix>,v1=<hex>", WebhookSignatureHeader, got)
+			}
+		})
+	}
+}
+
+
+func TestSignWebhook(t *testing.T) {
+	timestamp := time.Unix(1700000000, 0)
+	signature := SignWebhook("secret", timestamp, []byte("body"))
+
+	if !strings.HasPrefix(signature, "t=1700000000,v1=") || len(signature) != len("t=1700000000,v1=")+64 {
+		t.Errorf("SignWebhook() = %q, want t=1700000000,v1=<64 hex digits>", signature)
+	}
+	if SignWebhook("secret", timestamp, []byte("body")) != signature {
+		t.Error("SignWebhook() is not deterministic")
+	}
+
+	changed := []string{
+		SignWebhook("other", timestamp, []byte("body")),
+		SignWebhook("secret", timestamp.Add(time.Second), []byte("body")),
+		SignWebhook("secret", timestamp, []byte("bodies")),
+	}
+	for _, other := range changed {
+		if other[strings.Index(other, "v1="):] == signature[strings.Index(signature, "v1="):] {
+			t.Errorf("signature %q does not depend on every input", other)
+		}
+	}
+}
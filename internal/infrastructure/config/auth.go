@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"golang/internal/infrastructure/jwk"
 	"os"
 	"strconv"
 	"time"
@@ -16,6 +17,16 @@ type JwtConfig struct {
 	SigningMethod jwt.SigningMethod
 	RefreshTokenTime time.Duration
 	AccessTokenTime time.Duration
+	// Keys is set for asymmetric signing methods (RS*, ES*, EdDSA) and is nil
+	// when tokens are signed with the shared HMAC Secret.
+	Keys *jwk.KeyRing
+	KeyRotation time.Duration
+}
+
+
+func (cfg *JwtConfig) IsSymmetric() bool {
+	_, ok := cfg.SigningMethod.(*jwt.SigningMethodHMAC)
+	return ok
 }
 
 
@@ -33,10 +44,35 @@ func LoadJwtConfig() (*JwtConfig, error){
 		return nil, fmt.Errorf("failed to parse JWT_ACCESS_TOKEN_TIME: %w", err)
 	}
 
-	return &JwtConfig{
+	signingMethod := jwt.GetSigningMethod(os.Getenv("JWT_SIGNING_METHOD"))
+	if signingMethod == nil {
+		return nil, fmt.Errorf("unknown JWT_SIGNING_METHOD %q", os.Getenv("JWT_SIGNING_METHOD"))
+	}
+
+	keyRotationDays, err := strconv.Atoi(os.Getenv("JWT_KEY_ROTATION_DAYS"))
+	if err != nil || keyRotationDays < 0 {
+		keyRotationDays = 30
+	}
+
+	cfg := &JwtConfig{
 		Secret: os.Getenv("JWT_SECRET"),
-		SigningMethod: jwt.GetSigningMethod(os.Getenv("JWT_SIGNING_METHOD")),
+		SigningMethod: signingMethod,
 		RefreshTokenTime: time.Duration(refreshTokenTime) * time.Hour * 24 * 7,
 		AccessTokenTime: time.Duration(accessTokenTime) * time.Minute,
-	}, nil
+		KeyRotation: time.Duration(keyRotationDays) * time.Hour * 24,
+	}
+
+	if !cfg.IsSymmetric() {
+		keysDir := os.Getenv("JWT_KEYS_DIR")
+		if keysDir == "" {
+			keysDir = "keys"
+		}
+
+		keys, err := jwk.LoadKeyRing(keysDir, signingMethod, cfg.RefreshTokenTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT signing keys: %w", err)
+		}
+		cfg.Keys = keys
+	}
+	return cfg, nil
 }
\ No newline at end of file
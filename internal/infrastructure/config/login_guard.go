@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+
+type LoginGuardConfig struct {
+	MaxAccountAttempts int
+	MaxIpAttempts      int
+	AttemptWindow      time.Duration
+	BaseLockout        time.Duration
+	MaxLockout         time.Duration
+}
+
+
+func LoadLoginGuardConfig() *LoginGuardConfig {
+	godotenv.Load()
+
+	return &LoginGuardConfig{
+		MaxAccountAttempts: envInt("LOGIN_MAX_ACCOUNT_ATTEMPTS", 5),
+		MaxIpAttempts:      envInt("LOGIN_MAX_IP_ATTEMPTS", 20),
+		AttemptWindow:      time.Duration(envInt("LOGIN_ATTEMPT_WINDOW", 15)) * time.Minute,
+		BaseLockout:        time.Duration(envInt("LOGIN_BASE_LOCKOUT", 1)) * time.Minute,
+		MaxLockout:         time.Duration(envInt("LOGIN_MAX_LOCKOUT", 60)) * time.Minute,
+	}
+}
+
+
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
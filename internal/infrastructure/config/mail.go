@@ -0,0 +1,29 @@
+package config
+
+import (
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+
+type MailWorkerConfig struct {
+	Prefetch    int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	SentTTL     time.Duration
+}
+
+
+func LoadMailWorkerConfig() *MailWorkerConfig {
+	godotenv.Load()
+
+	return &MailWorkerConfig{
+		Prefetch:    envInt("MAIL_WORKER_PREFETCH", 10),
+		MaxAttempts: envInt("MAIL_MAX_ATTEMPTS", 6),
+		BaseBackoff: time.Duration(envInt("MAIL_BASE_BACKOFF", 10)) * time.Second,
+		MaxBackoff:  time.Duration(envInt("MAIL_MAX_BACKOFF", 30)) * time.Minute,
+		SentTTL:     time.Duration(envInt("MAIL_SENT_TTL_HOURS", 72)) * time.Hour,
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+
+type OidcProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
+
+type OidcConfig struct {
+	Providers map[string]*OidcProviderConfig
+	StateTime time.Duration
+}
+
+
+// LoadOidcConfig reads the comma separated OIDC_PROVIDERS list and, for every
+// provider name, the OIDC_<NAME>_* variables. Pointing OIDC_<NAME>_ISSUER at
+// a local stub (see cmd/oidc-stub) is enough to exercise the whole flow.
+func LoadOidcConfig() *OidcConfig {
+	godotenv.Load()
+
+	stateTime, err := strconv.Atoi(os.Getenv("OIDC_STATE_TIME"))
+	if err != nil || stateTime <= 0 {
+		stateTime = 10
+	}
+
+	cfg := &OidcConfig{
+		Providers: make(map[string]*OidcProviderConfig),
+		StateTime: time.Duration(stateTime) * time.Minute,
+	}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		scopes := strings.Fields(os.Getenv(prefix + "SCOPES"))
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+
+		cfg.Providers[name] = &OidcProviderConfig{
+			Name:         name,
+			Issuer:       strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientId:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectUrl:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       scopes,
+		}
+	}
+	return cfg
+}
@@ -13,11 +13,12 @@ type RabbitConfig struct {
 	Port int
 	User string
 	Password string 
+	EventsExchange string
 }
 
 
 func (cfg *RabbitConfig) ConnectionString() string {
-	return cfg.User + ":" + cfg.Password + "@" + cfg.Host + ":" + strconv.Itoa(cfg.Port)
+	return "amqp://" + cfg.User + ":" + cfg.Password + "@" + cfg.Host + ":" + strconv.Itoa(cfg.Port) + "/"
 }
 
 
@@ -29,10 +30,22 @@ func LoadRabbitConfig() (*RabbitConfig) {
 		panic("failed to parse RABBIT_PORT")
 	}
 
+	eventsExchange := os.Getenv("RABBIT_EVENTS_EXCHANGE")
+	if eventsExchange == "" {
+		eventsExchange = "domain.events"
+	}
+
 	return &RabbitConfig{
 		Host: os.Getenv("RABBIT_HOST"),
 		Port: port,
 		User: os.Getenv("RABBIT_USER"),
 		Password: os.Getenv("RABBIT_PASS"),
+		EventsExchange: eventsExchange,
 	}
-}
\ No newline at end of file
+}
+
+
+func RabbitConfigured() bool {
+	godotenv.Load()
+	return os.Getenv("RABBIT_HOST") != ""
+}
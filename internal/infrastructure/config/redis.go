@@ -35,4 +35,9 @@ func LoadRedisConfig() (*RedisConfig) {
 		DB:   0,
 		PoolSize: 50,
 	}
-}
\ No newline at end of file
+}
+
+func RedisConfigured() bool {
+	godotenv.Load()
+	return os.Getenv("REDIS_HOST") != ""
+}
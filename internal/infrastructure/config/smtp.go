@@ -8,24 +8,72 @@ import (
 )
 
 
+const (
+	MailTransportSmtp   = "smtp"
+	MailTransportMemory = "memory"
+	MailTransportDir    = "dir"
+)
+
+
 type SmtpConfig struct {
 	Host     string 
 	Port     int    
 	User     string 
 	Password string 
+	From     string
+	// Transport is smtp (default), memory (kept in-process) or dir (.eml
+	// files in MailDir). The last two are meant for development and tests.
+	Transport string
+	MailDir   string
 }
 
 
 func LoadSmtpConfig() *SmtpConfig {
 	godotenv.Load()
+
+	transport := os.Getenv("MAIL_TRANSPORT")
+	if transport == "" {
+		transport = MailTransportSmtp
+	}
+
+	mailDir := os.Getenv("MAIL_DIR")
+	if mailDir == "" {
+		mailDir = "mailbox"
+	}
+
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USER")
+	}
+	if from == "" {
+		from = "noreply@localhost"
+	}
+
 	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
-	if err != nil {
+	if err != nil && transport == MailTransportSmtp {
 		panic(err)
 	}
 	return &SmtpConfig{
-		Host:     os.Getenv("SMTP_HOST"),
-		Port:     port,
-		User:     os.Getenv("SMTP_USER"),
-		Password: os.Getenv("SMTP_PASSWORD"),
+		Host:      os.Getenv("SMTP_HOST"),
+		Port:      port,
+		User:      os.Getenv("SMTP_USER"),
+		Password:  os.Getenv("SMTP_PASSWORD"),
+		From:      from,
+		Transport: transport,
+		MailDir:   mailDir,
 	}
-}
\ No newline at end of file
+}
+
+func SmtpConfigured() bool {
+	godotenv.Load()
+	transport := os.Getenv("MAIL_TRANSPORT")
+	return os.Getenv("SMTP_HOST") != "" || transport == MailTransportMemory || transport == MailTransportDir
+}
+
+
+// IsDevelopment enables dev-only endpoints such as the mailbox.
+func IsDevelopment() bool {
+	godotenv.Load()
+	env := os.Getenv("APP_ENV")
+	return env == "development" || env == "dev"
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+
+// defaultAttachmentTypes are the sniffed types accepted when
+// ATTACHMENT_TYPES is not set. SVG and HTML are left out on purpose: served
+// from our origin they could run scripts.
+var defaultAttachmentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp",
+	"application/pdf", "application/zip", "text/plain",
+}
+
+
+type StorageConfig struct {
+	// Backend is local (files under Dir, the default) or s3, any
+	// S3-compatible service addressed path-style at S3Endpoint.
+	Backend     string
+	Dir         string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+
+	MaxAttachmentSize int64
+	AttachmentTypes   []string
+	CleanupInterval   time.Duration
+	CleanupBatch      int
+}
+
+
+func LoadStorageConfig() *StorageConfig {
+	godotenv.Load()
+
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = StorageLocal
+	}
+
+	dir := os.Getenv("STORAGE_DIR")
+	if dir == "" {
+		dir = "attachments"
+	}
+
+	region := os.Getenv("S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	types := defaultAttachmentTypes
+	if value := os.Getenv("ATTACHMENT_TYPES"); value != "" {
+		types = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				types = append(types, name)
+			}
+		}
+	}
+
+	return &StorageConfig{
+		Backend:           backend,
+		Dir:               dir,
+		S3Endpoint:        strings.TrimSuffix(os.Getenv("S3_ENDPOINT"), "/"),
+		S3Region:          region,
+		S3Bucket:          os.Getenv("S3_BUCKET"),
+		S3AccessKey:       os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:       os.Getenv("S3_SECRET_KEY"),
+		MaxAttachmentSize: int64(envInt("ATTACHMENT_MAX_MB", 10)) << 20,
+		AttachmentTypes:   types,
+		CleanupInterval:   time.Duration(envInt("ATTACHMENT_CLEANUP_INTERVAL", 5)) * time.Minute,
+		CleanupBatch:      envInt("ATTACHMENT_CLEANUP_BATCH", 100),
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+	BatchSize     int
+}
+
+
+func LoadTrashConfig() *TrashConfig {
+	godotenv.Load()
+
+	return &TrashConfig{
+		Retention:     time.Duration(envInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		PurgeInterval: time.Duration(envInt("TRASH_PURGE_INTERVAL", 60)) * time.Minute,
+		BatchSize:     envInt("TRASH_PURGE_BATCH", 50),
+	}
+}
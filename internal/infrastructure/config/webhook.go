@@ -0,0 +1,24 @@
+package config
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+)
+
+
+type WebhookConfig struct {
+	// AllowPrivateTargets lets webhooks reach loopback, private and other
+	// non-public addresses. Only for local development against a receiver
+	// on the same machine or network.
+	AllowPrivateTargets bool
+}
+
+
+func LoadWebhookConfig() *WebhookConfig {
+	godotenv.Load()
+
+	allowPrivate, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE_TARGETS"))
+	return &WebhookConfig{AllowPrivateTargets: allowPrivate}
+}
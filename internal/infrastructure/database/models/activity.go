@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+
+type ActivityModel struct {
+	Id         int64           `json:"id"`
+	DocumentId int             `json:"document_id"`
+	Kind       string          `json:"kind"`
+	Actor      *BaseUserModel  `json:"actor"`
+	Count      int             `json:"count"`
+	Data       json.RawMessage `json:"data"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+
+// NewActivityModel describes a feed entry before it is written. Edits by the
+// same actor right after each other are folded into one entry.
+type NewActivityModel struct {
+	DocumentId int
+	ActorId    int
+	Kind       string
+	Data       any
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+
+type CreateApiKeyModel struct {
+	Name   string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=documents:read documents:write comments"`
+}
+
+
+type ApiKeyModel struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+
+// CreatedApiKeyModel is only returned by the create endpoint: Key is the full
+// secret and is never stored or shown again.
+type CreatedApiKeyModel struct {
+	ApiKeyModel
+	Key string `json:"key"`
+}
+
+
+type ApiKeyCredentialModel struct {
+	ApiKeyModel
+	KeyHash string
+	User    BaseUserModel
+}
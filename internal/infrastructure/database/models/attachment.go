@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+
+// AttachmentModel is a file uploaded to a document. ContentType is sniffed
+// from the file, not taken from the upload.
+type AttachmentModel struct {
+	Id          int       	`json:"id"`
+	DocumentId  int       	`json:"documentId"`
+	Filename    string    	`json:"filename"`
+	ContentType string    	`json:"contentType"`
+	Size        int64     	`json:"size"`
+	UploadedBy  *int      	`json:"uploadedBy"`
+	CreatedAt   time.Time 	`json:"createdAt"`
+	StorageKey  string    	`json:"-"`
+}
+
+
+// AttachmentDeletionModel is a blob whose attachment row is gone.
+type AttachmentDeletionModel struct {
+	Id         int
+	StorageKey string
+}
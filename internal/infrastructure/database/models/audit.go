@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+
+type AuditEntryModel struct {
+	Id         int64           `json:"id"`
+	ActorId    *int            `json:"actorId"`
+	Action     string          `json:"action"`
+	Ip         string          `json:"ip"`
+	UserAgent  string          `json:"userAgent"`
+	TargetType string          `json:"targetType"`
+	TargetId   string          `json:"targetId"`
+	DocumentId *int            `json:"documentId,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+
+// AuditFilterModel narrows an audit log query; zero values match everything.
+type AuditFilterModel struct {
+	DocumentId *int
+	Action     string
+	Since      *time.Time
+	Until      *time.Time
+}
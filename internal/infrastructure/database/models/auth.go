@@ -22,3 +22,9 @@ type RegisterUserModel struct {
 	UserModel
 	Password string `json:"password" validate:"required,min=8"`
 }
+
+
+type ChangePasswordModel struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
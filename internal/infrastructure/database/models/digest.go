@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+
+type DigestSettingsModel struct {
+	Frequency  string     `json:"frequency" validate:"required,oneof=off daily weekly"`
+	LastSentAt *time.Time `json:"last_sent_at"`
+}
+
+
+type DigestRecipientModel struct {
+	User      BaseUserModel
+	Locale    string
+	Frequency string
+	Since     time.Time
+}
+
+
+// DigestDocumentModel is the activity of one document since the last digest,
+// not counting the recipient's own changes.
+type DigestDocumentModel struct {
+	DocumentId int
+	Title      string
+	Edits      int
+	Editors    []string
+	Comments   int
+	Commenters []string
+}
+
+
+type DigestMentionModel struct {
+	DocumentTitle string
+	Author        string
+	Excerpt       string
+}
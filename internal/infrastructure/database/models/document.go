@@ -12,9 +12,16 @@ type DocumentOwner struct {
 }
 
 
+// CreateDocumentModel creates a document, optionally from a template: a
+// stored one by TemplateId or a built-in one by TemplateKey.
 type CreateDocumentModel struct {
 	Title   string 
 	IsPublic bool
+	WorkspaceId *int
+	FolderId *int
+	Content string
+	TemplateId *int
+	TemplateKey string
 }
 
 
@@ -42,6 +49,8 @@ type BaseDocumentModel struct {
 	Content   string    	`json:"content"`
 	CreatedAt time.Time 	`json:"createdAt"`
 	IsPublic  bool			`json:"isPublic"`
+	WorkspaceId *int		`json:"workspaceId"`
+	FolderId  *int			`json:"folderId"`
 	UpdatedAt time.Time 	`json:"updatedAt"`
 }
 
@@ -50,6 +59,27 @@ type DocumentModel struct {
 	BaseDocumentModel 
 	Owner	  BaseUserModel 	`json:"owner"`
 	Members   []BaseUserModel 	`json:"members"`
+	Path      []BreadcrumbModel	`json:"path"`
+	Stats     *DocumentStatsModel `json:"stats"`
+	// LastEditor is the last user who changed the content; nil when unknown.
+	LastEditor *BaseUserModel 	`json:"lastEditor"`
+}
+
+
+// DocumentStatsModel describes the text of a document. It is stored with
+// every content change, not computed on read. ReadingTime is in minutes.
+type DocumentStatsModel struct {
+	WordCount      int 					`json:"wordCount"`
+	CharacterCount int 					`json:"characterCount"`
+	ReadingTime    int 					`json:"readingTime"`
+	Outline        []OutlineEntryModel 	`json:"outline"`
+}
+
+
+// OutlineEntryModel is a heading of the document, in document order.
+type OutlineEntryModel struct {
+	Level int    	`json:"level"`
+	Text  string 	`json:"text"`
 }
 
 
@@ -58,4 +88,98 @@ type BaseSnapshotModel struct {
 	DocumentId int       	`json:"documentId"`
 	UserId   int       		`json:"userId"`
 	CreatedAt time.Time 	`json:"createdAt"`
-}
\ No newline at end of file
+}
+
+// TrashedDocumentModel is a soft-deleted document awaiting restore or purge.
+type TrashedDocumentModel struct {
+	BaseDocumentModel
+	DeletedAt time.Time 	`json:"deletedAt"`
+	DeletedBy *int 			`json:"deletedBy"`
+	PurgeAt   time.Time 	`json:"purgeAt"`
+}
+
+
+// DuplicateDocumentModel copies a document. Without a target the copy is
+// filed next to the original when the caller may write there, and in their
+// personal space otherwise.
+type DuplicateDocumentModel struct {
+	Title          *string 	`json:"title" validate:"omitempty,max=255"`
+	FolderId       *int    	`json:"folderId"`
+	WorkspaceId    *int    	`json:"workspaceId"`
+	IncludeMembers bool    	`json:"includeMembers"`
+}
+
+
+type TransferOwnershipModel struct {
+	UserId int 	`json:"userId" validate:"required"`
+}
+
+
+// ImportFileModel is one file of an import. Folders is the folder path the
+// document is filed under, relative to the import target.
+type ImportFileModel struct {
+	Folders []string
+	Title   string
+	Content string
+}
+
+
+type ImportResultModel struct {
+	Folders   []*FolderModel 		`json:"folders"`
+	Documents []*BaseDocumentModel 	`json:"documents"`
+}
+
+
+// DocumentBlocksModel is the content of a document as a block tree.
+// Revision is the updated_at of the content it was read from; writes send it
+// back so edits based on stale content are rejected.
+type DocumentBlocksModel struct {
+	DocumentId int       		`json:"documentId"`
+	Blocks     json.RawMessage 	`json:"blocks"`
+	Revision   time.Time 		`json:"revision"`
+	// Content is the Markdown the blocks were derived from, when they were.
+	Content    string    		`json:"-"`
+}
+
+
+type ReplaceBlocksModel struct {
+	Blocks   json.RawMessage 	`json:"blocks" validate:"required"`
+	Revision *time.Time      	`json:"revision"`
+}
+
+
+type BlockOperationModel struct {
+	Op       string          	`json:"op" validate:"required,oneof=insert replace delete move"`
+	Id       string          	`json:"id"`
+	ParentId string          	`json:"parentId"`
+	AfterId  string          	`json:"afterId"`
+	Block    json.RawMessage 	`json:"block"`
+}
+
+
+type PatchBlocksModel struct {
+	Operations []BlockOperationModel 	`json:"operations" validate:"required,min=1,max=500,dive"`
+	Revision   *time.Time            	`json:"revision"`
+}
+
+
+// BacklinkModel is a document whose content links to another one.
+type BacklinkModel struct {
+	Id          int       	`json:"id"`
+	Title       string    	`json:"title"`
+	WorkspaceId *int      	`json:"workspaceId"`
+	FolderId    *int      	`json:"folderId"`
+	UpdatedAt   time.Time 	`json:"updatedAt"`
+}
+
+
+// DocumentLinkModel is a link in the content of a document, either to a
+// document id (TargetId) or a wiki link to a title (TargetTitle). DocumentId
+// and Title are the document it resolves to, set only when Status is ok.
+type DocumentLinkModel struct {
+	TargetId    *int    	`json:"targetId"`
+	TargetTitle *string 	`json:"targetTitle"`
+	Status      string  	`json:"status"`
+	DocumentId  *int    	`json:"documentId"`
+	Title       *string 	`json:"title"`
+}
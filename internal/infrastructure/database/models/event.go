@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+
+// DomainEventModel is a row of the transactional outbox and the body of the
+// message published to the events exchange (routing key = Type).
+type DomainEventModel struct {
+	Id            int64           `json:"-"`
+	EventId       string          `json:"event_id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   string          `json:"aggregate_id"`
+	ActorId       *int            `json:"actor_id"`
+	DocumentId    *int            `json:"document_id"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"-"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+
+type DocumentEventPayload struct {
+	Id       int    `json:"id"`
+	Title    string `json:"title"`
+	IsPublic bool   `json:"is_public"`
+	OwnerId  int    `json:"owner_id"`
+}
+
+
+type CommentEventPayload struct {
+	Id         int    `json:"id"`
+	DocumentId int    `json:"document_id"`
+	ParentId   int    `json:"parent_id"`
+	UserId     int    `json:"user_id"`
+	Content    string `json:"content"`
+}
+
+
+type MemberEventPayload struct {
+	DocumentId int    `json:"document_id"`
+	UserId     int    `json:"user_id"`
+	Role       string `json:"role,omitempty"`
+}
+
+
+type UserEventPayload struct {
+	Id       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
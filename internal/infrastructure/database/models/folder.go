@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+
+type CreateFolderModel struct {
+	Name        string `json:"name" validate:"required,min=1,max=255"`
+	ParentId    *int   `json:"parent_id"`
+	WorkspaceId *int   `json:"workspace_id"`
+}
+
+
+type RenameFolderModel struct {
+	Name string `json:"name" validate:"required,min=1,max=255"`
+}
+
+
+// MoveFolderModel moves a folder under ParentId, or to the root of its space
+// when ParentId is null.
+type MoveFolderModel struct {
+	ParentId *int `json:"parent_id"`
+}
+
+
+// MoveToFolderModel files a document into FolderId, or takes it out of any
+// folder when FolderId is null.
+type MoveToFolderModel struct {
+	FolderId *int `json:"folder_id"`
+}
+
+
+type ShareFolderModel struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=editor commenter viewer"`
+}
+
+
+// FolderModel is a folder as seen by one user, Role being their effective
+// role including grants inherited from parent folders.
+type FolderModel struct {
+	Id          int               `json:"id"`
+	Name        string            `json:"name"`
+	ParentId    *int              `json:"parent_id"`
+	WorkspaceId *int              `json:"workspace_id"`
+	Role        string            `json:"role"`
+	Path        []BreadcrumbModel `json:"path,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+
+// BreadcrumbModel is one folder on the path from the root, outermost first.
+type BreadcrumbModel struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+
+// FolderItemModel is a child of a folder: a subfolder or a document.
+type FolderItemModel struct {
+	Type      string    `json:"type"`
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
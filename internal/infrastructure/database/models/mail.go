@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+
+// MailMessageModel is the queued form of an outgoing email. Template names an
+// email of the templates package, rendered in Locale; the subject comes from
+// the template unless Subject is set. IdempotencyKey makes redelivered
+// messages send once.
+type MailMessageModel struct {
+	IdempotencyKey string         `json:"idempotency_key"`
+	Template       string         `json:"template"`
+	Locale         string         `json:"locale"`
+	To             string         `json:"to"`
+	Subject        string         `json:"subject,omitempty"`
+	Data           map[string]any `json:"data"`
+}
+
+
+type InviteMemberModel struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+
+// CapturedMailModel is a message kept by a development mail transport.
+type CapturedMailModel struct {
+	Id             string    `json:"id"`
+	To             []string  `json:"to"`
+	Subject        string    `json:"subject"`
+	Text           string    `json:"text"`
+	HTML           string    `json:"html"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	ReceivedAt     time.Time `json:"received_at"`
+}
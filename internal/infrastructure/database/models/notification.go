@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+
+type NotificationModel struct {
+	Id         int64           `json:"id"`
+	Type       string          `json:"type"`
+	DocumentId *int            `json:"document_id"`
+	Actor      *BaseUserModel  `json:"actor"`
+	Count      int             `json:"count"`
+	Data       json.RawMessage `json:"data"`
+	ReadAt     *time.Time      `json:"read_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+
+// NewNotificationModel describes a notification before fan-out. Notifications
+// with the same GroupKey are folded into one unread entry per recipient.
+type NewNotificationModel struct {
+	Type       string
+	GroupKey   string
+	DocumentId *int
+	ActorId    int
+	Data       any
+}
+
+
+type NotificationCountModel struct {
+	Unread int `json:"unread"`
+}
+
+
+type UpdateMemberRoleModel struct {
+	Role string `json:"role" validate:"required,oneof=viewer commenter editor"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+
+// TemplateModel is a document template. Built-in templates have a Key and
+// no Id; personal and workspace templates have an Id. Title and Content may
+// contain {{date}}, {{author}} and {{title}} placeholders.
+type TemplateModel struct {
+	Id          *int       `json:"id"`
+	Key         string     `json:"key,omitempty"`
+	Scope       string     `json:"scope"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	WorkspaceId *int       `json:"workspace_id"`
+	CreatedBy   *int       `json:"created_by"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+
+// CreateTemplateModel creates a personal template, or a workspace template
+// when WorkspaceId is set.
+type CreateTemplateModel struct {
+	Name        string `json:"name" validate:"required,min=1,max=255"`
+	Description string `json:"description" validate:"max=1000"`
+	Title       string `json:"title" validate:"max=255"`
+	Content     string `json:"content" validate:"max=5242880"`
+	WorkspaceId *int   `json:"workspace_id"`
+}
+
+
+type UpdateTemplateModel struct {
+	Name        *string `json:"name,omitempty" db:"name" validate:"omitempty,min=1,max=255"`
+	Description *string `json:"description,omitempty" db:"description" validate:"omitempty,max=1000"`
+	Title       *string `json:"title,omitempty" db:"title" validate:"omitempty,max=255"`
+	Content     *string `json:"content,omitempty" db:"content" validate:"omitempty,max=5242880"`
+}
+
+
+// SaveAsTemplateModel saves the title and content of a document as a new
+// template. The name defaults to the document title.
+type SaveAsTemplateModel struct {
+	Name        string `json:"name" validate:"max=255"`
+	Description string `json:"description" validate:"max=1000"`
+	WorkspaceId *int   `json:"workspace_id"`
+}
@@ -11,10 +11,24 @@ type BaseUserModel struct {
 type UserModel struct {
 	BaseUserModel
 	Password string `json:"-"`
+	Locale   string `json:"-"`
 }
 
 
 type UpdateUserModel struct {
 	Username string `json:"username" validate:"required,min=3,max=20"`
 	Email    string `json:"email" validate:"required"`
-}
\ No newline at end of file
+}
+
+type LocaleModel struct {
+	Locale string `json:"locale" validate:"required,oneof=ru en"`
+}
+
+
+type UserIdentityModel struct {
+	Id       int    `json:"id"`
+	UserId   int    `json:"user_id"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Email    string `json:"email"`
+}
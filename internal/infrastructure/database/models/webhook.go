@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+
+type CreateWebhookModel struct {
+	Url        string   `json:"url" validate:"required,url,startswith=http"`
+	DocumentId *int     `json:"document_id"`
+	Events     []string `json:"events" validate:"dive,oneof=document.created document.updated document.deleted document.restored comment.created member.added member.role_changed"`
+}
+
+
+type WebhookModel struct {
+	Id         int       `json:"id"`
+	DocumentId *int      `json:"document_id"`
+	Url        string    `json:"url"`
+	Events     []string  `json:"events"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+
+// CreatedWebhookModel is only returned by the create endpoint: Secret signs
+// the deliveries and is not shown again.
+type CreatedWebhookModel struct {
+	WebhookModel
+	Secret string `json:"secret"`
+}
+
+
+// WebhookTargetModel is what the dispatcher needs to send one delivery.
+type WebhookTargetModel struct {
+	DeliveryId int64
+	WebhookId  int
+	Url        string
+	Secret     string
+	EventId    string
+	EventType  string
+	Payload    json.RawMessage
+	Attempts   int
+}
+
+
+type WebhookDeliveryModel struct {
+	Id           int64           `json:"id"`
+	EventId      string          `json:"event_id"`
+	EventType    string          `json:"event_type"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode *int            `json:"response_code"`
+	LastError    *string         `json:"last_error"`
+	DurationMs   *int            `json:"duration_ms"`
+	NextAttempt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt  *time.Time      `json:"delivered_at"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+
+// WebhookResultModel is the outcome of one HTTP attempt.
+type WebhookResultModel struct {
+	ResponseCode int
+	Error        error
+	Duration     time.Duration
+}
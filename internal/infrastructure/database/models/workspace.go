@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+
+type CreateWorkspaceModel struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+
+// WorkspaceModel is a workspace as seen by one member, Role being theirs.
+type WorkspaceModel struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+
+type WorkspaceMemberModel struct {
+	User     BaseUserModel `json:"user"`
+	Role     string        `json:"role"`
+	JoinedAt time.Time     `json:"joined_at"`
+}
+
+
+type InviteWorkspaceMemberModel struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=admin editor viewer"`
+}
+
+
+type UpdateWorkspaceRoleModel struct {
+	Role string `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+
+// MoveDocumentModel moves a document into a workspace, or back into the
+// personal space of the caller when WorkspaceId is null.
+type MoveDocumentModel struct {
+	WorkspaceId *int `json:"workspace_id"`
+}
@@ -1,9 +1,11 @@
 package apierrors
 
 import (
+	"errors"
 	"log"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func CheckDBError(err error, itemName string) *APIError {
@@ -14,4 +16,11 @@ func CheckDBError(err error, itemName string) *APIError {
 		log.Printf("Internal Server Error: %v", err)
 		return &ErrInternalServerError
 	}
-}
\ No newline at end of file
+}
+
+
+// IsUniqueViolation reports whether err is a unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
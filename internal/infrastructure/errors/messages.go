@@ -3,6 +3,7 @@ package apierrors
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -30,6 +31,18 @@ var (
 	ErrItemNotFound = func (itemName string) *APIError {
 		return &APIError{Code: http.StatusNotFound, Message: fmt.Sprintf("%s not found", itemName)}
 	}
+	ErrLoginLocked = func (retryAfter time.Duration) *APIError {
+		return &APIError{Code: http.StatusTooManyRequests, Message: map[string]any{
+			"detail": "too many failed login attempts, try again later",
+			"retry_after": int(retryAfter.Seconds()) + 1,
+		}}
+	}
+	ErrMissingScope = func (scope string) *APIError {
+		return &APIError{Code: http.StatusForbidden, Message: map[string]string{
+			"detail": "insufficient scope",
+			"missing_scope": scope,
+		}}
+	}
 	ErrUserAlreadyExist = APIError{Code: http.StatusConflict, Message: "user already exists"}
 	ErrInternalServerError = APIError{Code: http.StatusInternalServerError, Message: "internal server error"}
 	ErrInvalidRequestBody = APIError{Code: http.StatusBadRequest, Message: "invalid request body"}
@@ -38,10 +51,46 @@ var (
 	ErrInvalidToken = APIError{Code: http.StatusUnauthorized, Message: "invalid token"}
 	ErrInvaliLoginData = APIError{Code: http.StatusUnauthorized, Message: "invalid login data"}
 	ErrDocumentAccessDenied = APIError{Code: http.StatusForbidden, Message: "access to document denied"}
+	ErrInvalidApiKey = APIError{Code: http.StatusUnauthorized, Message: "invalid api key"}
+	ErrInvalidInvite = APIError{Code: http.StatusBadRequest, Message: "invite is invalid or expired"}
+	ErrOidcProviderNotFound = APIError{Code: http.StatusNotFound, Message: "identity provider not found"}
+	ErrOidcLoginFailed = APIError{Code: http.StatusUnauthorized, Message: "external login failed"}
+	ErrOidcEmailNotVerified = APIError{Code: http.StatusForbidden, Message: "identity provider email is not verified"}
+	ErrWorkspaceAccessDenied = APIError{Code: http.StatusForbidden, Message: "access to workspace denied"}
+	ErrLastWorkspaceOwner = APIError{Code: http.StatusConflict, Message: "workspace must keep at least one owner"}
+	ErrFolderAccessDenied = APIError{Code: http.StatusForbidden, Message: "access to folder denied"}
+	ErrFolderCycle = APIError{Code: http.StatusConflict, Message: "folder cannot be moved into itself or its subfolders"}
+	ErrFolderTooDeep = APIError{Code: http.StatusConflict, Message: "folders are nested too deeply"}
+	ErrFolderSpaceMismatch = APIError{Code: http.StatusConflict, Message: "folder belongs to a different workspace"}
+	ErrAdminRequired = APIError{Code: http.StatusForbidden, Message: "administrator access required"}
+	ErrInvalidQuery = APIError{Code: http.StatusBadRequest, Message: "invalid query parameters"}
+	ErrInvalidImport = APIError{Code: http.StatusBadRequest, Message: "import must be UTF-8 Markdown, HTML or block JSON, or a zip archive of such files"}
+	ErrImportTooLarge = APIError{Code: http.StatusRequestEntityTooLarge, Message: "import exceeds the size limits"}
+	ErrDocumentChanged = APIError{Code: http.StatusConflict, Message: "document changed since the given revision"}
+	ErrNotDocumentMember = APIError{Code: http.StatusConflict, Message: "new owner must already have access to the document"}
+	ErrAlreadyOwner = APIError{Code: http.StatusConflict, Message: "user already owns the document"}
+	ErrBlockNotFound = APIError{Code: http.StatusConflict, Message: "block not found, the document may have changed"}
+	ErrAttachmentTooLarge = APIError{Code: http.StatusRequestEntityTooLarge, Message: "attachment exceeds the size limit"}
+	ErrAttachmentType = APIError{Code: http.StatusUnsupportedMediaType, Message: "attachment type is not allowed"}
+	ErrWebhookUrl = APIError{Code: http.StatusBadRequest, Message: "webhook url must be an http or https url on a public address"}
 )
 
 
 
+// NewContentError reports an invalid block tree: where in the submitted
+// JSON the problem is and why.
+func NewContentError(path string, reason string) *APIError {
+	return &APIError{
+		Code: http.StatusBadRequest,
+		Message: map[string]string{
+			"detail": "invalid content",
+			"path":   path,
+			"reason": reason,
+		},
+	}
+}
+
+
 func NewValidationError(errs validator.ValidationErrors) *APIError {
 	validationErrors := make(map[string]string)
 	for _, err := range errs {
@@ -0,0 +1,543 @@
+package jwk
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+
+type SigningKey struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	CreatedAt time.Time
+	// RetiresAt is zero for the active key. A rotated key keeps validating
+	// until every token it could have signed has expired.
+	RetiresAt time.Time
+}
+
+
+func (key *SigningKey) Public() crypto.PublicKey {
+	return key.Private.Public()
+}
+
+
+const (
+	// createdHeader is the PEM header holding when a key was generated. File
+	// times are not used, since copying or restoring the directory changes them.
+	createdHeader = "Created"
+	// lockFile guards key generation, so that of several instances sharing
+	// Dir only one rotates.
+	lockFile = "rotate.lock"
+	// staleLock is when a lock left behind by a crashed instance is broken.
+	staleLock = 2 * time.Minute
+	lockWait  = 30 * time.Second
+	// rotationCheck is how often the directory is re-read and the active key
+	// checked for rotation.
+	rotationCheck = 5 * time.Minute
+	// reloadCooldown limits how often an unknown kid re-reads the directory.
+	reloadCooldown = 10 * time.Second
+)
+
+
+// KeyRing holds the asymmetric signing keys. Keys live in Dir as <kid>.pem
+// files; the newest one signs, older ones only verify until RetainFor after
+// they were superseded. Dir may be shared by several instances: each re-reads
+// it regularly and when it meets an unknown kid.
+type KeyRing struct {
+	Dir        string
+	Method     jwt.SigningMethod
+	RetainFor  time.Duration
+	mutex      sync.RWMutex
+	keys       []*SigningKey
+	lastReload time.Time
+}
+
+
+func LoadKeyRing(dir string, method jwt.SigningMethod, retainFor time.Duration) (*KeyRing, error) {
+	ring := &KeyRing{Dir: dir, Method: method, RetainFor: retainFor}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := ring.Reload(); err != nil {
+		return nil, err
+	}
+	if err := ring.rotateIfNeeded(0); err != nil {
+		return nil, err
+	}
+	ring.Prune()
+	return ring, nil
+}
+
+
+// Reload re-reads Dir, picking up keys other instances generated and
+// dropping the ones they pruned. Keys already known are not parsed again.
+func (ring *KeyRing) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(ring.Dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	ring.mutex.RLock()
+	known := make(map[string]*SigningKey, len(ring.keys))
+	for _, key := range ring.keys {
+		known[key.Kid] = key
+	}
+	ring.mutex.RUnlock()
+
+	keys := make([]*SigningKey, 0, len(paths))
+	for _, path := range paths {
+		if key, ok := known[strings.TrimSuffix(filepath.Base(path), ".pem")]; ok {
+			keys = append(keys, key)
+			continue
+		}
+
+		key, err := readSigningKey(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// Pruned by another instance since the glob.
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load signing key %s: %w", path, err)
+		}
+		if key.Method.Alg() != ring.Method.Alg() {
+			log.Printf("Signing key %s uses %s, configured method is %s; keeping it for validation only", key.Kid, key.Method.Alg(), ring.Method.Alg())
+		}
+		keys = append(keys, key)
+	}
+
+	ring.mutex.Lock()
+	defer ring.mutex.Unlock()
+
+	ring.lastReload = time.Now()
+	if len(keys) == 0 && len(ring.keys) > 0 {
+		// Never drop the active key over a directory that reads empty.
+		return nil
+	}
+	ring.keys = keys
+	ring.sortAndRetire()
+	return nil
+}
+
+
+func (ring *KeyRing) Active() *SigningKey {
+	ring.mutex.RLock()
+	defer ring.mutex.RUnlock()
+
+	if len(ring.keys) == 0 {
+		return nil
+	}
+	return ring.keys[len(ring.keys)-1]
+}
+
+
+// Lookup finds a key that has not retired yet. An unknown kid may have been
+// generated by another instance, so it re-reads Dir at most every
+// reloadCooldown.
+func (ring *KeyRing) Lookup(kid string) (*SigningKey, bool) {
+	if key, ok := ring.lookup(kid); ok {
+		return key, true
+	}
+	if !ring.reloadDue() {
+		return nil, false
+	}
+	if err := ring.Reload(); err != nil {
+		log.Printf("Failed to reload signing keys: %v", err)
+	}
+	return ring.lookup(kid)
+}
+
+
+func (ring *KeyRing) lookup(kid string) (*SigningKey, bool) {
+	ring.mutex.RLock()
+	defer ring.mutex.RUnlock()
+
+	now := time.Now()
+	for _, key := range ring.keys {
+		if key.Kid == kid {
+			if !key.RetiresAt.IsZero() && now.After(key.RetiresAt) {
+				return nil, false
+			}
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+
+func (ring *KeyRing) PublicSet() Set {
+	ring.mutex.RLock()
+	defer ring.mutex.RUnlock()
+
+	set := Set{Keys: make([]Key, 0, len(ring.keys))}
+	now := time.Now()
+	for _, key := range ring.keys {
+		if !key.RetiresAt.IsZero() && now.After(key.RetiresAt) {
+			continue
+		}
+		public, err := FromPublicKey(key.Kid, key.Method.Alg(), key.Public())
+		if err != nil {
+			log.Printf("Skipping signing key %s in JWKS: %v", key.Kid, err)
+			continue
+		}
+		set.Keys = append(set.Keys, public)
+	}
+	return set
+}
+
+
+// reloadDue reports whether Dir was last read more than reloadCooldown ago
+// and claims the next reload if so.
+func (ring *KeyRing) reloadDue() bool {
+	ring.mutex.Lock()
+	defer ring.mutex.Unlock()
+
+	if time.Since(ring.lastReload) < reloadCooldown {
+		return false
+	}
+	ring.lastReload = time.Now()
+	return true
+}
+
+
+// needsRotation reports whether there is no active key for the configured
+// method or, when maxAge is positive, the active one is older than maxAge.
+func (ring *KeyRing) needsRotation(maxAge time.Duration) bool {
+	active := ring.Active()
+	if active == nil || active.Method.Alg() != ring.Method.Alg() {
+		return true
+	}
+	return maxAge > 0 && time.Since(active.CreatedAt) >= maxAge
+}
+
+
+// rotateIfNeeded generates a new key when needsRotation says so. The check
+// is repeated under the directory lock after re-reading Dir, so an instance
+// that lost the race picks up the key the winner wrote instead of adding
+// another one.
+func (ring *KeyRing) rotateIfNeeded(maxAge time.Duration) error {
+	if !ring.needsRotation(maxAge) {
+		return nil
+	}
+
+	unlock, err := ring.lockDir()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := ring.Reload(); err != nil {
+		return err
+	}
+	if !ring.needsRotation(maxAge) {
+		return nil
+	}
+	_, err = ring.Rotate()
+	return err
+}
+
+
+// lockDir takes the rotation lock file in Dir, waiting up to lockWait for
+// another instance to release it. The returned function releases it.
+func (ring *KeyRing) lockDir() (func(), error) {
+	path := filepath.Join(ring.Dir, lockFile)
+	deadline := time.Now().Add(lockWait)
+
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			file.Close()
+			return func() {
+				if err := os.Remove(path); err != nil {
+					log.Printf("Failed to release key rotation lock: %v", err)
+				}
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to take key rotation lock: %w", err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			log.Printf("Breaking stale key rotation lock %s", path)
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for key rotation lock %s", path)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+
+// Rotate generates a new active key and schedules the previous one for
+// retirement. Instances sharing Dir should go through rotateIfNeeded, which
+// holds the directory lock.
+func (ring *KeyRing) Rotate() (*SigningKey, error) {
+	private, err := generatePrivateKey(ring.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key := &SigningKey{
+		Kid:       fmt.Sprintf("%s-%d-%x", strings.ToLower(ring.Method.Alg()), now.Unix(), suffix),
+		Method:    ring.Method,
+		Private:   private,
+		CreatedAt: now,
+	}
+
+	if err := writeSigningKey(filepath.Join(ring.Dir, key.Kid+".pem"), key); err != nil {
+		return nil, err
+	}
+
+	ring.mutex.Lock()
+	ring.keys = append(ring.keys, key)
+	ring.sortAndRetire()
+	ring.mutex.Unlock()
+
+	log.Printf("Rotated JWT signing key, new kid %s", key.Kid)
+	return key, nil
+}
+
+
+// Prune forgets keys whose retirement time has passed and removes their files.
+func (ring *KeyRing) Prune() {
+	ring.mutex.Lock()
+	defer ring.mutex.Unlock()
+
+	now := time.Now()
+	kept := ring.keys[:0]
+	for _, key := range ring.keys {
+		if !key.RetiresAt.IsZero() && now.After(key.RetiresAt) {
+			if err := os.Remove(filepath.Join(ring.Dir, key.Kid+".pem")); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to remove retired signing key %s: %v", key.Kid, err)
+			}
+			continue
+		}
+		kept = append(kept, key)
+	}
+	ring.keys = kept
+}
+
+
+// StartRotation re-reads Dir, rotates the active key once it is older than
+// interval and prunes retired keys, every rotationCheck until ctx is
+// cancelled. A zero interval keeps the keys in sync without rotating.
+func (ring *KeyRing) StartRotation(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(rotationCheck)
+		defer ticker.Stop()
+
+		for {
+			if err := ring.Reload(); err != nil {
+				log.Printf("Failed to reload signing keys: %v", err)
+			}
+			if interval > 0 {
+				if err := ring.rotateIfNeeded(interval); err != nil {
+					log.Printf("JWT key rotation failed: %v", err)
+				}
+			}
+			ring.Prune()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+
+func (ring *KeyRing) sortAndRetire() {
+	sort.Slice(ring.keys, func(i, j int) bool {
+		return ring.keys[i].CreatedAt.Before(ring.keys[j].CreatedAt)
+	})
+	for i, key := range ring.keys {
+		if i == len(ring.keys)-1 {
+			key.RetiresAt = time.Time{}
+		} else {
+			key.RetiresAt = ring.keys[i+1].CreatedAt.Add(ring.RetainFor)
+		}
+	}
+}
+
+
+func FromPublicKey(kid string, alg string, public crypto.PublicKey) (Key, error) {
+	key := Key{Kid: kid, Alg: alg, Use: "sig"}
+
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		key.Kty = "RSA"
+		key.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		key.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		key.Kty = "EC"
+		key.Crv = public.Curve.Params().Name
+		key.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+		key.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+
+	case ed25519.PublicKey:
+		key.Kty = "OKP"
+		key.Crv = "Ed25519"
+		key.X = base64.RawURLEncoding.EncodeToString(public)
+
+	default:
+		return Key{}, fmt.Errorf("unsupported public key type %T", public)
+	}
+	return key, nil
+}
+
+
+func generatePrivateKey(method jwt.SigningMethod) (crypto.Signer, error) {
+	switch method.Alg() {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "EdDSA":
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	default:
+		return nil, fmt.Errorf("unsupported signing method %s", method.Alg())
+	}
+}
+
+
+func writeSigningKey(path string, key *SigningKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+
+	block := &pem.Block{
+		Type: "PRIVATE KEY",
+		Headers: map[string]string{
+			"Alg":         key.Method.Alg(),
+			createdHeader: key.CreatedAt.UTC().Format(time.RFC3339Nano),
+		},
+		Bytes: der,
+	}
+
+	// Write next to the target and rename, so that other instances reading
+	// the directory never see half a key.
+	file, err := os.CreateTemp(filepath.Dir(path), ".key-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(pem.EncodeToMemory(block)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+
+// keyCreatedAt reads when a key was generated from its PEM header. Keys
+// written before the header was added fall back to the Unix time in their
+// kid and only then to the file time.
+func keyCreatedAt(block *pem.Block, kid string, path string) (time.Time, error) {
+	if created := block.Headers[createdHeader]; created != "" {
+		return time.Parse(time.RFC3339Nano, created)
+	}
+
+	if parts := strings.Split(kid, "-"); len(parts) == 3 {
+		if seconds, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			return time.Unix(seconds, 0), nil
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+
+func readSigningKey(path string) (*SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+
+	alg := block.Headers["Alg"]
+	if alg == "" {
+		switch signer.(type) {
+		case *rsa.PrivateKey:
+			alg = "RS256"
+		case *ecdsa.PrivateKey:
+			alg = "ES256"
+		case ed25519.PrivateKey:
+			alg = "EdDSA"
+		}
+	}
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, fmt.Errorf("unknown signing method %q", alg)
+	}
+
+	kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+	createdAt, err := keyCreatedAt(block, kid, path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", createdHeader, err)
+	}
+
+	return &SigningKey{
+		Kid:       kid,
+		Method:    method,
+		Private:   signer,
+		CreatedAt: createdAt,
+	}, nil
+}
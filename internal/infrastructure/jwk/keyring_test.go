@@ -0,0 +1,327 @@
+package jwk
+
+import (
+	"crypto"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+
+type publicKey interface {
+	Equal(crypto.PublicKey) bool
+}
+
+
+func pemFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+
+func TestSigningKeyRoundTrip(t *testing.T) {
+	methods := []jwt.SigningMethod{
+		jwt.SigningMethodRS256,
+		jwt.SigningMethodPS256,
+		jwt.SigningMethodES256,
+		jwt.SigningMethodES384,
+		jwt.SigningMethodEdDSA,
+	}
+
+	for _, method := range methods {
+		t.Run(method.Alg(), func(t *testing.T) {
+			private, err := generatePrivateKey(method)
+			if err != nil {
+				t.Fatalf("generatePrivateKey: %v", err)
+			}
+			key := &SigningKey{
+				Kid:       "test-1-00",
+				Method:    method,
+				Private:   private,
+				CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC),
+			}
+
+			path := filepath.Join(t.TempDir(), key.Kid+".pem")
+			if err := writeSigningKey(path, key); err != nil {
+				t.Fatalf("writeSigningKey: %v", err)
+			}
+			read, err := readSigningKey(path)
+			if err != nil {
+				t.Fatalf("readSigningKey: %v", err)
+			}
+
+			if read.Kid != key.Kid {
+				t.Errorf("Kid = %q, want %q", read.Kid, key.Kid)
+			}
+			if read.Method.Alg() != method.Alg() {
+				t.Errorf("Alg = %q, want %q", read.Method.Alg(), method.Alg())
+			}
+			if !read.CreatedAt.Equal(key.CreatedAt) {
+				t.Errorf("CreatedAt = %v, want %v", read.CreatedAt, key.CreatedAt)
+			}
+			if !read.Public().(publicKey).Equal(key.Public()) {
+				t.Error("public key changed in the round trip")
+			}
+
+			jwk, err := FromPublicKey(key.Kid, method.Alg(), key.Public())
+			if err != nil {
+				t.Fatalf("FromPublicKey: %v", err)
+			}
+			public, err := jwk.PublicKey()
+			if err != nil {
+				t.Fatalf("PublicKey: %v", err)
+			}
+			if !key.Public().(publicKey).Equal(public) {
+				t.Error("JWK does not decode to the signing key's public key")
+			}
+		})
+	}
+}
+
+
+func TestKeyCreatedAt(t *testing.T) {
+	modTime := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		kid     string
+		headers map[string]string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:    "header",
+			kid:     "es256-1700000000-abcd",
+			headers: map[string]string{createdHeader: "2026-03-04T05:06:07.000000008Z"},
+			want:    time.Date(2026, 3, 4, 5, 6, 7, 8, time.UTC),
+		},
+		{
+			name: "time in kid",
+			kid:  "es256-1700000000-abcd",
+			want: time.Unix(1700000000, 0),
+		},
+		{
+			name: "file time",
+			kid:  "imported",
+			want: modTime,
+		},
+		{
+			name:    "invalid header",
+			kid:     "es256-1700000000-abcd",
+			headers: map[string]string{createdHeader: "yesterday"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.kid+".pem")
+			if err := os.WriteFile(path, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := keyCreatedAt(&pem.Block{Headers: tt.headers}, tt.kid, path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("keyCreatedAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("keyCreatedAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+
+	ring, err := LoadKeyRing(dir, jwt.SigningMethodES256, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadKeyRing: %v", err)
+	}
+	active := ring.Active()
+	if active == nil {
+		t.Fatal("no active key in an empty directory")
+	}
+	if files := pemFiles(t, dir); len(files) != 1 {
+		t.Fatalf("got %d key files, want 1", len(files))
+	}
+
+	// A second instance picks up the existing key instead of generating one.
+	other, err := LoadKeyRing(dir, jwt.SigningMethodES256, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadKeyRing: %v", err)
+	}
+	if other.Active().Kid != active.Kid {
+		t.Errorf("second instance signs with %s, want %s", other.Active().Kid, active.Kid)
+	}
+	if files := pemFiles(t, dir); len(files) != 1 {
+		t.Errorf("got %d key files after reload, want 1", len(files))
+	}
+
+	// A different method rotates, and the old key keeps validating.
+	switched, err := LoadKeyRing(dir, jwt.SigningMethodEdDSA, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadKeyRing: %v", err)
+	}
+	if alg := switched.Active().Method.Alg(); alg != "EdDSA" {
+		t.Errorf("active key uses %s after switching method, want EdDSA", alg)
+	}
+	if _, ok := switched.Lookup(active.Kid); !ok {
+		t.Error("key of the previous method no longer validates")
+	}
+}
+
+
+func TestKeyRingRotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAge      time.Duration
+		wantRotated bool
+	}{
+		{name: "never", maxAge: 0, wantRotated: false},
+		{name: "not due", maxAge: time.Hour, wantRotated: false},
+		{name: "due", maxAge: time.Nanosecond, wantRotated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := LoadKeyRing(t.TempDir(), jwt.SigningMethodES256, time.Hour)
+			if err != nil {
+				t.Fatalf("LoadKeyRing: %v", err)
+			}
+			previous := ring.Active()
+
+			if err := ring.rotateIfNeeded(tt.maxAge); err != nil {
+				t.Fatalf("rotateIfNeeded: %v", err)
+			}
+			active := ring.Active()
+			if rotated := active.Kid != previous.Kid; rotated != tt.wantRotated {
+				t.Fatalf("rotated = %v, want %v", rotated, tt.wantRotated)
+			}
+			if !tt.wantRotated {
+				return
+			}
+
+			if !active.RetiresAt.IsZero() {
+				t.Errorf("active key retires at %v", active.RetiresAt)
+			}
+			if want := active.CreatedAt.Add(ring.RetainFor); !previous.RetiresAt.Equal(want) {
+				t.Errorf("previous key retires at %v, want %v", previous.RetiresAt, want)
+			}
+			if _, ok := ring.Lookup(previous.Kid); !ok {
+				t.Error("previous key stopped validating before it retired")
+			}
+			if got := len(ring.PublicSet().Keys); got != 2 {
+				t.Errorf("JWKS has %d keys, want 2", got)
+			}
+		})
+	}
+}
+
+
+func TestKeyRingPrune(t *testing.T) {
+	dir := t.TempDir()
+
+	ring, err := LoadKeyRing(dir, jwt.SigningMethodES256, 0)
+	if err != nil {
+		t.Fatalf("LoadKeyRing: %v", err)
+	}
+	previous := ring.Active()
+	if _, err := ring.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// With no retention the previous key retired when the new one was made.
+	if _, ok := ring.Lookup(previous.Kid); ok {
+		t.Error("retired key still validates")
+	}
+	for _, key := range ring.PublicSet().Keys {
+		if key.Kid == previous.Kid {
+			t.Error("retired key is still published")
+		}
+	}
+
+	ring.Prune()
+	if _, err := os.Stat(filepath.Join(dir, previous.Kid+".pem")); !os.IsNotExist(err) {
+		t.Errorf("retired key file was not removed: %v", err)
+	}
+	if files := pemFiles(t, dir); len(files) != 1 {
+		t.Errorf("got %d key files after pruning, want 1", len(files))
+	}
+}
+
+
+func TestKeyRingLookupReloads(t *testing.T) {
+	dir := t.TempDir()
+
+	ring, err := LoadKeyRing(dir, jwt.SigningMethodES256, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadKeyRing: %v", err)
+	}
+	other, err := LoadKeyRing(dir, jwt.SigningMethodES256, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadKeyRing: %v", err)
+	}
+	rotated, err := other.Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// The ring was read just now, so the cooldown keeps it from re-reading.
+	if _, ok := ring.Lookup(rotated.Kid); ok {
+		t.Error("unknown kid found without reloading")
+	}
+
+	ring.mutex.Lock()
+	ring.lastReload = time.Time{}
+	ring.mutex.Unlock()
+
+	if _, ok := ring.Lookup(rotated.Kid); !ok {
+		t.Error("key generated by another instance not found after the cooldown")
+	}
+	if ring.Active().Kid != rotated.Kid {
+		t.Errorf("active key is %s, want %s", ring.Active().Kid, rotated.Kid)
+	}
+}
+
+
+func TestLockDir(t *testing.T) {
+	dir := t.TempDir()
+	ring := &KeyRing{Dir: dir, Method: jwt.SigningMethodES256}
+	path := filepath.Join(dir, lockFile)
+
+	// A lock left behind by a crashed instance is broken.
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(path, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := ring.lockDir()
+	if err != nil {
+		t.Fatalf("lockDir: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("lock file missing while held: %v", err)
+	}
+
+	unlock()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file left after unlock: %v", err)
+	}
+}
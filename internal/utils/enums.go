@@ -3,4 +3,115 @@ package utils
 const (
 	AccessToken = "access"
 	RefreshToken = "refresh"
-)
\ No newline at end of file
+)
+
+const (
+	ScopeDocumentsRead = "documents:read"
+	ScopeDocumentsWrite = "documents:write"
+	ScopeComments = "comments"
+	// ScopeAccount covers profile and credential management; it is never
+	// granted to API keys.
+	ScopeAccount = "account"
+)
+
+const ApiKeyPrefix = "dk"
+
+const (
+	AuditLoginLockout = "auth.lockout"
+	AuditLoginSuccess = "auth.login.success"
+	AuditLoginFailure = "auth.login.failure"
+	AuditTokenRefresh = "auth.token.refresh"
+	AuditPasswordChange = "auth.password.change"
+	AuditDocumentCreate = "document.create"
+	AuditDocumentVisibility = "document.visibility"
+	AuditDocumentDelete = "document.delete"
+	AuditDocumentRestore = "document.restore"
+	AuditDocumentPurge = "document.purge"
+	AuditMemberRole = "document.member.role"
+	AuditInviteSend = "document.invite.send"
+	AuditInviteAccept = "document.invite.accept"
+	AuditDocumentTransfer = "document.transfer"
+	AuditWorkspaceCreate = "workspace.create"
+	AuditWorkspaceInviteSend = "workspace.invite.send"
+	AuditWorkspaceInviteAccept = "workspace.invite.accept"
+	AuditWorkspaceMemberRole = "workspace.member.role"
+	AuditWorkspaceMemberRemove = "workspace.member.remove"
+	AuditDocumentFolder = "document.folder"
+	AuditFolderMove = "folder.move"
+	AuditFolderShare = "folder.share"
+	AuditFolderUnshare = "folder.unshare"
+	AuditDocumentDuplicate = "document.duplicate"
+	AuditDocumentOwner = "document.owner"
+)
+
+const (
+	EventDocumentCreated = "document.created"
+	EventDocumentUpdated = "document.updated"
+	EventDocumentDeleted = "document.deleted"
+	EventDocumentRestored = "document.restored"
+	EventCommentCreated = "comment.created"
+	EventMemberAdded = "member.added"
+	EventMemberRoleChanged = "member.role_changed"
+	EventUserRegistered = "user.registered"
+)
+
+const EventWebhookTest = "webhook.test"
+
+const (
+	WebhookPending = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed = "failed"
+)
+
+const (
+	NotificationInvite = "invite"
+	NotificationMention = "mention"
+	NotificationReply = "reply"
+	NotificationRoleChanged = "role_changed"
+	NotificationDocumentDeleted = "document_deleted"
+	NotificationWorkspaceInvite = "workspace_invite"
+)
+
+const (
+	RoleOwner = "owner"
+	RoleAdmin = "admin"
+	RoleViewer = "viewer"
+	RoleCommenter = "commenter"
+	RoleEditor = "editor"
+)
+
+const (
+	TemplateScopeBuiltin = "builtin"
+	TemplateScopePersonal = "personal"
+	TemplateScopeWorkspace = "workspace"
+)
+
+const (
+	LinkOk = "ok"
+	LinkMissing = "missing"
+	LinkInaccessible = "inaccessible"
+)
+
+const (
+	FolderItemFolder = "folder"
+	FolderItemDocument = "document"
+)
+
+const (
+	DigestOff = "off"
+	DigestDaily = "daily"
+	DigestWeekly = "weekly"
+)
+
+const (
+	ActivityCreated = "created"
+	ActivityEdited = "edited"
+	ActivityCommented = "commented"
+	ActivityMemberJoined = "member_joined"
+	ActivityRoleChanged = "role_changed"
+	ActivitySnapshotCreated = "snapshot_created"
+	ActivitySnapshotRestored = "snapshot_restored"
+	ActivityTrashed = "trashed"
+	ActivityRestored = "restored"
+	ActivityOwnerChanged = "owner_changed"
+)
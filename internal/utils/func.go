@@ -1,12 +1,17 @@
 package utils
 
 import (
+	cryptorand "crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	apierrors "golang/internal/infrastructure/errors"
 	"math/rand"
+	"net"
 	"net/http"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -61,6 +66,28 @@ func RandSeq(n int) string {
 }
 
 
+// RandomToken returns n bytes from crypto/rand encoded as unpadded base64url,
+// for anything that must not be guessable (state, nonces, keys).
+func RandomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := cryptorand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+
+// RandomHex returns n bytes from crypto/rand as lowercase hex, for random
+// identifiers that must stay within [0-9a-f].
+func RandomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := cryptorand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+
 func GetLimitAndOffset(request *http.Request) (int, int) {
 	limit := 10
 	offset := 0
@@ -81,6 +108,62 @@ func GetLimitAndOffset(request *http.Request) (int, int) {
 }
 
 
+// GetClientIP returns the peer address of the request. X-Real-IP is only
+// honoured when the peer itself is a loopback or private address, i.e. a
+// reverse proxy in front of the API, so clients cannot spoof it directly.
+func GetClientIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+
+	if peer := net.ParseIP(host); peer != nil && (peer.IsLoopback() || peer.IsPrivate()) {
+		if realIp := net.ParseIP(strings.TrimSpace(request.Header.Get("X-Real-IP"))); realIp != nil {
+			return realIp.String()
+		}
+	}
+	return host
+}
+
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]+)`)
+
+
+// ParseMentions returns the distinct @usernames in text, without the @.
+func ParseMentions(text string) []string {
+	seen := make(map[string]bool)
+	mentions := make([]string, 0)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username != "" && !seen[username] {
+			seen[username] = true
+			mentions = append(mentions, username)
+		}
+	}
+	return mentions
+}
+
+
+// Excerpt shortens text to at most n runes for previews.
+func Excerpt(text string, n int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n]) + "…"
+}
+
+
+// SameId reports whether two optional ids are both unset or equal, e.g. the
+// workspaces of a document and a folder.
+func SameId(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+
 func WriteJSONResponse(w http.ResponseWriter, status int, data interface{}) error {
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(status)
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS digest_frequency,
+    DROP COLUMN IF EXISTS last_digest_at;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN digest_frequency TEXT NOT NULL DEFAULT 'daily' CHECK (digest_frequency IN ('off', 'daily', 'weekly')),
+    ADD COLUMN last_digest_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,13 @@
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+DROP TRIGGER IF EXISTS audit_log_no_update ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_immutable();
+
+DROP INDEX IF EXISTS audit_log_created_idx;
+DROP INDEX IF EXISTS audit_log_document_idx;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS document_id;
+
+UPDATE audit_log SET actor_id = NULL WHERE actor_id NOT IN (SELECT id FROM users);
+ALTER TABLE audit_log
+    ADD CONSTRAINT audit_log_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL;
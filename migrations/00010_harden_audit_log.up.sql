@@ -0,0 +1,23 @@
+-- Audit entries must outlive the users and documents they mention, so the
+-- actor reference becomes a plain column and the table is append-only.
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_actor_id_fkey;
+ALTER TABLE audit_log ADD COLUMN document_id INTEGER;
+
+CREATE INDEX audit_log_document_idx ON audit_log (document_id, created_at) WHERE document_id IS NOT NULL;
+CREATE INDEX audit_log_created_idx ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_immutable();
+
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
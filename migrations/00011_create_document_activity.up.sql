@@ -0,0 +1,14 @@
+CREATE TABLE document_activity (
+    id BIGSERIAL PRIMARY KEY,
+    document_id INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL,
+    -- Number of actions folded into the entry, e.g. saves in an edit burst.
+    count INTEGER NOT NULL DEFAULT 1,
+    data JSONB NOT NULL DEFAULT '{}',
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX document_activity_document_idx ON document_activity (document_id, id DESC);
@@ -0,0 +1,7 @@
+DROP FUNCTION IF EXISTS document_role(INTEGER, INTEGER);
+
+DROP INDEX IF EXISTS documents_workspace_idx;
+ALTER TABLE documents DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
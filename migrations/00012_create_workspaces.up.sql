@@ -0,0 +1,58 @@
+CREATE TABLE workspaces (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'editor', 'viewer')),
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX workspace_members_user_idx ON workspace_members (user_id);
+
+ALTER TABLE documents ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id) ON DELETE RESTRICT;
+CREATE INDEX documents_workspace_idx ON documents (workspace_id) WHERE workspace_id IS NOT NULL;
+
+-- document_role is the single definition of what a user may do with a
+-- document: 'owner', 'editor', 'commenter', 'viewer' or NULL for no access.
+-- The strongest of these grants wins:
+--   * documents.owner_id                     -> owner
+--   * workspace role owner/admin             -> owner
+--   * workspace role editor/viewer           -> same role
+--   * documents_users role (NULL means viewer)
+CREATE FUNCTION document_role(doc_id INTEGER, uid INTEGER) RETURNS TEXT AS $$
+    SELECT grants.role
+    FROM (
+        SELECT 'owner' AS role, 4 AS rank
+        FROM documents d
+        WHERE d.id = doc_id AND d.owner_id = uid
+
+        UNION ALL
+
+        SELECT
+            CASE WHEN wm.role IN ('owner', 'admin') THEN 'owner' ELSE wm.role END,
+            CASE wm.role WHEN 'owner' THEN 4 WHEN 'admin' THEN 4 WHEN 'editor' THEN 3 ELSE 1 END
+        FROM documents d
+        JOIN workspace_members wm ON wm.workspace_id = d.workspace_id
+        WHERE d.id = doc_id AND wm.user_id = uid
+
+        UNION ALL
+
+        SELECT
+            COALESCE(r.name, 'viewer'),
+            CASE r.name WHEN 'editor' THEN 3 WHEN 'commenter' THEN 2 ELSE 1 END
+        FROM documents_users du
+        LEFT JOIN roles r ON r.id = du.role_id
+        WHERE du.document_id = doc_id AND du.user_id = uid
+    ) AS grants
+    ORDER BY grants.rank DESC
+    LIMIT 1
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,37 @@
+-- Restore document_role as defined in 00012, without folder inheritance.
+CREATE OR REPLACE FUNCTION document_role(doc_id INTEGER, uid INTEGER) RETURNS TEXT AS $$
+    SELECT grants.role
+    FROM (
+        SELECT 'owner' AS role, 4 AS rank
+        FROM documents d
+        WHERE d.id = doc_id AND d.owner_id = uid
+
+        UNION ALL
+
+        SELECT
+            CASE WHEN wm.role IN ('owner', 'admin') THEN 'owner' ELSE wm.role END,
+            CASE wm.role WHEN 'owner' THEN 4 WHEN 'admin' THEN 4 WHEN 'editor' THEN 3 ELSE 1 END
+        FROM documents d
+        JOIN workspace_members wm ON wm.workspace_id = d.workspace_id
+        WHERE d.id = doc_id AND wm.user_id = uid
+
+        UNION ALL
+
+        SELECT
+            COALESCE(r.name, 'viewer'),
+            CASE r.name WHEN 'editor' THEN 3 WHEN 'commenter' THEN 2 ELSE 1 END
+        FROM documents_users du
+        LEFT JOIN roles r ON r.id = du.role_id
+        WHERE du.document_id = doc_id AND du.user_id = uid
+    ) AS grants
+    ORDER BY grants.rank DESC
+    LIMIT 1
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS folder_role(INTEGER, INTEGER);
+
+DROP INDEX IF EXISTS documents_folder_idx;
+ALTER TABLE documents DROP COLUMN IF EXISTS folder_id;
+
+DROP TABLE IF EXISTS folder_members;
+DROP TABLE IF EXISTS folders;
@@ -0,0 +1,117 @@
+CREATE TABLE folders (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    parent_id INTEGER REFERENCES folders(id) ON DELETE RESTRICT,
+    owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    workspace_id INTEGER REFERENCES workspaces(id) ON DELETE RESTRICT,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CHECK (parent_id <> id)
+);
+
+CREATE INDEX folders_parent_idx ON folders (parent_id);
+CREATE INDEX folders_owner_root_idx ON folders (owner_id) WHERE parent_id IS NULL;
+CREATE INDEX folders_workspace_root_idx ON folders (workspace_id) WHERE parent_id IS NULL;
+
+CREATE TABLE folder_members (
+    folder_id INTEGER NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('editor', 'commenter', 'viewer')),
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (folder_id, user_id)
+);
+
+CREATE INDEX folder_members_user_idx ON folder_members (user_id);
+
+ALTER TABLE documents ADD COLUMN folder_id INTEGER REFERENCES folders(id) ON DELETE SET NULL;
+CREATE INDEX documents_folder_idx ON documents (folder_id) WHERE folder_id IS NOT NULL;
+
+-- folder_role is the effective role of a user on a folder. Grants on any
+-- ancestor apply to everything below it:
+--   * folders.owner_id                       -> owner
+--   * workspace role owner/admin             -> owner
+--   * workspace role editor/viewer           -> same role
+--   * folder_members role
+-- The depth bound only guards against corrupt data; moves reject cycles.
+CREATE FUNCTION folder_role(f_id INTEGER, uid INTEGER) RETURNS TEXT AS $$
+    WITH RECURSIVE chain AS (
+        SELECT f.id, f.parent_id, f.owner_id, f.workspace_id, 1 AS depth
+        FROM folders f
+        WHERE f.id = f_id
+
+        UNION ALL
+
+        SELECT f.id, f.parent_id, f.owner_id, f.workspace_id, chain.depth + 1
+        FROM folders f
+        JOIN chain ON f.id = chain.parent_id
+        WHERE chain.depth < 64
+    )
+    SELECT grants.role
+    FROM (
+        SELECT 'owner' AS role, 4 AS rank
+        FROM chain
+        WHERE chain.owner_id = uid
+
+        UNION ALL
+
+        SELECT
+            CASE WHEN wm.role IN ('owner', 'admin') THEN 'owner' ELSE wm.role END,
+            CASE wm.role WHEN 'owner' THEN 4 WHEN 'admin' THEN 4 WHEN 'editor' THEN 3 ELSE 1 END
+        FROM chain
+        JOIN workspace_members wm ON wm.workspace_id = chain.workspace_id
+        WHERE wm.user_id = uid
+
+        UNION ALL
+
+        SELECT fm.role, CASE fm.role WHEN 'editor' THEN 3 WHEN 'commenter' THEN 2 ELSE 1 END
+        FROM chain
+        JOIN folder_members fm ON fm.folder_id = chain.id
+        WHERE fm.user_id = uid
+    ) AS grants
+    ORDER BY grants.rank DESC
+    LIMIT 1
+$$ LANGUAGE sql STABLE;
+
+-- document_role additionally inherits the role on the containing folder.
+CREATE OR REPLACE FUNCTION document_role(doc_id INTEGER, uid INTEGER) RETURNS TEXT AS $$
+    SELECT grants.role
+    FROM (
+        SELECT 'owner' AS role, 4 AS rank
+        FROM documents d
+        WHERE d.id = doc_id AND d.owner_id = uid
+
+        UNION ALL
+
+        SELECT
+            CASE WHEN wm.role IN ('owner', 'admin') THEN 'owner' ELSE wm.role END,
+            CASE wm.role WHEN 'owner' THEN 4 WHEN 'admin' THEN 4 WHEN 'editor' THEN 3 ELSE 1 END
+        FROM documents d
+        JOIN workspace_members wm ON wm.workspace_id = d.workspace_id
+        WHERE d.id = doc_id AND wm.user_id = uid
+
+        UNION ALL
+
+        SELECT
+            COALESCE(r.name, 'viewer'),
+            CASE r.name WHEN 'editor' THEN 3 WHEN 'commenter' THEN 2 ELSE 1 END
+        FROM documents_users du
+        LEFT JOIN roles r ON r.id = du.role_id
+        WHERE du.document_id = doc_id AND du.user_id = uid
+
+        UNION ALL
+
+        SELECT
+            inherited.role,
+            CASE inherited.role WHEN 'owner' THEN 4 WHEN 'editor' THEN 3 WHEN 'commenter' THEN 2 ELSE 1 END
+        FROM (
+            SELECT folder_role(d.folder_id, uid) AS role
+            FROM documents d
+            WHERE d.id = doc_id AND d.folder_id IS NOT NULL
+        ) AS inherited
+        WHERE inherited.role IS NOT NULL
+    ) AS grants
+    ORDER BY grants.rank DESC
+    LIMIT 1
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,7 @@
+DROP FUNCTION IF EXISTS document_role(INTEGER, INTEGER);
+ALTER FUNCTION document_role_ignoring_trash(INTEGER, INTEGER) RENAME TO document_role;
+
+DROP INDEX IF EXISTS documents_deleted_at_idx;
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,17 @@
+ALTER TABLE documents
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX documents_deleted_at_idx ON documents (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- document_role_ignoring_trash keeps the grant rules; it only decides who may
+-- restore or purge a trashed document.
+ALTER FUNCTION document_role(INTEGER, INTEGER) RENAME TO document_role_ignoring_trash;
+
+-- document_role grants nothing on trashed documents, so every access check
+-- and query filter built on it hides the trash without further changes.
+CREATE FUNCTION document_role(doc_id INTEGER, uid INTEGER) RETURNS TEXT AS $$
+    SELECT document_role_ignoring_trash(d.id, uid)
+    FROM documents d
+    WHERE d.id = doc_id AND d.deleted_at IS NULL
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS documents_reset_blocks ON documents;
+DROP FUNCTION IF EXISTS documents_reset_blocks();
+
+ALTER TABLE documents DROP COLUMN IF EXISTS blocks;
@@ -0,0 +1,17 @@
+-- blocks is the structured form of content. content stays the Markdown the
+-- editor, snapshots and exports work with; blocks is NULL until it is first
+-- read and is rebuilt from content after any write that only changes content.
+ALTER TABLE documents ADD COLUMN blocks JSONB;
+
+CREATE FUNCTION documents_reset_blocks() RETURNS trigger AS $$
+BEGIN
+    IF NEW.content IS DISTINCT FROM OLD.content AND NEW.blocks IS NOT DISTINCT FROM OLD.blocks THEN
+        NEW.blocks := NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER documents_reset_blocks
+    BEFORE UPDATE OF content ON documents
+    FOR EACH ROW EXECUTE FUNCTION documents_reset_blocks();
@@ -0,0 +1,20 @@
+-- A template is personal (owner_id, removed with its owner) or shared with a
+-- workspace (workspace_id). created_by is kept for workspace templates so
+-- their author can still edit them.
+CREATE TABLE document_templates (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    owner_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CHECK ((owner_id IS NULL) <> (workspace_id IS NULL))
+);
+
+CREATE INDEX document_templates_owner_idx ON document_templates (owner_id) WHERE owner_id IS NOT NULL;
+CREATE INDEX document_templates_workspace_idx ON document_templates (workspace_id) WHERE workspace_id IS NOT NULL;
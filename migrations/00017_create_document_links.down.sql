@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS documents_index_links ON documents;
+DROP FUNCTION IF EXISTS documents_index_links();
+DROP FUNCTION IF EXISTS index_document_links(INTEGER, TEXT);
+
+DROP INDEX IF EXISTS documents_lower_title_idx;
+DROP TABLE IF EXISTS document_links;
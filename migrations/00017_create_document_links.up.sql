@@ -0,0 +1,55 @@
+-- document_links indexes the links in document content: references to
+-- /documents/{id} (target_id) and wiki links [[Title]] or [[Title|text]]
+-- (target_title). target_id has no foreign key so a link to a purged
+-- document is still reported as broken. Title links are resolved when read,
+-- against the documents of the same workspace or personal space, so renames
+-- and deletions need no reindexing.
+CREATE TABLE document_links (
+    id SERIAL PRIMARY KEY,
+    source_id INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    target_id INTEGER,
+    target_title TEXT,
+    CHECK ((target_id IS NULL) <> (target_title IS NULL))
+);
+
+CREATE UNIQUE INDEX document_links_source_target_idx ON document_links (source_id, target_id) WHERE target_id IS NOT NULL;
+CREATE UNIQUE INDEX document_links_source_title_idx ON document_links (source_id, lower(target_title)) WHERE target_title IS NOT NULL;
+CREATE INDEX document_links_target_idx ON document_links (target_id) WHERE target_id IS NOT NULL;
+CREATE INDEX document_links_title_idx ON document_links (lower(target_title)) WHERE target_title IS NOT NULL;
+CREATE INDEX documents_lower_title_idx ON documents (lower(title));
+
+-- index_document_links replaces the links of a document with those in body.
+-- Links to the document itself are left out. Code is not told apart from
+-- prose, so links inside code blocks are indexed too.
+CREATE FUNCTION index_document_links(doc_id INTEGER, body TEXT) RETURNS void AS $$
+    DELETE FROM document_links WHERE source_id = doc_id;
+
+    INSERT INTO document_links (source_id, target_id)
+    SELECT DISTINCT doc_id, m[1]::int
+    FROM regexp_matches(COALESCE(body, ''), '/documents/([0-9]{1,9})\M', 'g') AS m
+    WHERE m[1]::int <> doc_id;
+
+    INSERT INTO document_links (source_id, target_title)
+    SELECT DISTINCT ON (lower(title)) doc_id, title
+    FROM (
+        SELECT left(btrim(m[1]), 255) AS title
+        FROM regexp_matches(COALESCE(body, ''), '\[\[([^][|\n]+)(\|[^][\n]*)?\]\]', 'g') AS m
+    ) links
+    WHERE title <> ''
+    ORDER BY lower(title), title;
+$$ LANGUAGE sql;
+
+CREATE FUNCTION documents_index_links() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.content IS DISTINCT FROM OLD.content THEN
+        PERFORM index_document_links(NEW.id, NEW.content);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER documents_index_links
+    AFTER INSERT OR UPDATE OF content ON documents
+    FOR EACH ROW EXECUTE FUNCTION documents_index_links();
+
+SELECT index_document_links(id, content) FROM documents;
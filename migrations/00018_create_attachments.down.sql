@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS attachments_queue_deletion ON attachments;
+DROP FUNCTION IF EXISTS attachments_queue_deletion();
+
+DROP TABLE IF EXISTS attachment_deletions;
+DROP TABLE IF EXISTS attachments;
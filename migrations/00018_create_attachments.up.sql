@@ -0,0 +1,36 @@
+-- Attachment files live in blob storage under storage_key; the row holds
+-- what the upload was sniffed as and how it was named.
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    document_id INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    uploaded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX attachments_document_idx ON attachments (document_id);
+
+-- attachment_deletions queues the blobs of deleted attachments, however the
+-- row went (directly, with a purged document or by cascade), for the
+-- cleaner to remove from storage after the deleting transaction commits.
+CREATE TABLE attachment_deletions (
+    id SERIAL PRIMARY KEY,
+    storage_key TEXT NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE FUNCTION attachments_queue_deletion() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO attachment_deletions (storage_key) VALUES (OLD.storage_key);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER attachments_queue_deletion
+    AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION attachments_queue_deletion();
@@ -0,0 +1,5 @@
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS word_count,
+    DROP COLUMN IF EXISTS character_count,
+    DROP COLUMN IF EXISTS outline,
+    DROP COLUMN IF EXISTS last_editor_id;
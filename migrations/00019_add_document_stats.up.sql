@@ -0,0 +1,17 @@
+-- Statistics of the content, written with every content change. They are
+-- NULL until computed; documents written before this migration get them on
+-- their next read. last_editor_id is the last user who changed the content,
+-- taken from the activity feed for existing documents.
+ALTER TABLE documents
+    ADD COLUMN word_count INTEGER,
+    ADD COLUMN character_count INTEGER,
+    ADD COLUMN outline JSONB,
+    ADD COLUMN last_editor_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE documents d
+SET last_editor_id = (
+    SELECT a.actor_id FROM document_activity a
+    WHERE a.document_id = d.id AND a.kind IN ('created', 'edited', 'snapshot_restored')
+    ORDER BY a.updated_at DESC, a.id DESC
+    LIMIT 1
+);
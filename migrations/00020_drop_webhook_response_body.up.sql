@@ -0,0 +1,3 @@
+-- Receiver responses are no longer kept: the delivery log only records the
+-- status code, so it cannot be used to read what an address returns.
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS response_body;
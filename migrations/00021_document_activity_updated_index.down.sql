@@ -0,0 +1 @@
+DROP INDEX IF EXISTS document_activity_updated_idx;
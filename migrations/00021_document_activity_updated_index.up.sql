@@ -0,0 +1,2 @@
+-- The digest reads recent activity across all documents by updated_at.
+CREATE INDEX document_activity_updated_idx ON document_activity (updated_at);
@@ -0,0 +1,42 @@
+package templates
+
+
+// Samples is the preview data for every email, shaped like what the senders
+// put in MailMessageModel.Data.
+var Samples = map[string]map[string]any{
+	"invite_member": {
+		"DocumentTitle": "Quarterly report",
+		"DocumentId":    "42",
+		"AccessCode":    "aBcDeF",
+		"InviteUrl":     "https://www.fasttaski.ru/42/invite/aBcDeF",
+	},
+	"workspace_invite": {
+		"WorkspaceName": "Design team",
+		"WorkspaceId":   "7",
+		"InviterName":   "alice",
+		"Role":          "editor",
+		"InviteUrl":     "https://www.fasttaski.ru/workspaces/7/invite/aBcDeF",
+	},
+	"account_locked": {
+		"Username":    "alice",
+		"Ip":          "203.0.113.7",
+		"LockedUntil": "19.10.2026 14:30 UTC",
+	},
+	"digest": {
+		"Username":  "alice",
+		"Frequency": "daily",
+		"Documents": []map[string]any{
+			{"Title": "Quarterly report", "Edits": 12, "Editors": "bob, carol", "Comments": 3, "Commenters": "bob"},
+			{"Title": "Roadmap", "Edits": 0, "Editors": "", "Comments": 1, "Commenters": "dave"},
+		},
+		"Mentions": []map[string]any{
+			{"DocumentTitle": "Roadmap", "Author": "dave", "Excerpt": "@alice can you check the Q3 dates?"},
+		},
+	},
+}
+
+
+// Sample returns the preview data for name, or nil if there is none.
+func Sample(name string) map[string]any {
+	return Samples[name]
+}
@@ -0,0 +1,139 @@
+// Package templates holds the transactional email templates, embedded into
+// the binary. Every email <name> has, per locale, <locale>/<name>.html
+// defining "heading" and "content", and <locale>/<name>.txt defining
+// "subject" and "content". Both parts are wrapped in the shared layout and
+// the locale's common footer.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+
+//go:embed layout.html layout.txt ru en
+var files embed.FS
+
+
+const DefaultLocale = "ru"
+
+
+var Locales = []string{"ru", "en"}
+
+
+type Email struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+
+type compiled struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+
+var (
+	cache = make(map[string]*compiled)
+	mutex sync.Mutex
+)
+
+
+// Locale returns locale if there are templates for it and DefaultLocale
+// otherwise. Region suffixes are ignored ("en-GB" -> "en").
+func Locale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if base, _, found := strings.Cut(locale, "-"); found {
+		locale = base
+	}
+	if slices.Contains(Locales, locale) {
+		return locale
+	}
+	return DefaultLocale
+}
+
+
+// Names lists the available emails, based on the default locale.
+func Names() []string {
+	entries, _ := fs.ReadDir(files, DefaultLocale)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name, found := strings.CutSuffix(entry.Name(), ".txt")
+		if found && name != "common" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+
+func load(name string, locale string) (*compiled, error) {
+	key := locale + "/" + name
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if t, ok := cache[key]; ok {
+		return t, nil
+	}
+
+	funcs := map[string]any{"locale": func() string { return locale }}
+
+	html, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFS(
+		files, "layout.html", path.Join(locale, "common.html"), path.Join(locale, name+".html"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := texttemplate.New("layout.txt").Funcs(funcs).ParseFS(
+		files, "layout.txt", path.Join(locale, "common.txt"), path.Join(locale, name+".txt"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &compiled{html: html, text: text}
+	cache[key] = t
+	return t, nil
+}
+
+
+// Render renders email name in locale (falling back to DefaultLocale).
+func Render(name string, locale string, data any) (*Email, error) {
+	if !slices.Contains(Names(), name) {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+
+	t, err := load(name, Locale(locale))
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, html, text bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := t.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	return &Email{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}